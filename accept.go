@@ -0,0 +1,135 @@
+package scanner
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// AcceptLanguage holds the languages of an Accept-Language header ordered by their weights,
+// it can be the destination of a `header:"accept-language"` field.
+type AcceptLanguage []language.Tag
+
+func (a *AcceptLanguage) UnmarshalString(s string) error {
+	tags, _, err := language.ParseAcceptLanguage(s)
+	if err != nil {
+		return err
+	}
+
+	*a = tags
+	return nil
+}
+
+// Match returns the supported language that best matches the accepted languages,
+// the first supported language is returned when there is no good match.
+func (a AcceptLanguage) Match(supported ...language.Tag) language.Tag {
+	if len(supported) == 0 {
+		return language.Und
+	}
+
+	_, index, _ := language.NewMatcher(supported).Match(a...)
+	return supported[index]
+}
+
+// MediaRange is a single entry of an Accept header
+type MediaRange struct {
+	Type    string
+	Subtype string
+	Params  map[string]string
+	Q       float64
+}
+
+// matches reports whether the media type t/sub falls in the range
+func (m MediaRange) matches(t, sub string) bool {
+	return (m.Type == "*" || strings.EqualFold(m.Type, t)) && (m.Subtype == "*" || strings.EqualFold(m.Subtype, sub))
+}
+
+// specificity ranks ranges so that `text/html` is preferred over `text/*` and `*/*`
+func (m MediaRange) specificity() int {
+	switch {
+	case m.Type == "*":
+		return 0
+	case m.Subtype == "*":
+		return 1
+	default:
+		return 2 + len(m.Params)
+	}
+}
+
+// Accept holds the media ranges of an Accept header ordered by their weights,
+// it can be the destination of a `header:"accept"` field.
+type Accept []MediaRange
+
+func (a *Accept) UnmarshalString(s string) error {
+	ranges := Accept{}
+
+	for _, element := range splitList(s) {
+		parts := strings.Split(element, ";")
+		t, sub, ok := strings.Cut(strings.TrimSpace(parts[0]), "/")
+		if !ok || t == "" || sub == "" {
+			continue
+		}
+
+		m := MediaRange{Type: strings.ToLower(t), Subtype: strings.ToLower(sub), Params: map[string]string{}, Q: 1}
+		for _, param := range parts[1:] {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			key = strings.ToLower(strings.TrimSpace(key))
+			value = strings.Trim(strings.TrimSpace(value), `"`)
+
+			if key == "q" {
+				q, err := strconv.ParseFloat(value, 64)
+				if err != nil || q < 0 || q > 1 {
+					q = 0
+				}
+				m.Q = q
+				continue
+			}
+			m.Params[key] = value
+		}
+		ranges = append(ranges, m)
+	}
+
+	slices.SortStableFunc(ranges, func(a, b MediaRange) int {
+		if c := cmp.Compare(b.Q, a.Q); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.specificity(), a.specificity())
+	})
+
+	*a = ranges
+	return nil
+}
+
+// Negotiate returns the offered content type the client prefers the most, or an empty string when none
+// of them is acceptable. An empty Accept header accepts the first offer.
+func (a Accept) Negotiate(offers ...string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	if len(a) == 0 {
+		return offers[0]
+	}
+
+	best := ""
+	bestQ := 0.0
+	for _, offer := range offers {
+		t, sub, _ := strings.Cut(offer, "/")
+
+		var match *MediaRange
+		for i, m := range a {
+			if m.matches(t, sub) && (match == nil || m.specificity() > match.specificity()) {
+				match = &a[i]
+			}
+		}
+
+		if match != nil && match.Q > bestQ {
+			best = offer
+			bestQ = match.Q
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,317 @@
+// Package tagcheck defines an analyzer that checks the struct tags the scanners of this module bind.
+// It reports unknown tag options, field types no cast path exists for, duplicate keys and tagged
+// unexported fields.
+package tagcheck
+
+import (
+	"go/ast"
+	"go/types"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "scannertags",
+	Doc:      "check struct tags bound by github.com/canpacis/scanner",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// options are extra tag options to accept, like the names of custom sanitizers
+var options string
+
+func init() {
+	Analyzer.Flags.StringVar(&options, "options", "", "comma separated list of extra tag options to accept")
+}
+
+// source is the kind of value a tag key binds
+type source int
+
+const (
+	// text keys bind strings that are cast to the field type
+	text source = iota
+	// file keys bind file contents
+	file
+	// upload keys bind multipart files
+	upload
+	// picture keys bind decoded images
+	picture
+)
+
+// keys are the tag keys checked by the analyzer, keys with their own option syntax like `fixed` and `bin`
+// and keys shared with other libraries like `json` are not checked
+var keys = map[string]source{
+	"header":    text,
+	"query":     text,
+	"cookie":    text,
+	"form":      text,
+	"path":      text,
+	"forwarded": text,
+	"link":      text,
+	"pattern":   text,
+	"html":      text,
+	"secret":    text,
+	"env":       text,
+	"kv":        text,
+	"flag":      text,
+	"file":      file,
+	"multipart": upload,
+	"image":     picture,
+}
+
+var knownOptions = map[string]bool{
+	"min":           true,
+	"max":           true,
+	"minlen":        true,
+	"maxlen":        true,
+	"locale":        true,
+	"layout":        true,
+	"tz":            true,
+	"secret":        true,
+	"trim":          true,
+	"lower":         true,
+	"upper":         true,
+	"nfkc":          true,
+	"discriminator": true,
+	"md5":           true,
+	"sha256":        true,
+	"format":        true,
+	"quality":       true,
+	"blurhash":      true,
+	"color":         true,
+	"required":      true,
+	"default":       true,
+	"base64":        true,
+	"remain":        true,
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	extra := map[string]bool{}
+	for _, option := range strings.Split(options, ",") {
+		if option = strings.TrimSpace(option); option != "" {
+			extra[option] = true
+		}
+	}
+
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.Preorder([]ast.Node{(*ast.StructType)(nil)}, func(n ast.Node) {
+		checkStruct(pass, n.(*ast.StructType), extra)
+	})
+
+	return nil, nil
+}
+
+func checkStruct(pass *analysis.Pass, st *ast.StructType, extra map[string]bool) {
+	seen := map[string]string{}
+
+	for _, field := range st.Fields.List {
+		if field.Tag == nil {
+			continue
+		}
+		raw, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			continue
+		}
+		tag := reflect.StructTag(raw)
+		typ := pass.TypesInfo.TypeOf(field.Type)
+
+		for _, key := range slices.Sorted(maps.Keys(keys)) {
+			src := keys[key]
+			value, ok := tag.Lookup(key)
+			if !ok {
+				continue
+			}
+
+			for _, name := range field.Names {
+				if !name.IsExported() {
+					pass.Reportf(name.Pos(), "unexported field %s has a %s tag and is never bound", name.Name, key)
+				}
+			}
+
+			parts := strings.Split(value, ",")
+			id := key + ":" + parts[0]
+			if prev, ok := seen[id]; ok {
+				pass.Reportf(field.Tag.Pos(), "duplicate %s tag %q, already used by %s", key, parts[0], prev)
+			} else if len(field.Names) > 0 {
+				seen[id] = field.Names[0].Name
+			} else {
+				seen[id] = types.TypeString(typ, nil)
+			}
+
+			remain := false
+			for _, part := range parts[1:] {
+				option, _, _ := strings.Cut(strings.TrimSpace(part), "=")
+				if option != "" && !knownOptions[option] && !extra[option] {
+					pass.Reportf(field.Tag.Pos(), "unknown %s tag option %q", key, option)
+				}
+				remain = remain || option == "remain"
+			}
+
+			if remain {
+				if typ != nil && !remainable(typ, src) {
+					pass.Reportf(field.Pos(), "remain field of %s values must be a map with string keys, not %s", sourceName(src, key), types.TypeString(typ, types.RelativeTo(pass.Pkg)))
+				}
+				continue
+			}
+			if typ != nil && !castable(typ, src) && !(key == "cookie" && isCookie(typ)) && !(key == "form" && isStructSlice(typ)) {
+				pass.Reportf(field.Pos(), "no cast path from a %s value to %s", sourceName(src, key), types.TypeString(typ, types.RelativeTo(pass.Pkg)))
+			}
+		}
+	}
+}
+
+func sourceName(src source, key string) string {
+	switch src {
+	case upload:
+		return "multipart file"
+	case picture:
+		return "image"
+	case file:
+		return "file"
+	default:
+		return key
+	}
+}
+
+// castable reports whether the scanners can bind a value of src onto type t
+func castable(t types.Type, src source) bool {
+	switch src {
+	case upload:
+		if isBytes(t) {
+			return true
+		}
+		if basic, ok := t.Underlying().(*types.Basic); ok && basic.Info()&types.IsString != 0 {
+			return true
+		}
+		return assignableFrom(t, "mime/multipart", "File")
+	case picture:
+		if isNamed(t, "github.com/canpacis/scanner", "ImageInfo") || isNamed(t, "github.com/canpacis/scanner", "ImageOutput") {
+			return true
+		}
+		if p, ok := t.(*types.Pointer); ok && isNamed(p.Elem(), "image/gif", "GIF") {
+			return true
+		}
+		return assignableFrom(t, "image", "Image")
+	case file:
+		if isBytes(t) {
+			return true
+		}
+	}
+
+	if _, ok := t.Underlying().(*types.Interface); ok {
+		return true
+	}
+	for _, method := range []string{"UnmarshalString", "UnmarshalParam", "UnmarshalText", "UnmarshalBinary", "Scan", "WrappedType"} {
+		if hasMethod(t, method) {
+			return true
+		}
+	}
+	if isNamed(t, "time", "Time") {
+		return true
+	}
+	if src == text && (isNamed(t, "image", "Point") || isNamed(t, "image", "Rectangle") || isNamed(t, "image/color", "NRGBA") || isNamed(t, "image/color", "RGBA")) {
+		return true
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		return u.Info()&(types.IsString|types.IsInteger|types.IsFloat|types.IsBoolean) != 0
+	case *types.Slice:
+		return src != file && castable(u.Elem(), text)
+	case *types.Pointer:
+		return castable(u.Elem(), src)
+	default:
+		return false
+	}
+}
+
+// remainable reports whether t is a map with string keys whose values src can be bound onto
+func remainable(t types.Type, src source) bool {
+	m, ok := t.Underlying().(*types.Map)
+	if !ok {
+		return false
+	}
+	key, ok := m.Key().Underlying().(*types.Basic)
+	return ok && key.Info()&types.IsString != 0 && castable(m.Elem(), src)
+}
+
+// isStructSlice reports whether t is a slice of structs or of pointers to them, form fields bind them from
+// indexed keys like `items[0].name`
+func isStructSlice(t types.Type) bool {
+	slice, ok := t.Underlying().(*types.Slice)
+	if !ok {
+		return false
+	}
+	elem := slice.Elem()
+	if p, ok := elem.(*types.Pointer); ok {
+		elem = p.Elem()
+	}
+	_, ok = elem.Underlying().(*types.Struct)
+	return ok
+}
+
+// isCookie reports whether t is an `http.Cookie` or a pointer to one, cookie fields receive whole cookies
+func isCookie(t types.Type) bool {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	return isNamed(t, "net/http", "Cookie")
+}
+
+// isNamed reports whether t is the named type pkg.name
+func isNamed(t types.Type, pkg, name string) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == pkg && named.Obj().Name() == name
+}
+
+func isBytes(t types.Type) bool {
+	s, ok := t.Underlying().(*types.Slice)
+	if !ok {
+		return false
+	}
+	b, ok := s.Elem().Underlying().(*types.Basic)
+	return ok && b.Kind() == types.Byte
+}
+
+// hasMethod reports whether t or its pointer has a method called name
+func hasMethod(t types.Type, name string) bool {
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(t), true, nil, name)
+	_, ok := obj.(*types.Func)
+	return ok
+}
+
+// assignableFrom reports whether a value of the named interface pkg.name can be assigned to t
+func assignableFrom(t types.Type, pkg, name string) bool {
+	if isNamed(t, pkg, name) {
+		return true
+	}
+
+	iface, ok := t.Underlying().(*types.Interface)
+	if !ok {
+		return false
+	}
+	return !hasForeignMethods(iface, pkg)
+}
+
+// hasForeignMethods reports whether iface requires methods beyond the reader methods files and images share,
+// it keeps the check free of importing the packages of the source types
+func hasForeignMethods(iface *types.Interface, pkg string) bool {
+	allowed := map[string]bool{"Read": true, "ReadAt": true, "Seek": true, "Close": true}
+	if pkg == "image" {
+		allowed = map[string]bool{"ColorModel": true, "Bounds": true, "At": true}
+	}
+
+	for i := range iface.NumMethods() {
+		if !allowed[iface.Method(i).Name()] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,13 @@
+package tagcheck_test
+
+import (
+	"testing"
+
+	"github.com/canpacis/scanner/analysis/tagcheck"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	tagcheck.Analyzer.Flags.Set("options", "custom")
+	analysistest.Run(t, analysistest.TestData(), tagcheck.Analyzer, "a")
+}
@@ -0,0 +1,46 @@
+package a
+
+import (
+	"image"
+	"image/gif"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"time"
+)
+
+type Level int
+
+type Line struct {
+	From int
+}
+
+func (l *Level) UnmarshalString(s string) error {
+	return nil
+}
+
+type Params struct {
+	Page    int               `query:"page,min=1"`
+	Tags    []string          `query:"tags"`
+	Since   time.Time         `query:"since,layout=2006-01-02"`
+	Level   Level             `header:"x-level"`
+	Name    string            `query:"name,trimm"` // want `unknown query tag option "trimm"`
+	Other   string            `query:"page"`       // want `duplicate query tag "page", already used by Page`
+	Avatar  int               `multipart:"avatar"` // want `no cast path from a multipart file value to int`
+	Upload  multipart.File    `multipart:"upload"`
+	Reader  io.Reader         `multipart:"reader"`
+	Picture image.Image       `image:"picture"`
+	Thumb   []byte            `image:"thumb"` // want `no cast path from a image value to \[\]byte`
+	Config  []byte            `file:"config.json"`
+	Done    chan bool         `query:"done"`      // want `no cast path from a query value to chan bool`
+	secret  string            `header:"x-secret"` // want `unexported field secret has a header tag and is never bound`
+	Slug    string            `path:"slug,lower,custom"`
+	Frames  *gif.GIF          `image:"frames"`
+	Size    image.Point       `query:"size"`
+	Session *http.Cookie      `cookie:"session"`
+	Theme   http.Cookie       `query:"theme"` // want `no cast path from a query value to net/http.Cookie`
+	Extra   map[string]string `query:",remain"`
+	Lines   []Line            `form:"lines"`
+	Points  []Line            `query:"points"`   // want `no cast path from a query value to \[\]Line`
+	Rest    []string          `header:",remain"` // want `remain field of header values must be a map with string keys, not \[\]string`
+}
@@ -0,0 +1,142 @@
+package scanner
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrUnknownArchive is returned by `scanner.NewArchive` when the stream is not a zip, tar or gzipped tar archive
+var ErrUnknownArchive = errors.New("scanner: unknown archive format")
+
+// NewArchive creates a directory scanner of the members of a zip, tar or gzipped tar stream. The format
+// is sniffed from the content and members are keyed by their slash separated path like `file:"theme/style.css"`.
+// The options of `scanner.NewDirectory` apply to the members, and gzipped tars are limited like compressed bodies.
+func NewArchive(r io.Reader, opts ...Option) (*Directory, error) {
+	c := newConfig(opts)
+
+	b, err := io.ReadAll(c.reader(r))
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(b, []byte("PK\x03\x04")), bytes.HasPrefix(b, []byte("PK\x05\x06")):
+		zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+		if err != nil {
+			return nil, err
+		}
+		return NewDirectory(zr, opts...)
+	case bytes.HasPrefix(b, []byte{0x1f, 0x8b}):
+		gr, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		defer gr.Close()
+		limit := c.maxDecompressed
+		if limit <= 0 {
+			limit = DefaultMaxDecompressedBytes
+		}
+		return newTarDirectory(&limitedReader{r: gr, n: limit, limit: limit}, c, opts)
+	case len(b) > 262 && string(b[257:262]) == "ustar":
+		return newTarDirectory(bytes.NewReader(b), c, opts)
+	default:
+		return nil, ErrUnknownArchive
+	}
+}
+
+// newTarDirectory reads the regular files of a tar stream into a directory scanner. Members the directory
+// options filter out are skipped and members over the size limit are kept without their content, so they
+// fail only when a field binds them like the files of `scanner.NewDirectory`.
+func newTarDirectory(r io.Reader, c *config, opts []Option) (*Directory, error) {
+	fsys := tarFS{}
+	tr := tar.NewReader(r)
+	root := path.Clean(c.directory.root)
+
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if !fs.ValidPath(name) {
+			continue
+		}
+		rel := name
+		if root != "." {
+			var ok bool
+			if rel, ok = strings.CutPrefix(name, root+"/"); !ok {
+				continue
+			}
+		}
+		if !c.directory.allowed(rel) {
+			continue
+		}
+
+		member := &tarMember{size: header.Size, modTime: header.ModTime}
+		if limit := c.directory.maxSize; limit <= 0 || header.Size <= limit {
+			if member.data, err = io.ReadAll(tr); err != nil {
+				return nil, err
+			}
+		}
+		fsys[name] = member
+	}
+
+	return NewDirectory(fsys, opts...)
+}
+
+// tarFS is a file system of the members of a tar archive
+type tarFS map[string]*tarMember
+
+type tarMember struct {
+	data    []byte
+	size    int64
+	modTime time.Time
+}
+
+func (f tarFS) Open(name string) (fs.File, error) {
+	member, ok := f[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &tarFile{Reader: bytes.NewReader(member.data), info: tarInfo{name: path.Base(name), member: member}}, nil
+}
+
+type tarFile struct {
+	*bytes.Reader
+	info tarInfo
+}
+
+func (f *tarFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *tarFile) Close() error {
+	return nil
+}
+
+type tarInfo struct {
+	name   string
+	member *tarMember
+}
+
+func (i tarInfo) Name() string       { return i.name }
+func (i tarInfo) Size() int64        { return i.member.size }
+func (i tarInfo) Mode() fs.FileMode  { return 0444 }
+func (i tarInfo) ModTime() time.Time { return i.member.modTime }
+func (i tarInfo) IsDir() bool        { return false }
+func (i tarInfo) Sys() any           { return nil }
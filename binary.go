@@ -0,0 +1,215 @@
+package scanner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/canpacis/scanner/structd"
+)
+
+// binaryRecord is the getter of a binary payload, values are decoded from the struct tags before decoding
+// and keyed by the first element of the tag since getters only receive the tag name
+type binaryRecord map[string]any
+
+func (r binaryRecord) Get(key string) any {
+	return r[key]
+}
+
+func (r binaryRecord) Cast(from any, to reflect.Type) (any, error) {
+	if b, ok := from.([]byte); ok {
+		from = string(b)
+	}
+	return structd.DefaultCast(from, to)
+}
+
+// binaryField is a parsed `bin` tag
+type binaryField struct {
+	offset int
+	length int
+	little bool
+}
+
+func parseBinaryField(tag string, offset int) (binaryField, error) {
+	f := binaryField{offset: offset, length: -1}
+
+	for _, part := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+
+		var err error
+		switch key {
+		case "offset":
+			f.offset, err = strconv.Atoi(value)
+		case "len":
+			f.length, err = strconv.Atoi(value)
+		case "le":
+			f.little = true
+		case "be":
+			f.little = false
+		}
+		if err != nil {
+			return f, err
+		}
+	}
+	if f.offset < 0 {
+		return f, fmt.Errorf("scanner: negative bin offset %d", f.offset)
+	}
+
+	return f, nil
+}
+
+// size returns the byte length of a field of type t when the tag does not specify one,
+// -1 means the rest of the payload
+func (f binaryField) size(t reflect.Type) int {
+	if f.length >= 0 {
+		return f.length
+	}
+
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int8, reflect.Uint8:
+		return 1
+	case reflect.Int16, reflect.Uint16:
+		return 2
+	case reflect.Int32, reflect.Uint32, reflect.Float32:
+		return 4
+	case reflect.Int64, reflect.Uint64, reflect.Float64, reflect.Int, reflect.Uint:
+		return 8
+	case reflect.Array:
+		return t.Len()
+	default:
+		return -1
+	}
+}
+
+// decode converts the bytes of a field to a value of type t
+func (f binaryField) decode(b []byte, t reflect.Type) (any, error) {
+	var n uint64
+	if f.little {
+		for i := len(b) - 1; i >= 0; i-- {
+			n = n<<8 | uint64(b[i])
+		}
+	} else {
+		for _, c := range b {
+			n = n<<8 | uint64(c)
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return reflect.ValueOf(n != 0).Convert(t).Interface(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if len(b) > 8 {
+			return nil, fmt.Errorf("scanner: %d bytes overflow %s", len(b), t)
+		}
+		shift := 64 - 8*len(b)
+		return reflect.ValueOf(int64(n<<shift) >> shift).Convert(t).Interface(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if len(b) > 8 {
+			return nil, fmt.Errorf("scanner: %d bytes overflow %s", len(b), t)
+		}
+		return reflect.ValueOf(n).Convert(t).Interface(), nil
+	case reflect.Float32, reflect.Float64:
+		switch len(b) {
+		case 4:
+			return reflect.ValueOf(math.Float32frombits(uint32(n))).Convert(t).Interface(), nil
+		case 8:
+			return reflect.ValueOf(math.Float64frombits(n)).Convert(t).Interface(), nil
+		default:
+			return nil, fmt.Errorf("scanner: floats must be 4 or 8 bytes long, got %d", len(b))
+		}
+	case reflect.String:
+		return reflect.ValueOf(string(bytes.TrimRight(b, "\x00"))).Convert(t).Interface(), nil
+	case reflect.Array:
+		v := reflect.New(t).Elem()
+		reflect.Copy(v, reflect.ValueOf(b))
+		return v.Interface(), nil
+	default:
+		return bytes.Clone(b), nil
+	}
+}
+
+// newBinaryRecord decodes the fields the `bin` tags of v describe from b, fields without an offset
+// follow the previous one
+func newBinaryRecord(b []byte, v any) (binaryRecord, error) {
+	r := binaryRecord{}
+	rt := reflect.TypeOf(v)
+	if rt == nil || rt.Kind() != reflect.Pointer || rt.Elem().Kind() != reflect.Struct {
+		return r, nil
+	}
+	rt = rt.Elem()
+
+	offset := 0
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		tag, ok := field.Tag.Lookup("bin")
+		if !ok {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if _, ok := r[name]; ok {
+			return nil, fmt.Errorf("scanner: duplicate bin tag name %q of %s, give each field an explicit offset", name, field.Name)
+		}
+
+		f, err := parseBinaryField(tag, offset)
+		if err != nil {
+			return nil, err
+		}
+		size := f.size(field.Type)
+		if size < 0 {
+			size = max(len(b)-f.offset, 0)
+		}
+		offset = f.offset + size
+		if offset > len(b) {
+			return nil, fmt.Errorf("scanner: field %s at %d:%d is out of bounds of %d bytes: %w", field.Name, f.offset, offset, len(b), io.ErrUnexpectedEOF)
+		}
+
+		value, err := f.decode(b[f.offset:offset], field.Type)
+		if err != nil {
+			return nil, err
+		}
+		r[name] = value
+	}
+
+	return r, nil
+}
+
+// A scanner to scan fixed-layout binary payloads onto structs. Fields describe their layout with the `bin` tag,
+// e.g. `bin:"offset=4,len=2,le"`. The offset defaults to the end of the previous field, the length to the size
+// of the field type or the rest of the payload for strings and slices, and the byte order to big-endian.
+// Fields are keyed by the first element of their tag, which must be unique.
+type Binary struct {
+	r      io.Reader
+	config *config
+}
+
+// Scans the payload onto v
+func (s *Binary) Scan(v any) error {
+	b, err := io.ReadAll(s.r)
+	if err != nil {
+		return err
+	}
+
+	r, err := newBinaryRecord(b, v)
+	if err != nil {
+		return err
+	}
+	return s.config.decoder(r, "bin").Decode(v)
+}
+
+func NewBinary(r io.Reader, opts ...Option) *Binary {
+	c := newConfig(opts)
+
+	return &Binary{
+		r:      c.reader(r),
+		config: c,
+	}
+}
+
+func NewBinaryBytes(b []byte, opts ...Option) *Binary {
+	return NewBinary(bytes.NewBuffer(b), opts...)
+}
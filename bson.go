@@ -0,0 +1,256 @@
+package scanner
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// A scanner to scan a bson document, like a `bson.M` or raw bson bytes, onto a struct with the `bson` tag.
+// Nested documents and arrays are cast to the field types, ObjectIDs to their hex strings and
+// bson dates to `time.Time`, without depending on the mongo driver.
+type BSON struct {
+	d      *document
+	config *config
+}
+
+// Scans the document onto v
+func (s *BSON) Scan(v any) error {
+	return s.config.decoder(s.d, "bson").Decode(v)
+}
+
+func NewBSON(doc map[string]any, opts ...Option) *BSON {
+	c := newConfig(opts)
+
+	return &BSON{
+		d:      &document{values: doc, key: "bson", config: c},
+		config: c,
+	}
+}
+
+// NewBSOND creates a scanner over an ordered document, like a `bson.D`
+func NewBSOND[E ~struct {
+	Key   string
+	Value any
+}](doc []E, opts ...Option) *BSON {
+	m := make(map[string]any, len(doc))
+	for _, e := range doc {
+		kv := struct {
+			Key   string
+			Value any
+		}(e)
+		if _, ok := m[kv.Key]; !ok {
+			m[kv.Key] = kv.Value
+		}
+	}
+
+	return NewBSON(m, opts...)
+}
+
+// NewBSONRaw creates a scanner over a raw bson document, like a `bson.Raw`. Decimal128, regular expression,
+// db pointer and code with scope values are not supported.
+func NewBSONRaw(b []byte, opts ...Option) (*BSON, error) {
+	d := &bsonDecoder{b: b}
+	doc, err := d.document(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.off != len(b) {
+		return nil, fmt.Errorf("scanner: %d trailing bytes after bson document", len(b)-d.off)
+	}
+
+	return NewBSON(doc, opts...), nil
+}
+
+// bsonObjectID is an ObjectID of a raw bson document, it is cast to strings as its hex form
+type bsonObjectID [12]byte
+
+func (id bsonObjectID) Hex() string {
+	return hex.EncodeToString(id[:])
+}
+
+// maxBSONDepth limits the nesting of bson documents and arrays
+const maxBSONDepth = 100
+
+// bsonDecoder decodes raw bson into the types of a document, documents become map[string]any, arrays []any,
+// dates time.Time and ObjectIDs values with a Hex method
+type bsonDecoder struct {
+	b   []byte
+	off int
+}
+
+func (d *bsonDecoder) next(n int) ([]byte, error) {
+	if n < 0 || len(d.b)-d.off < n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := d.b[d.off : d.off+n]
+	d.off += n
+	return b, nil
+}
+
+func (d *bsonDecoder) int32() (int32, error) {
+	b, err := d.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.LittleEndian.Uint32(b)), nil
+}
+
+func (d *bsonDecoder) int64() (int64, error) {
+	b, err := d.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.LittleEndian.Uint64(b)), nil
+}
+
+// cstring reads a null terminated string
+func (d *bsonDecoder) cstring() (string, error) {
+	for i := d.off; i < len(d.b); i++ {
+		if d.b[i] == 0 {
+			s := string(d.b[d.off:i])
+			d.off = i + 1
+			return s, nil
+		}
+	}
+	return "", io.ErrUnexpectedEOF
+}
+
+// str reads a length prefixed string
+func (d *bsonDecoder) str() (string, error) {
+	n, err := d.int32()
+	if err != nil {
+		return "", err
+	}
+	b, err := d.next(int(n))
+	if err != nil {
+		return "", err
+	}
+	if n == 0 || b[n-1] != 0 {
+		return "", errors.New("scanner: bson string is not null terminated")
+	}
+	return string(b[:n-1]), nil
+}
+
+// elements reads the keys and values of a document in order
+func (d *bsonDecoder) elements(depth int) ([]string, []any, error) {
+	if depth > maxBSONDepth {
+		return nil, nil, errors.New("scanner: bson document is nested too deeply")
+	}
+
+	start := d.off
+	n, err := d.int32()
+	if err != nil {
+		return nil, nil, err
+	}
+	if n < 5 || int(n) > len(d.b)-start {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	end := start + int(n)
+
+	keys, values := []string{}, []any{}
+	for {
+		t, err := d.next(1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if t[0] == 0 {
+			if d.off != end {
+				return nil, nil, fmt.Errorf("scanner: bson document ends at %d instead of %d", d.off, end)
+			}
+			return keys, values, nil
+		}
+
+		key, err := d.cstring()
+		if err != nil {
+			return nil, nil, err
+		}
+		value, err := d.value(t[0], key, depth)
+		if err != nil {
+			return nil, nil, err
+		}
+		if d.off > end {
+			return nil, nil, io.ErrUnexpectedEOF
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+}
+
+// document reads a document, the first of duplicate keys wins like in `scanner.NewBSOND`
+func (d *bsonDecoder) document(depth int) (map[string]any, error) {
+	keys, values, err := d.elements(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := make(map[string]any, len(keys))
+	for i, key := range keys {
+		if _, ok := doc[key]; !ok {
+			doc[key] = values[i]
+		}
+	}
+	return doc, nil
+}
+
+func (d *bsonDecoder) value(t byte, key string, depth int) (any, error) {
+	switch t {
+	case 0x01:
+		b, err := d.next(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+	case 0x02, 0x0d, 0x0e:
+		return d.str()
+	case 0x03:
+		return d.document(depth + 1)
+	case 0x04:
+		_, values, err := d.elements(depth + 1)
+		return values, err
+	case 0x05:
+		n, err := d.int32()
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		// the subtype precedes the data
+		b, err := d.next(int(n) + 1)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b[1:]...), nil
+	case 0x06, 0x0a, 0x7f, 0xff:
+		return nil, nil
+	case 0x07:
+		b, err := d.next(12)
+		if err != nil {
+			return nil, err
+		}
+		return bsonObjectID(b), nil
+	case 0x08:
+		b, err := d.next(1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0] != 0, nil
+	case 0x09:
+		ms, err := d.int64()
+		return time.UnixMilli(ms).UTC(), err
+	case 0x10:
+		return d.int32()
+	case 0x11:
+		n, err := d.int64()
+		return uint64(n), err
+	case 0x12:
+		return d.int64()
+	default:
+		return nil, fmt.Errorf("scanner: unsupported bson type 0x%02x of key %q", t, key)
+	}
+}
@@ -0,0 +1,115 @@
+package scanner
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrRangeNotSatisfiable is reported when none of the requested ranges overlap the content,
+// it maps to an http 416 status
+var ErrRangeNotSatisfiable = errors.New("scanner: range not satisfiable")
+
+// maxByteRanges bounds the number of ranges of a Range header
+const maxByteRanges = 64
+
+// ByteRange is a single range of a `Range` header. The bounds are inclusive, a negative Start is a suffix
+// range of the last End bytes and a negative End is open ended, until the end of the content.
+type ByteRange struct {
+	Start int64
+	End   int64
+}
+
+// Length returns the number of bytes in a resolved range
+func (r ByteRange) Length() int64 {
+	return r.End - r.Start + 1
+}
+
+// ContentRange returns the `Content-Range` header value of a resolved range of content with the size
+func (r ByteRange) ContentRange(size int64) string {
+	return "bytes " + strconv.FormatInt(r.Start, 10) + "-" + strconv.FormatInt(r.End, 10) + "/" + strconv.FormatInt(size, 10)
+}
+
+// UnsatisfiedContentRange returns the `Content-Range` header value of a 416 response for content with the size
+func UnsatisfiedContentRange(size int64) string {
+	return "bytes */" + strconv.FormatInt(size, 10)
+}
+
+// ByteRanges holds the ranges of a `Range` header like `bytes=0-1023,2048-,-500`,
+// it can be the destination of a `header:"range"` field.
+type ByteRanges []ByteRange
+
+func (b *ByteRanges) UnmarshalString(s string) error {
+	unit, specs, ok := strings.Cut(strings.TrimSpace(s), "=")
+	if !ok || !strings.EqualFold(strings.TrimSpace(unit), "bytes") {
+		return errors.New("scanner: unsupported range unit in " + strconv.Quote(s))
+	}
+
+	ranges := ByteRanges{}
+	for _, spec := range strings.Split(specs, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		if len(ranges) == maxByteRanges {
+			return errors.New("scanner: too many byte ranges")
+		}
+
+		invalid := errors.New("scanner: invalid byte range " + strconv.Quote(spec))
+		first, last, ok := strings.Cut(spec, "-")
+		if !ok || (first == "" && last == "") {
+			return invalid
+		}
+
+		r := ByteRange{Start: -1, End: -1}
+		if first != "" {
+			n, err := strconv.ParseInt(first, 10, 64)
+			if err != nil || n < 0 {
+				return invalid
+			}
+			r.Start = n
+		}
+		if last != "" {
+			n, err := strconv.ParseInt(last, 10, 64)
+			if err != nil || n < 0 || (r.Start >= 0 && n < r.Start) {
+				return invalid
+			}
+			r.End = n
+		}
+		ranges = append(ranges, r)
+	}
+
+	if len(ranges) == 0 {
+		return errors.New("scanner: empty range header")
+	}
+
+	*b = ranges
+	return nil
+}
+
+// Resolve returns the absolute ranges of content with the size, clamping them to its end and dropping the
+// ones that start past it. It fails with `scanner.ErrRangeNotSatisfiable` when no range is left.
+func (b ByteRanges) Resolve(size int64) (ByteRanges, error) {
+	resolved := ByteRanges{}
+
+	for _, r := range b {
+		switch {
+		case r.Start < 0:
+			if r.End == 0 {
+				continue
+			}
+			r.Start = max(size-r.End, 0)
+			r.End = size - 1
+		case r.Start >= size:
+			continue
+		case r.End < 0 || r.End >= size:
+			r.End = size - 1
+		}
+		resolved = append(resolved, r)
+	}
+
+	if len(resolved) == 0 {
+		return nil, ErrRangeNotSatisfiable
+	}
+	return resolved, nil
+}
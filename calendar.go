@@ -0,0 +1,275 @@
+package scanner
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/canpacis/scanner/structd"
+)
+
+// property is a content line of a vCard or iCalendar document
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// component is a `BEGIN:NAME` ... `END:NAME` block of content lines
+type component struct {
+	name       string
+	properties []property
+	children   []*component
+	key        string
+	config     *config
+}
+
+func (c *component) lookup(name string) []property {
+	result := []property{}
+	for _, p := range c.properties {
+		if strings.EqualFold(p.name, name) {
+			result = append(result, p)
+		}
+	}
+
+	return result
+}
+
+func (c *component) Get(key string) any {
+	properties := c.lookup(key)
+	if len(properties) == 0 {
+		return nil
+	}
+
+	return properties[0]
+}
+
+func (c *component) GetAll(key string) []string {
+	values := []string{}
+	for _, p := range c.lookup(key) {
+		values = append(values, p.value)
+	}
+
+	return values
+}
+
+func (c *component) Cast(from any, to reflect.Type) (any, error) {
+	p, ok := from.(property)
+	if !ok {
+		return structd.DefaultCast(from, to)
+	}
+
+	switch {
+	case to == timeType:
+		return parseDateTime(p)
+	case to.Kind() == reflect.String:
+		return reflect.ValueOf(p.value).Convert(to).Interface(), nil
+	default:
+		return structd.DefaultCast(p.value, to)
+	}
+}
+
+// Scans the properties of the component onto v
+func (c *component) Scan(v any) error {
+	return c.config.decoder(c, c.key).Decode(v)
+}
+
+// parseDateTime parses DATE and DATE-TIME values, honoring the TZID parameter for local times
+func parseDateTime(p property) (time.Time, error) {
+	loc := time.UTC
+	if tzid, ok := p.params["TZID"]; ok {
+		l, err := time.LoadLocation(strings.TrimPrefix(tzid, "/"))
+		if err != nil {
+			return time.Time{}, err
+		}
+		loc = l
+	}
+
+	value := p.value
+	switch {
+	case strings.HasSuffix(value, "Z"):
+		return time.Parse("20060102T150405Z", value)
+	case strings.Contains(value, "T"):
+		return time.ParseInLocation("20060102T150405", value, loc)
+	case strings.Contains(value, "-"):
+		return time.ParseInLocation(time.DateOnly, value, loc)
+	default:
+		return time.ParseInLocation("20060102", value, loc)
+	}
+}
+
+var textEscapes = strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+
+// parseContentLine parses an unfolded content line like `DTSTART;TZID=Europe/Istanbul:20240101T100000`
+func parseContentLine(line string) (property, error) {
+	p := property{params: map[string]string{}}
+
+	quoted := false
+	colon := -1
+	for i := 0; i < len(line) && colon < 0; i++ {
+		switch line[i] {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				colon = i
+			}
+		}
+	}
+	if colon < 0 {
+		return p, errors.New("scanner: malformed content line " + line)
+	}
+
+	head := strings.Split(line[:colon], ";")
+	p.name = strings.ToUpper(head[0])
+	if _, name, ok := strings.Cut(p.name, "."); ok {
+		p.name = name
+	}
+	for _, param := range head[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		p.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+	p.value = textEscapes.Replace(line[colon+1:])
+
+	return p, nil
+}
+
+// parseComponents parses the components of a vCard or iCalendar document, unfolding its lines
+func parseComponents(r io.Reader) ([]*component, error) {
+	lines := []string{}
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for s.Scan() {
+		line := strings.TrimRight(s.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	root := &component{}
+	stack := []*component{root}
+	for _, line := range lines {
+		p, err := parseContentLine(line)
+		if err != nil {
+			return nil, err
+		}
+
+		current := stack[len(stack)-1]
+		switch p.name {
+		case "BEGIN":
+			c := &component{name: strings.ToUpper(p.value)}
+			current.children = append(current.children, c)
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 1 || !strings.EqualFold(current.name, p.value) {
+				return nil, errors.New("scanner: unexpected END:" + p.value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			current.properties = append(current.properties, p)
+		}
+	}
+	if len(stack) != 1 {
+		return nil, errors.New("scanner: unterminated BEGIN:" + stack[len(stack)-1].name)
+	}
+
+	return root.children, nil
+}
+
+// find returns the components with the given name, searching nested components depth first
+func find(components []*component, name string) []*component {
+	result := []*component{}
+	for _, c := range components {
+		if c.name == name {
+			result = append(result, c)
+		}
+		result = append(result, find(c.children, name)...)
+	}
+
+	return result
+}
+
+// A scanner to scan the properties of a vCard (RFC 6350) onto a struct with the `vcard` tag, e.g. `vcard:"FN"`.
+// Slice fields receive every value of a property, like multiple `EMAIL`s.
+type VCard struct {
+	r      io.Reader
+	config *config
+}
+
+// Scans the first card of the document onto v
+func (s *VCard) Scan(v any) error {
+	components, err := parseComponents(s.r)
+	if err != nil {
+		return err
+	}
+
+	cards := find(components, "VCARD")
+	if len(cards) == 0 {
+		return errors.New("scanner: no vcard found")
+	}
+
+	cards[0].key = "vcard"
+	cards[0].config = s.config
+	return cards[0].Scan(v)
+}
+
+func NewVCard(r io.Reader, opts ...Option) *VCard {
+	c := newConfig(opts)
+
+	return &VCard{
+		r:      c.reader(r),
+		config: c,
+	}
+}
+
+// A scanner to scan the properties of an iCalendar (RFC 5545) event onto a struct with the `ical` tag,
+// e.g. `ical:"DTSTART"`. Date and time values are parsed in the zone of their TZID parameter.
+type ICalendar struct {
+	events []*component
+}
+
+// Scans the first event of the calendar onto v
+func (s *ICalendar) Scan(v any) error {
+	if len(s.events) == 0 {
+		return errors.New("scanner: no vevent found")
+	}
+
+	return s.events[0].Scan(v)
+}
+
+// Events returns a scanner for each event of the calendar
+func (s *ICalendar) Events() []Scanner {
+	scanners := make([]Scanner, len(s.events))
+	for i, event := range s.events {
+		scanners[i] = event
+	}
+
+	return scanners
+}
+
+func NewICalendar(r io.Reader, opts ...Option) (*ICalendar, error) {
+	c := newConfig(opts)
+
+	components, err := parseComponents(c.reader(r))
+	if err != nil {
+		return nil, err
+	}
+
+	events := find(components, "VEVENT")
+	for _, event := range events {
+		event.key = "ical"
+		event.config = c
+	}
+
+	return &ICalendar{events: events}, nil
+}
@@ -0,0 +1,96 @@
+package scanner
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/transform"
+)
+
+// An UnsupportedCharsetError describes a body in a charset that cannot be transcoded to UTF-8,
+// it usually maps to an http 415 status.
+type UnsupportedCharsetError struct {
+	Charset string
+}
+
+func (e *UnsupportedCharsetError) Error() string {
+	return "scanner: unsupported charset " + e.Charset
+}
+
+// WithCharset makes body scanners transcode their source from charset to UTF-8, like the `charset=` parameter
+// of a `Content-Type` header. Charsets are named as in the WHATWG encoding standard, like `shift_jis` or
+// `iso-8859-1`, which is read as `windows-1252`. Raw query and form strings transcode their values once they
+// are unescaped. `scanner.NewRequest` reads the parameter on its own.
+func WithCharset(charset string) Option {
+	return func(c *config) {
+		c.charset = strings.ToLower(strings.TrimSpace(charset))
+	}
+}
+
+// charsetDecoder returns the decoder of the configured charset, nil when sources are already UTF-8
+func (c *config) charsetDecoder() (*encoding.Decoder, error) {
+	switch c.charset {
+	case "", "utf-8", "utf8", "us-ascii":
+		return nil, nil
+	}
+
+	e, err := htmlindex.Get(c.charset)
+	if err != nil {
+		return nil, &UnsupportedCharsetError{Charset: c.charset}
+	}
+	if name, _ := htmlindex.Name(e); name == "utf-8" {
+		return nil, nil
+	}
+	return e.NewDecoder(), nil
+}
+
+// transcode wraps r with the decoder of the configured charset
+func (c *config) transcode(r io.Reader) io.Reader {
+	d, err := c.charsetDecoder()
+	if err != nil {
+		return errReader{err}
+	}
+	if d == nil {
+		return r
+	}
+	return transform.NewReader(r, d)
+}
+
+// transcodeValues transcodes the keys and values of decoded url values from the configured charset
+func (c *config) transcodeValues(values url.Values) (url.Values, error) {
+	d, err := c.charsetDecoder()
+	if err != nil || d == nil {
+		return values, err
+	}
+
+	result := url.Values{}
+	for key, list := range values {
+		key, err := d.String(key)
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range list {
+			value, err := d.String(value)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = append(result[key], value)
+		}
+	}
+	return result, nil
+}
+
+// charsetOption returns the option that transcodes the body of req from the charset of its content type,
+// nil when it has none
+func charsetOption(req *http.Request) Option {
+	_, params, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if params["charset"] == "" {
+		return nil
+	}
+	return WithCharset(params["charset"])
+}
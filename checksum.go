@@ -0,0 +1,78 @@
+package scanner
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/canpacis/scanner/structd"
+)
+
+// checksums lists the digests the `md5=` and `sha256=` options of multipart tags compute
+var checksums = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha256": sha256.New,
+}
+
+// checksum sets the digests requested by the multipart tags of v, like `multipart:"document,sha256=DocumentHash"`,
+// on the fields they name. String fields receive the hex digest and byte slices the raw one. Files are rewound
+// after they are hashed.
+func (s *Multipart) checksum(v any, key string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		tag, ok := field.Tag.Lookup(key)
+		if !ok || !strings.Contains(tag, "=") {
+			continue
+		}
+
+		spec := structd.ParseTag(tag)
+		file, ok := s.v.Files[spec.Name]
+		if !ok {
+			continue
+		}
+
+		for _, algorithm := range spec.Keys() {
+			target := spec.Options[algorithm]
+			newHash, ok := checksums[algorithm]
+			if !ok {
+				continue
+			}
+
+			dst := rv.FieldByName(target)
+			if !dst.IsValid() || !dst.CanSet() {
+				return fmt.Errorf("scanner: %s checksum of %s.%s names an unknown field %q", algorithm, rt.Name(), field.Name, target)
+			}
+
+			h := newHash()
+			if _, err := io.Copy(h, file); err != nil {
+				return err
+			}
+			if _, err := file.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+
+			switch {
+			case dst.Kind() == reflect.String:
+				dst.SetString(hex.EncodeToString(h.Sum(nil)))
+			case dst.Kind() == reflect.Slice && dst.Type().Elem().Kind() == reflect.Uint8:
+				dst.SetBytes(h.Sum(nil))
+			default:
+				return fmt.Errorf("scanner: %s checksum field %s.%s must be a string or a byte slice", algorithm, rt.Name(), target)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,203 @@
+// Package client builds outgoing http requests from structs tagged with the same vocabulary the scanners read.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/canpacis/scanner/structd"
+	"github.com/canpacis/scanner/uritemplate"
+)
+
+// NewRequest builds an `*http.Request` from params, a struct or a pointer to one. `path` fields expand the
+// RFC 6570 uri template baseURL, e.g. `/users/{id}`, `query`, `header` and `cookie` fields are set on the request and
+// `json` or `form` fields make up the body. Zero values are left out.
+func NewRequest(ctx context.Context, method, baseURL string, params any) (*http.Request, error) {
+	rv := reflect.ValueOf(params)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("client: params must be a struct, got %T", params)
+	}
+	rt := rv.Type()
+
+	pathValues := map[string][]string{}
+	query := url.Values{}
+	header := http.Header{}
+	cookies := []*http.Cookie{}
+	form := url.Values{}
+	body := map[string]any{}
+
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		value := rv.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			name, options, _ := strings.Cut(tag, ",")
+			if name == "" {
+				name = field.Name
+			}
+			// fields left out of the body still fill their other tags
+			if name != "-" && !(strings.Contains(options, "omitempty") && value.IsZero()) {
+				body[name] = value.Interface()
+			}
+		}
+
+		for _, key := range []string{"path", "query", "header", "cookie", "form"} {
+			tag, ok := field.Tag.Lookup(key)
+			if !ok {
+				continue
+			}
+			name, _, _ := strings.Cut(tag, ",")
+
+			values, err := format(value)
+			if err != nil {
+				return nil, fmt.Errorf("client: field %s.%s: %w", rt.Name(), field.Name, err)
+			}
+			if len(values) == 0 {
+				continue
+			}
+
+			switch key {
+			case "path":
+				pathValues[name] = values
+			case "query":
+				query[name] = values
+			case "header":
+				header[http.CanonicalHeaderKey(name)] = values
+			case "cookie":
+				cookies = append(cookies, &http.Cookie{Name: name, Value: strings.Join(values, structd.DefaultSeperator)})
+			case "form":
+				form[name] = values
+			}
+		}
+	}
+
+	template, err := uritemplate.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// query values may fill the query expressions of the template, the rest is appended to the query string
+	for _, v := range template.Variables() {
+		switch v.Operator {
+		case '?', '&':
+			if values, ok := query[v.Name]; ok {
+				pathValues[v.Name] = values
+				delete(query, v.Name)
+			}
+		default:
+			if _, ok := pathValues[v.Name]; !ok {
+				return nil, errors.New("client: no value for path variable {" + v.Name + "}")
+			}
+		}
+	}
+
+	u, err := url.Parse(template.Expand(pathValues))
+	if err != nil {
+		return nil, err
+	}
+	if len(query) > 0 {
+		q := u.Query()
+		for key, values := range query {
+			q[key] = values
+		}
+		u.RawQuery = q.Encode()
+	}
+
+	var reader io.Reader
+	contentType := ""
+	switch {
+	case len(body) > 0:
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(b)
+		contentType = "application/json"
+	case len(form) > 0:
+		reader = strings.NewReader(form.Encode())
+		contentType = "application/x-www-form-urlencoded"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if contentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+
+	return req, nil
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// format turns a field value into its string representations, zero values produce none
+func format(v reflect.Value) ([]string, error) {
+	if v.IsZero() {
+		return nil, nil
+	}
+
+	if v.Type() == timeType {
+		return []string{v.Interface().(time.Time).Format(time.RFC3339)}, nil
+	}
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		b, err := m.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return []string{string(b)}, nil
+	}
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return []string{s.String()}, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return format(v.Elem())
+	case reflect.String:
+		return []string{v.String()}, nil
+	case reflect.Bool:
+		return []string{strconv.FormatBool(v.Bool())}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return []string{strconv.FormatInt(v.Int(), 10)}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return []string{strconv.FormatUint(v.Uint(), 10)}, nil
+	case reflect.Float32, reflect.Float64:
+		return []string{strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits())}, nil
+	case reflect.Slice, reflect.Array:
+		values := []string{}
+		for i := range v.Len() {
+			entry, err := format(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, entry...)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("%w: cannot format %s", errors.ErrUnsupported, v.Type())
+	}
+}
@@ -0,0 +1,90 @@
+package client_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/canpacis/scanner/client"
+	"github.com/stretchr/testify/assert"
+)
+
+type Params struct {
+	ID       string   `path:"id"`
+	Slug     string   `path:"slug"`
+	Page     uint32   `query:"page"`
+	IDs      []string `query:"ids"`
+	Language string   `header:"accept-language"`
+	Token    string   `cookie:"token"`
+	Email    string   `json:"email"`
+	Name     string   `json:"name,omitempty"`
+}
+
+func TestNewRequest(t *testing.T) {
+	assert := assert.New(t)
+
+	params := &Params{
+		ID:       "this is id",
+		Slug:     "slug",
+		Page:     2,
+		IDs:      []string{"a", "b"},
+		Language: "en",
+		Token:    "cookie-token",
+		Email:    "test@example.com",
+	}
+
+	req, err := client.NewRequest(context.Background(), http.MethodPost, "https://api.example.com/users/{id}/posts/{slug}?v=1", params)
+	assert.NoError(err)
+	assert.Equal("/users/this%20is%20id/posts/slug", req.URL.EscapedPath())
+	assert.Equal(url.Values{"v": {"1"}, "page": {"2"}, "ids": {"a", "b"}}, req.URL.Query())
+	assert.Equal("en", req.Header.Get("Accept-Language"))
+	assert.Equal("application/json", req.Header.Get("Content-Type"))
+
+	cookie, err := req.Cookie("token")
+	assert.NoError(err)
+	assert.Equal("cookie-token", cookie.Value)
+
+	body, err := io.ReadAll(req.Body)
+	assert.NoError(err)
+	assert.JSONEq(`{ "email": "test@example.com" }`, string(body))
+
+	_, err = client.NewRequest(context.Background(), http.MethodGet, "https://api.example.com/{missing}", params)
+	assert.Error(err)
+}
+
+func TestNewRequestTemplate(t *testing.T) {
+	assert := assert.New(t)
+
+	type Search struct {
+		Path  []string `path:"path"`
+		Query string   `query:"q"`
+		Page  int      `query:"page"`
+	}
+
+	params := Search{Path: []string{"docs", "api"}, Query: "hello world", Page: 3}
+	req, err := client.NewRequest(context.Background(), http.MethodGet, "https://example.com/search{/path*}{?q}", params)
+	assert.NoError(err)
+	assert.Equal("/search/docs/api", req.URL.Path)
+	assert.Equal(url.Values{"q": {"hello world"}, "page": {"3"}}, req.URL.Query())
+}
+
+func TestNewRequestSkippedJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	type Update struct {
+		ID    string `path:"id" json:"-"`
+		Page  int    `query:"page" json:"page,omitempty"`
+		Email string `json:"email"`
+	}
+
+	req, err := client.NewRequest(context.Background(), http.MethodPatch, "https://api.example.com/users/{id}", Update{ID: "42", Email: "test@example.com"})
+	assert.NoError(err)
+	assert.Equal("/users/42", req.URL.Path)
+	assert.Empty(req.URL.Query())
+
+	body, err := io.ReadAll(req.Body)
+	assert.NoError(err)
+	assert.JSONEq(`{ "email": "test@example.com" }`, string(body))
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// directive marks the structs to generate decoders for when no types are given
+const directive = "scanner:generate"
+
+// parsers are the strconv calls that parse the basic field types, keyed by type name
+var parsers = map[string]string{
+	"int":     "strconv.ParseInt(raw, 10, 0)",
+	"int8":    "strconv.ParseInt(raw, 10, 8)",
+	"int16":   "strconv.ParseInt(raw, 10, 16)",
+	"int32":   "strconv.ParseInt(raw, 10, 32)",
+	"int64":   "strconv.ParseInt(raw, 10, 64)",
+	"uint":    "strconv.ParseUint(raw, 10, 0)",
+	"uint8":   "strconv.ParseUint(raw, 10, 8)",
+	"uint16":  "strconv.ParseUint(raw, 10, 16)",
+	"uint32":  "strconv.ParseUint(raw, 10, 32)",
+	"uint64":  "strconv.ParseUint(raw, 10, 64)",
+	"float32": "strconv.ParseFloat(raw, 32)",
+	"float64": "strconv.ParseFloat(raw, 64)",
+	"bool":    "strconv.ParseBool(raw)",
+}
+
+// field is a tagged struct field of a generated decoder
+type field struct {
+	name string
+	typ  string
+	tag  string
+}
+
+// target is a struct to generate a decoder for
+type target struct {
+	name   string
+	fields map[string][]field
+	keys   []string
+}
+
+// generate returns the source of the generated decoders of the structs in files, types selects the structs
+// by name and the ones marked with the directive are used when it is empty
+func generate(pkg string, files []*ast.File, types []string) ([]byte, error) {
+	targets := []*target{}
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok || ts.TypeParams != nil {
+					continue
+				}
+
+				selected := slices.Contains(types, ts.Name.Name)
+				if len(types) == 0 {
+					selected = hasDirective(gen.Doc) || hasDirective(ts.Doc)
+				}
+				if selected {
+					targets = append(targets, collect(ts.Name.Name, st))
+				}
+			}
+		}
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("scannergen: no structs to generate")
+	}
+
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "// Code generated by scannergen. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+
+	body := &bytes.Buffer{}
+	for _, t := range targets {
+		write(body, t)
+	}
+	if bytes.Contains(body.Bytes(), []byte("strconv.")) {
+		b.WriteString("import (\n\t\"strconv\"\n\n\t\"github.com/canpacis/scanner/structd\"\n)\n")
+	} else {
+		b.WriteString("import \"github.com/canpacis/scanner/structd\"\n")
+	}
+	b.Write(body.Bytes())
+
+	return format.Source(b.Bytes())
+}
+
+func hasDirective(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(strings.TrimPrefix(c.Text, "//")) == directive {
+			return true
+		}
+	}
+	return false
+}
+
+// collect gathers the exported tagged fields of a struct by tag key
+func collect(name string, st *ast.StructType) *target {
+	t := &target{name: name, fields: map[string][]field{}}
+
+	for _, f := range st.Fields.List {
+		if f.Tag == nil || len(f.Names) == 0 {
+			continue
+		}
+		raw, err := strconv.Unquote(f.Tag.Value)
+		if err != nil {
+			continue
+		}
+
+		typ := ""
+		if ident, ok := f.Type.(*ast.Ident); ok {
+			typ = ident.Name
+		}
+
+		for _, key := range tagKeys(raw) {
+			value := reflect.StructTag(raw).Get(key)
+			if value == "-" {
+				continue
+			}
+			if _, ok := t.fields[key]; !ok {
+				t.keys = append(t.keys, key)
+			}
+			for _, n := range f.Names {
+				if n.IsExported() {
+					t.fields[key] = append(t.fields[key], field{name: n.Name, typ: typ, tag: value})
+				}
+			}
+		}
+	}
+
+	return t
+}
+
+// tagKeys returns the keys of a struct tag in order
+func tagKeys(tag string) []string {
+	keys := []string{}
+	for tag != "" {
+		tag = strings.TrimLeft(tag, " ")
+		i := strings.Index(tag, ":\"")
+		if i <= 0 {
+			break
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		value, err := strconv.QuotedPrefix(tag)
+		if err != nil {
+			break
+		}
+		tag = tag[len(value):]
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// write writes the generated decoder of t, fields of basic types without tag options are parsed
+// directly and every other field falls back to reflection
+func write(b *bytes.Buffer, t *target) {
+	fmt.Fprintf(b, "\n// DecodeGenerated decodes %s without reflection, see structd.GeneratedDecoder\n", t.name)
+	fmt.Fprintf(b, "func (v *%s) DecodeGenerated(d *structd.Decoder) (bool, error) {\n", t.name)
+	b.WriteString("\tswitch d.Key() {\n")
+
+	for _, key := range t.keys {
+		fmt.Fprintf(b, "\tcase %q:\n", key)
+		for _, f := range t.fields[key] {
+			name, _, options := strings.Cut(f.tag, ",")
+			parser, basic := parsers[f.typ]
+			if options || !basic && f.typ != "string" {
+				fmt.Fprintf(b, "\t\tif err := d.DecodeField(v, %q); err != nil {\n\t\t\treturn true, err\n\t\t}\n", f.name)
+				continue
+			}
+
+			fmt.Fprintf(b, "\t\tif raw, ok := d.Get(%q).(string); !ok {\n", name)
+			fmt.Fprintf(b, "\t\t\tif err := d.DecodeField(v, %q); err != nil {\n\t\t\t\treturn true, err\n\t\t\t}\n", f.name)
+			b.WriteString("\t\t} else if raw != \"\" {\n")
+			switch f.typ {
+			case "string":
+				fmt.Fprintf(b, "\t\t\tv.%s = raw\n", f.name)
+			default:
+				value := "parsed"
+				if f.typ != "int64" && f.typ != "uint64" && f.typ != "float64" && f.typ != "bool" {
+					value = f.typ + "(parsed)"
+				}
+				fmt.Fprintf(b, "\t\t\tif parsed, err := %s; err == nil {\n", parser)
+				fmt.Fprintf(b, "\t\t\t\tv.%s = %s\n", f.name, value)
+				fmt.Fprintf(b, "\t\t\t} else if err := d.DecodeField(v, %q); err != nil {\n\t\t\t\treturn true, err\n\t\t\t}\n", f.name)
+			}
+			b.WriteString("\t\t}\n")
+		}
+		b.WriteString("\t\treturn true, nil\n")
+	}
+
+	b.WriteString("\t}\n\treturn false, nil\n}\n")
+}
+
+// parseDir parses the non-test go files of dir, skipping the output file
+func parseDir(dir, output string) (string, []*ast.File, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, nil, parser.ParseComments)
+	if err != nil {
+		return "", nil, err
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(pkgs)) {
+		if strings.HasSuffix(name, "_test") {
+			continue
+		}
+
+		files := []*ast.File{}
+		for _, path := range slices.Sorted(maps.Keys(pkgs[name].Files)) {
+			if strings.HasSuffix(path, "_test.go") || strings.HasSuffix(path, output) {
+				continue
+			}
+			files = append(files, pkgs[name].Files[path])
+		}
+		return name, files, nil
+	}
+
+	return "", nil, fmt.Errorf("scannergen: no go package in %s", dir)
+}
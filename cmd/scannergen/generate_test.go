@@ -0,0 +1,55 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	assert := assert.New(t)
+
+	src := `package listing
+
+//scanner:generate
+type Listing struct {
+	Page   int    ` + "`query:\"page\"`" + `
+	Term   string ` + "`query:\"q\" header:\"x-term\"`" + `
+	Sort   string ` + "`query:\"sort,lower\"`" + `
+	Small  int8   ` + "`query:\"small\"`" + `
+	hidden string ` + "`query:\"hidden\"`" + `
+}
+
+type Other struct {
+	Name string ` + "`query:\"name\"`" + `
+}
+`
+	file, err := parser.ParseFile(token.NewFileSet(), "listing.go", src, parser.ParseComments)
+	assert.NoError(err)
+
+	out, err := generate("listing", []*ast.File{file}, nil)
+	assert.NoError(err)
+	code := string(out)
+
+	assert.True(strings.HasPrefix(code, "// Code generated by scannergen. DO NOT EDIT."))
+	assert.Contains(code, "func (v *Listing) DecodeGenerated(d *structd.Decoder) (bool, error) {")
+	assert.Contains(code, `case "query":`)
+	assert.Contains(code, `case "header":`)
+	assert.Contains(code, "v.Page = int(parsed)")
+	assert.Contains(code, "strconv.ParseInt(raw, 10, 8)")
+	assert.Contains(code, `if err := d.DecodeField(v, "Sort"); err != nil {`)
+	assert.NotContains(code, "hidden")
+	assert.NotContains(code, "Other")
+
+	out, err = generate("listing", []*ast.File{file}, []string{"Other"})
+	assert.NoError(err)
+	assert.Contains(string(out), "func (v *Other) DecodeGenerated")
+	assert.NotContains(string(out), "strconv")
+
+	_, err = generate("listing", []*ast.File{file}, []string{"Missing"})
+	assert.Error(err)
+}
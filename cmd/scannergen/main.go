@@ -0,0 +1,51 @@
+// Command scannergen generates reflection free decoders for structs bound by github.com/canpacis/scanner.
+// Mark the structs with a `//scanner:generate` comment or name them with -type and run it with go generate:
+//
+//	//go:generate go run github.com/canpacis/scanner/cmd/scannergen
+//
+// Fields of basic types without tag options are parsed directly, the rest fall back to reflection.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	types := flag.String("type", "", "comma separated list of struct names, defaults to the marked structs")
+	output := flag.String("output", "scanner_gen.go", "output file name")
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	if err := run(dir, *output, *types); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(dir, output, types string) error {
+	pkg, files, err := parseDir(dir, output)
+	if err != nil {
+		return err
+	}
+
+	names := []string{}
+	for _, name := range strings.Split(types, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	src, err := generate(pkg, files, names)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, output), src, 0o644)
+}
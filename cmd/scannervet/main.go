@@ -0,0 +1,12 @@
+// Command scannervet checks the struct tags bound by github.com/canpacis/scanner, run it with
+// `go vet -vettool=$(which scannervet) ./...`
+package main
+
+import (
+	"github.com/canpacis/scanner/analysis/tagcheck"
+	"golang.org/x/tools/go/analysis/unitchecker"
+)
+
+func main() {
+	unitchecker.Main(tagcheck.Analyzer)
+}
@@ -0,0 +1,90 @@
+package scanner
+
+import (
+	"errors"
+	"net/mail"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// Email is a validated email address, it is parsed as an RFC 5322 address without a display name and
+// normalized by lowercasing its domain and converting it to its ascii form
+type Email string
+
+func (e *Email) UnmarshalString(s string) error {
+	addr, err := mail.ParseAddress(strings.TrimSpace(s))
+	if err != nil {
+		return errors.New("scanner: invalid email address " + strconv.Quote(s))
+	}
+	if addr.Name != "" {
+		return errors.New("scanner: email address " + strconv.Quote(s) + " has a display name")
+	}
+
+	at := strings.LastIndexByte(addr.Address, '@')
+	local, domain := addr.Address[:at], addr.Address[at+1:]
+	domain, err = idna.Lookup.ToASCII(domain)
+	if err != nil || !strings.Contains(domain, ".") {
+		return errors.New("scanner: invalid email domain in " + strconv.Quote(s))
+	}
+
+	*e = Email(local + "@" + strings.ToLower(domain))
+	return nil
+}
+
+// Local returns the part of the address before the @
+func (e Email) Local() string {
+	at := strings.LastIndexByte(string(e), '@')
+	if at < 0 {
+		return ""
+	}
+	return string(e)[:at]
+}
+
+// Domain returns the part of the address after the @
+func (e Email) Domain() string {
+	at := strings.LastIndexByte(string(e), '@')
+	if at < 0 {
+		return ""
+	}
+	return string(e)[at+1:]
+}
+
+// Phone is a phone number in the E.164 format like `+905551234567`. It is parsed from international numbers
+// that start with `+` or `00`, spaces, dots, dashes and parentheses between the digits are dropped.
+type Phone string
+
+func (p *Phone) UnmarshalString(s string) error {
+	invalid := errors.New("scanner: invalid international phone number " + strconv.Quote(s))
+
+	number := strings.TrimSpace(s)
+	switch {
+	case strings.HasPrefix(number, "+"):
+		number = number[1:]
+	case strings.HasPrefix(number, "00"):
+		number = number[2:]
+	default:
+		return invalid
+	}
+
+	digits := strings.Builder{}
+	for _, c := range number {
+		switch {
+		case c >= '0' && c <= '9':
+			digits.WriteRune(c)
+		case c == ' ' || c == '-' || c == '.' || c == '(' || c == ')':
+		default:
+			return invalid
+		}
+	}
+
+	// E.164 numbers have at most 15 digits and country codes never start with 0
+	n := digits.String()
+	if len(n) < 7 || len(n) > 15 || n[0] == '0' {
+		return invalid
+	}
+
+	*p = Phone("+" + n)
+	return nil
+}
@@ -0,0 +1,18 @@
+// Package brotli registers the decompressor of the `br` content coding for body scanners. Import it for its
+// side effect:
+//
+//	import _ "github.com/canpacis/scanner/contentencoding/brotli"
+package brotli
+
+import (
+	"io"
+
+	"github.com/andybalholm/brotli"
+	"github.com/canpacis/scanner"
+)
+
+func init() {
+	scanner.RegisterDecompressor("br", func(r io.Reader) (io.ReadCloser, error) {
+		return io.NopCloser(brotli.NewReader(r)), nil
+	})
+}
@@ -0,0 +1,27 @@
+package brotli_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/canpacis/scanner"
+	_ "github.com/canpacis/scanner/contentencoding/brotli"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecompress(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	w := brotli.NewWriter(buf)
+	w.Write([]byte(`{"name":"ada"}`))
+	assert.NoError(w.Close())
+
+	type Payload struct {
+		Name string `json:"name"`
+	}
+	p := Payload{}
+	assert.NoError(scanner.NewJSONBytes(buf.Bytes(), scanner.WithContentEncoding("br")).Scan(&p))
+	assert.Equal(Payload{Name: "ada"}, p)
+}
@@ -0,0 +1,25 @@
+// Package zstd registers the decompressor of the `zstd` content coding for body scanners. Import it for its
+// side effect:
+//
+//	import _ "github.com/canpacis/scanner/contentencoding/zstd"
+package zstd
+
+import (
+	"io"
+
+	"github.com/canpacis/scanner"
+	"github.com/klauspost/compress/zstd"
+)
+
+// maxWindow is the largest window a frame may ask for, the limit RFC 8878 sets for the http content coding
+const maxWindow = 8 << 20
+
+func init() {
+	scanner.RegisterDecompressor("zstd", func(r io.Reader) (io.ReadCloser, error) {
+		d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxWindow(maxWindow))
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	})
+}
@@ -0,0 +1,28 @@
+package zstd_test
+
+import (
+	"testing"
+
+	"github.com/canpacis/scanner"
+	_ "github.com/canpacis/scanner/contentencoding/zstd"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecompress(t *testing.T) {
+	assert := assert.New(t)
+
+	e, err := zstd.NewWriter(nil)
+	assert.NoError(err)
+	b := e.EncodeAll([]byte(`{"name":"ada"}`), nil)
+
+	type Payload struct {
+		Name string `json:"name"`
+	}
+	p := Payload{}
+	assert.NoError(scanner.NewJSONBytes(b, scanner.WithContentEncoding("zstd")).Scan(&p))
+	assert.Equal(Payload{Name: "ada"}, p)
+
+	var uerr *scanner.UnsupportedEncodingError
+	assert.ErrorAs(scanner.NewJSONBytes(b, scanner.WithContentEncoding("zstd, br")).Scan(&Payload{}), &uerr)
+}
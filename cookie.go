@@ -0,0 +1,213 @@
+package scanner
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/canpacis/scanner/structd"
+)
+
+// ErrTamperedCookie is reported for cookie values whose signature or ciphertext does not verify with any key
+var ErrTamperedCookie = errors.New("scanner: cookie value is tampered")
+
+// A CookieError describes a cookie value a codec could not decode, it usually maps to an http 400 status
+type CookieError struct {
+	Name string
+	Err  error
+}
+
+func (e *CookieError) Error() string {
+	return "scanner: cookie " + e.Name + ": " + e.Err.Error()
+}
+
+func (e *CookieError) Unwrap() error {
+	return e.Err
+}
+
+// A CookieCodec protects cookie values. Encode is used when a cookie is set and Decode when it is scanned,
+// the cookie name is bound to the value so it cannot be moved to another cookie.
+type CookieCodec interface {
+	Encode(name, value string) (string, error)
+	Decode(name, value string) (string, error)
+}
+
+// WithCookieCodec decodes cookie values with codec before they are cast, values that fail to decode
+// fail the scan with a `*scanner.CookieError`
+func WithCookieCodec(codec CookieCodec) Option {
+	return func(c *config) {
+		c.cookieCodec = codec
+	}
+}
+
+var cookieEncoding = base64.RawURLEncoding
+
+// signedCookies signs cookie values with HMAC-SHA256
+type signedCookies struct {
+	keys [][]byte
+}
+
+func (c *signedCookies) mac(key []byte, name, value string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write([]byte(value))
+	return h.Sum(nil)
+}
+
+func (c *signedCookies) Encode(name, value string) (string, error) {
+	mac := c.mac(c.keys[0], name, value)
+	return cookieEncoding.EncodeToString([]byte(value)) + "." + cookieEncoding.EncodeToString(mac), nil
+}
+
+func (c *signedCookies) Decode(name, value string) (string, error) {
+	encoded, signature, ok := strings.Cut(value, ".")
+	if !ok {
+		return "", &CookieError{Name: name, Err: ErrTamperedCookie}
+	}
+	raw, err := cookieEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", &CookieError{Name: name, Err: ErrTamperedCookie}
+	}
+	mac, err := cookieEncoding.DecodeString(signature)
+	if err != nil {
+		return "", &CookieError{Name: name, Err: ErrTamperedCookie}
+	}
+
+	for _, key := range c.keys {
+		if hmac.Equal(mac, c.mac(key, name, string(raw))) {
+			return string(raw), nil
+		}
+	}
+
+	return "", &CookieError{Name: name, Err: ErrTamperedCookie}
+}
+
+// NewSignedCookies creates a codec that signs cookie values with HMAC-SHA256. Values are signed with
+// the first key and verified with any of them, so keys can be rotated by prepending the new one.
+func NewSignedCookies(keys ...[]byte) (CookieCodec, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("scanner: signed cookies need at least one key")
+	}
+
+	return &signedCookies{keys: keys}, nil
+}
+
+// encryptedCookies encrypts cookie values with AES-GCM
+type encryptedCookies struct {
+	aeads []cipher.AEAD
+}
+
+func (c *encryptedCookies) Encode(name, value string) (string, error) {
+	aead := c.aeads[0]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return cookieEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(value), []byte(name))), nil
+}
+
+func (c *encryptedCookies) Decode(name, value string) (string, error) {
+	b, err := cookieEncoding.DecodeString(value)
+	if err != nil {
+		return "", &CookieError{Name: name, Err: ErrTamperedCookie}
+	}
+
+	for _, aead := range c.aeads {
+		if len(b) < aead.NonceSize() {
+			continue
+		}
+		nonce, ciphertext := b[:aead.NonceSize()], b[aead.NonceSize():]
+		plain, err := aead.Open(nil, nonce, ciphertext, []byte(name))
+		if err == nil {
+			return string(plain), nil
+		}
+	}
+
+	return "", &CookieError{Name: name, Err: ErrTamperedCookie}
+}
+
+// NewEncryptedCookies creates a codec that encrypts and authenticates cookie values with AES-GCM.
+// Keys must be 16, 24 or 32 bytes long, values are encrypted with the first key and decrypted with any of them.
+func NewEncryptedCookies(keys ...[]byte) (CookieCodec, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("scanner: encrypted cookies need at least one key")
+	}
+
+	aeads := make([]cipher.AEAD, 0, len(keys))
+	for _, key := range keys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		aeads = append(aeads, aead)
+	}
+
+	return &encryptedCookies{aeads: aeads}, nil
+}
+
+// codedCookies decodes the values of a cookie scanner with a codec, keeping the first failure
+type codedCookies struct {
+	*Cookie
+	codec CookieCodec
+	err   error
+}
+
+func (c *codedCookies) Get(key string) any {
+	value, ok := c.Cookie.Get(key).(string)
+	if !ok {
+		return nil
+	}
+
+	decoded, err := c.codec.Decode(key, value)
+	if err != nil {
+		if c.err == nil {
+			c.err = err
+		}
+		return nil
+	}
+	return decoded
+}
+
+// Keys lists no cookies, only the cookies fields bind by name are expected to be encoded with the codec
+func (c *codedCookies) Keys() []string {
+	return nil
+}
+
+var (
+	cookieType    = reflect.TypeFor[http.Cookie]()
+	cookiePtrType = reflect.TypeFor[*http.Cookie]()
+)
+
+// withCookies replaces the values of cookie fields with their cookies, the value of a cookie is the one
+// its field would receive, decoded when there is a codec
+func (v Cookie) withCookies(key string) func(field reflect.StructField, raw any) (any, error) {
+	return func(field reflect.StructField, raw any) (any, error) {
+		value, ok := raw.(string)
+		if !ok || (field.Type != cookieType && field.Type != cookiePtrType) {
+			return raw, nil
+		}
+
+		name := structd.ParseTag(field.Tag.Get(key)).Name
+		for _, cookie := range v.cookies {
+			if cookie.Name == name {
+				copied := *cookie
+				copied.Value = value
+				return &copied, nil
+			}
+		}
+		return raw, nil
+	}
+}
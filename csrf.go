@@ -0,0 +1,148 @@
+package scanner
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"mime"
+	"net/http"
+)
+
+var (
+	// ErrMissingCSRFToken is reported when a request carries no csrf token
+	ErrMissingCSRFToken = errors.New("scanner: missing csrf token")
+	// ErrInvalidCSRFToken is reported by validators for tokens that do not verify
+	ErrInvalidCSRFToken = errors.New("scanner: invalid csrf token")
+	// ErrCSRFTokenFromCookie is reported by `scanner.CSRFDoubleSubmit` for tokens read from the cookie they
+	// are checked against, which would always equal it
+	ErrCSRFTokenFromCookie = errors.New("scanner: csrf token is read from the cookie it is checked against")
+)
+
+// A CSRFError describes a request that failed csrf verification, it usually maps to an http 403 status
+type CSRFError struct {
+	Err error
+}
+
+func (e *CSRFError) Error() string {
+	return "scanner: csrf verification failed: " + e.Err.Error()
+}
+
+func (e *CSRFError) Unwrap() error {
+	return e.Err
+}
+
+// A CSRFValidator verifies the csrf token of a request
+type CSRFValidator func(req *http.Request, token string) error
+
+// csrfCookieKey is the context key of the cookie a csrf token was read from
+type csrfCookieKey struct{}
+
+// CSRFDoubleSubmit validates tokens with the double submit pattern, the token must equal the value of
+// the named cookie. Tokens read from that same cookie fail with `scanner.ErrCSRFTokenFromCookie`, so the
+// cookie of a `scanner.CSRFSource` must not be the cookie it is checked against.
+func CSRFDoubleSubmit(cookie string) CSRFValidator {
+	return func(req *http.Request, token string) error {
+		if from, ok := req.Context().Value(csrfCookieKey{}).(string); ok && from == cookie {
+			return ErrCSRFTokenFromCookie
+		}
+		c, err := req.Cookie(cookie)
+		if err != nil || c.Value == "" || subtle.ConstantTimeCompare([]byte(c.Value), []byte(token)) != 1 {
+			return ErrInvalidCSRFToken
+		}
+		return nil
+	}
+}
+
+// CSRFSource names the places a csrf token is looked up in, in the order of header, form field and cookie.
+// Empty names are skipped. Form fields are read from urlencoded and multipart bodies.
+type CSRFSource struct {
+	Header string
+	Field  string
+	Cookie string
+}
+
+// DefaultCSRFSource looks the token up in the `X-CSRF-Token` header and the `csrf_token` form field
+var DefaultCSRFSource = CSRFSource{Header: "X-CSRF-Token", Field: "csrf_token"}
+
+// A scanner to extract and verify the csrf token of a request. The token is bound to `csrf:"token"` fields.
+// Requests with safe methods (GET, HEAD, OPTIONS and TRACE) are not verified.
+type CSRF struct {
+	req      *http.Request
+	source   CSRFSource
+	validate CSRFValidator
+	token    string
+	config   *config
+}
+
+func (s *CSRF) Get(key string) any {
+	if key != "token" {
+		return nil
+	}
+	return s.token
+}
+
+// lookup finds the token of the request in the configured source and the name of the cookie it was read
+// from, if any
+func (s *CSRF) lookup() (string, string, error) {
+	if s.source.Header != "" {
+		if token := s.req.Header.Get(s.source.Header); token != "" {
+			return token, "", nil
+		}
+	}
+	if s.source.Field != "" {
+		if err := s.req.ParseForm(); err != nil {
+			return "", "", err
+		}
+		// multipart bodies are parsed like `scanner.NewRequest` does, which reuses the parsed form
+		if mediaType, _, _ := mime.ParseMediaType(s.req.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+			if err := s.req.ParseMultipartForm(defaultMaxMemory); err != nil {
+				return "", "", err
+			}
+		}
+		if token := s.req.PostForm.Get(s.source.Field); token != "" {
+			return token, "", nil
+		}
+	}
+	if s.source.Cookie != "" {
+		if c, err := s.req.Cookie(s.source.Cookie); err == nil && c.Value != "" {
+			return c.Value, s.source.Cookie, nil
+		}
+	}
+
+	return "", "", nil
+}
+
+// Verifies the csrf token and scans it onto v, failures are reported as a `*scanner.CSRFError`
+func (s *CSRF) Scan(v any) error {
+	token, cookie, err := s.lookup()
+	if err != nil {
+		return err
+	}
+	s.token = token
+
+	switch s.req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+	default:
+		if token == "" {
+			return &CSRFError{Err: ErrMissingCSRFToken}
+		}
+		req := s.req
+		if cookie != "" {
+			req = req.WithContext(context.WithValue(req.Context(), csrfCookieKey{}, cookie))
+		}
+		if err := s.validate(req, token); err != nil {
+			return &CSRFError{Err: err}
+		}
+	}
+
+	return s.config.decoder(s, "csrf").Decode(v)
+}
+
+func NewCSRF(req *http.Request, source CSRFSource, validate CSRFValidator, opts ...Option) *CSRF {
+	return &CSRF{
+		req:      req,
+		source:   source,
+		validate: validate,
+		config:   newConfig(opts),
+	}
+}
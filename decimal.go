@@ -0,0 +1,225 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/currency"
+)
+
+// Decimal is an arbitrary precision decimal number, it keeps values like prices exact where binary floats
+// cannot. It is parsed from strings like `19.99` or `1.5e3` and from json numbers or strings.
+type Decimal struct {
+	coef  *big.Int
+	scale int
+}
+
+// ParseDecimal parses a decimal number
+func ParseDecimal(s string) (Decimal, error) {
+	invalid := errors.New("scanner: invalid decimal " + strconv.Quote(s))
+
+	mantissa, exponent, hasExponent := strings.Cut(strings.ToLower(s), "e")
+	exp := 0
+	if hasExponent {
+		n, err := strconv.Atoi(exponent)
+		if err != nil || n > 1000 || n < -1000 {
+			return Decimal{}, invalid
+		}
+		exp = n
+	}
+
+	negative := false
+	if mantissa != "" && (mantissa[0] == '-' || mantissa[0] == '+') {
+		negative = mantissa[0] == '-'
+		mantissa = mantissa[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(mantissa, ".")
+	digits := whole + fraction
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return Decimal{}, invalid
+	}
+
+	coef, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return Decimal{}, invalid
+	}
+	if negative {
+		coef.Neg(coef)
+	}
+
+	scale := len(fraction) - exp
+	if scale < 0 {
+		coef.Mul(coef, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(-scale)), nil))
+		scale = 0
+	}
+
+	return Decimal{coef: coef, scale: scale}, nil
+}
+
+// MustParseDecimal is like ParseDecimal but panics when s is not a decimal
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func (d Decimal) coefficient() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return d.coef
+}
+
+// Scale returns the number of digits after the decimal point
+func (d Decimal) Scale() int {
+	return d.scale
+}
+
+// Rat returns the decimal as a rational number
+func (d Decimal) Rat() *big.Rat {
+	denom := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(d.scale)), nil)
+	return new(big.Rat).SetFrac(d.coefficient(), denom)
+}
+
+// Float64 returns the nearest float to the decimal
+func (d Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+	return f
+}
+
+// Cmp compares the decimals and returns -1, 0 or 1
+func (d Decimal) Cmp(other Decimal) int {
+	return d.Rat().Cmp(other.Rat())
+}
+
+func (d Decimal) String() string {
+	coef := d.coefficient()
+	digits := new(big.Int).Abs(coef).String()
+	if d.scale > 0 {
+		if len(digits) <= d.scale {
+			digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-d.scale] + "." + digits[len(digits)-d.scale:]
+	}
+	if coef.Sign() < 0 {
+		digits = "-" + digits
+	}
+	return digits
+}
+
+func (d *Decimal) UnmarshalString(s string) error {
+	parsed, err := ParseDecimal(strings.TrimSpace(s))
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+	return nil
+}
+
+func (d *Decimal) UnmarshalText(b []byte) error {
+	return d.UnmarshalString(string(b))
+}
+
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON accepts json numbers and strings, numbers are read without passing through a float
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	if len(b) > 0 && b[0] == '"' {
+		s := ""
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		return d.UnmarshalString(s)
+	}
+	return d.UnmarshalString(string(b))
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// Money is an amount in an ISO 4217 currency, it is parsed from strings like `19.99 USD` or `USD 19.99`.
+// Amounts with more decimal places than the currency has are rejected.
+type Money struct {
+	Amount   Decimal
+	Currency string
+}
+
+func (m *Money) UnmarshalString(s string) error {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return errors.New("scanner: expected an amount and a currency like 19.99 USD, got " + strconv.Quote(s))
+	}
+
+	amount, code := fields[0], fields[1]
+	if _, err := currency.ParseISO(amount); err == nil {
+		amount, code = code, amount
+	}
+
+	return m.set(amount, code)
+}
+
+// set parses the amount and the currency code and validates the scale of the amount
+func (m *Money) set(amount, code string) error {
+	unit, err := currency.ParseISO(code)
+	if err != nil {
+		return errors.New("scanner: unknown currency " + strconv.Quote(code))
+	}
+	d, err := ParseDecimal(amount)
+	if err != nil {
+		return err
+	}
+
+	scale, _ := currency.Standard.Rounding(unit)
+	if d.scale > scale {
+		return errors.New("scanner: " + unit.String() + " amounts have at most " + strconv.Itoa(scale) + " decimal places")
+	}
+
+	*m = Money{Amount: d, Currency: unit.String()}
+	return nil
+}
+
+type moneyJSON struct {
+	Amount   json.RawMessage `json:"amount"`
+	Currency string          `json:"currency"`
+}
+
+// UnmarshalJSON accepts strings like `"19.99 USD"` and objects like `{"amount": "19.99", "currency": "USD"}`
+func (m *Money) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		s := ""
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		return m.UnmarshalString(s)
+	}
+
+	raw := moneyJSON{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	amount := string(raw.Amount)
+	if len(raw.Amount) > 0 && raw.Amount[0] == '"' {
+		if err := json.Unmarshal(raw.Amount, &amount); err != nil {
+			return err
+		}
+	}
+	return m.set(amount, raw.Currency)
+}
+
+func (m Money) String() string {
+	return m.Amount.String() + " " + m.Currency
+}
@@ -0,0 +1,196 @@
+package scanner
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// A FileTooLargeError describes a directory file larger than the `scanner.WithMaxFileSize` limit
+type FileTooLargeError struct {
+	Name  string
+	Limit int64
+}
+
+func (e *FileTooLargeError) Error() string {
+	return "scanner: file " + e.Name + " exceeds the limit of " + strconv.FormatInt(e.Limit, 10) + " bytes"
+}
+
+// A TooManyFilesError describes a scan that binds more directory files than the `scanner.WithMaxFiles` limit
+type TooManyFilesError struct {
+	Limit int
+}
+
+func (e *TooManyFilesError) Error() string {
+	return "scanner: scan binds more than " + strconv.Itoa(e.Limit) + " directory files"
+}
+
+// directoryConfig holds the options of directory scanners
+type directoryConfig struct {
+	root     string
+	include  []string
+	exclude  []string
+	maxSize  int64
+	maxFiles int
+}
+
+// WithRoot makes a directory scanner bind the files under the slash separated dir of its file system,
+// keyed by their path relative to it
+func WithRoot(dir string) Option {
+	return func(c *config) {
+		c.directory.root = dir
+	}
+}
+
+// WithInclude makes a directory scanner bind only the files that match one of the `path.Match` patterns.
+// Patterns without a slash match the base name of a file and the others its whole path.
+func WithInclude(patterns ...string) Option {
+	return func(c *config) {
+		c.directory.include = append(c.directory.include, patterns...)
+	}
+}
+
+// WithExclude makes a directory scanner skip the files, and the files of the directories, that match one of
+// the `path.Match` patterns, matched like the patterns of `scanner.WithInclude`
+func WithExclude(patterns ...string) Option {
+	return func(c *config) {
+		c.directory.exclude = append(c.directory.exclude, patterns...)
+	}
+}
+
+// WithMaxFileSize makes a directory scanner fail with a `*scanner.FileTooLargeError` for files larger than n bytes
+func WithMaxFileSize(n int64) Option {
+	return func(c *config) {
+		c.directory.maxSize = n
+	}
+}
+
+// WithMaxFiles makes a directory scanner fail with a `*scanner.TooManyFilesError` when a scan binds
+// more than n files
+func WithMaxFiles(n int) Option {
+	return func(c *config) {
+		c.directory.maxFiles = n
+	}
+}
+
+// validate reports malformed patterns
+func (c directoryConfig) validate() error {
+	for _, pattern := range append(c.include[:len(c.include):len(c.include)], c.exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchPattern matches name to a pattern of `scanner.WithInclude` or `scanner.WithExclude`
+func matchPattern(pattern, name string) bool {
+	if !strings.Contains(pattern, "/") {
+		name = path.Base(name)
+	}
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
+// excluded reports whether a file or directory is excluded
+func (c directoryConfig) excluded(name string) bool {
+	for _, pattern := range c.exclude {
+		if matchPattern(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// included reports whether a file is included
+func (c directoryConfig) included(name string) bool {
+	if len(c.include) == 0 {
+		return true
+	}
+	for _, pattern := range c.include {
+		if matchPattern(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowed reports whether the filters let a file be bound, a file is excluded when a directory above it is
+func (c directoryConfig) allowed(name string) bool {
+	for dir := name; dir != "."; dir = path.Dir(dir) {
+		if c.excluded(dir) {
+			return false
+		}
+	}
+	return c.included(name)
+}
+
+// load opens and reads the files the tags of v bind, closing each one once it is read. Missing and
+// filtered files are left out.
+func (s *Directory) load(v any, key string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	rt := rv.Elem().Type()
+
+	for i := range rt.NumField() {
+		tag, ok := rt.Field(i).Tag.Lookup(key)
+		if !ok {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if _, ok := s.loaded[name]; ok || !fs.ValidPath(name) || !s.config.directory.allowed(name) {
+			continue
+		}
+
+		b, err := s.readFile(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		s.loaded[name] = b
+
+		if limit := s.config.directory.maxFiles; limit > 0 && len(s.loaded) > limit {
+			return &TooManyFilesError{Limit: limit}
+		}
+	}
+
+	return nil
+}
+
+// readFile reads a file of the file system, enforcing the size limit
+func (s *Directory) readFile(name string) ([]byte, error) {
+	file, err := s.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fs.ErrNotExist
+	}
+	limit := s.config.directory.maxSize
+	if limit > 0 && info.Size() > limit {
+		return nil, &FileTooLargeError{Name: name, Limit: limit}
+	}
+
+	if limit <= 0 {
+		return io.ReadAll(file)
+	}
+	b, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err == nil && int64(len(b)) > limit {
+		return nil, &FileTooLargeError{Name: name, Limit: limit}
+	}
+	return b, err
+}
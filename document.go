@@ -0,0 +1,235 @@
+package scanner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/canpacis/scanner/structd"
+)
+
+// document is a getter over decoded documents like json objects, the values it holds are the
+// types encoding/json produces: strings, float64s, bools, []any and map[string]any
+type document struct {
+	values map[string]any
+	key    string
+	config *config
+}
+
+func (d *document) Get(key string) any {
+	return d.values[key]
+}
+
+// Keys returns the keys of the document
+func (d *document) Keys() []string {
+	return slices.Sorted(maps.Keys(d.values))
+}
+
+func (d *document) Cast(from any, to reflect.Type) (any, error) {
+	switch f := from.(type) {
+	case interface{ Hex() string }:
+		if to.Kind() == reflect.String {
+			return reflect.ValueOf(f.Hex()).Convert(to).Interface(), nil
+		}
+	case interface{ Time() time.Time }:
+		if to == timeType {
+			return f.Time(), nil
+		}
+	}
+
+	switch from := from.(type) {
+	case float64:
+		return castFloat(from, to)
+	case float32:
+		return castFloat(float64(from), to)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return castInteger(reflect.ValueOf(from), to)
+	case json.Number:
+		return structd.DefaultCast(from.String(), to)
+	case []any:
+		if to.Kind() != reflect.Slice {
+			return nil, errors.ErrUnsupported
+		}
+
+		result := reflect.MakeSlice(to, 0, len(from))
+		for _, entry := range from {
+			casted, err := d.cast(entry, to.Elem())
+			if err != nil {
+				return nil, err
+			}
+			result = reflect.Append(result, casted)
+		}
+		return result.Interface(), nil
+	case map[string]any:
+		switch to.Kind() {
+		case reflect.Struct:
+			result := reflect.New(to)
+			nested := &document{values: from, key: d.key, config: d.config.withinScan()}
+			if err := nested.config.decoder(nested, d.key).Decode(result.Interface()); err != nil {
+				return nil, err
+			}
+			return result.Elem().Interface(), nil
+		case reflect.Pointer:
+			casted, err := d.Cast(from, to.Elem())
+			if err != nil {
+				return nil, err
+			}
+			result := reflect.New(to.Elem())
+			result.Elem().Set(reflect.ValueOf(casted))
+			return result.Interface(), nil
+		case reflect.Map:
+			if to.Key().Kind() != reflect.String {
+				return nil, errors.ErrUnsupported
+			}
+
+			result := reflect.MakeMapWithSize(to, len(from))
+			for _, key := range slices.Sorted(maps.Keys(from)) {
+				casted, err := d.cast(from[key], to.Elem())
+				if err != nil {
+					return nil, err
+				}
+				result.SetMapIndex(reflect.ValueOf(key).Convert(to.Key()), casted)
+			}
+			return result.Interface(), nil
+		default:
+			return nil, errors.ErrUnsupported
+		}
+	default:
+		if normalized, ok := normalize(from); ok {
+			return d.Cast(normalized, to)
+		}
+		return structd.DefaultCast(from, to)
+	}
+}
+
+// normalize converts named map and slice types, like `bson.M` and `bson.A`, to the plain types of a document
+func normalize(v any) (any, bool) {
+	rv := reflect.ValueOf(v)
+
+	switch {
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String && rv.Type() != mapType:
+		m := make(map[string]any, rv.Len())
+		for iter := rv.MapRange(); iter.Next(); {
+			m[iter.Key().String()] = iter.Value().Interface()
+		}
+		return m, true
+	case rv.Kind() == reflect.Slice && isElement(rv.Type().Elem()):
+		m := make(map[string]any, rv.Len())
+		for i := range rv.Len() {
+			m[rv.Index(i).Field(0).String()] = rv.Index(i).Field(1).Interface()
+		}
+		return m, true
+	case rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 && rv.Type() != sliceType:
+		s := make([]any, rv.Len())
+		for i := range rv.Len() {
+			s[i] = rv.Index(i).Interface()
+		}
+		return s, true
+	default:
+		return nil, false
+	}
+}
+
+// isElement reports whether t is a key value pair of an ordered document, like `bson.E`
+func isElement(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t.NumField() == 2 &&
+		t.Field(0).Name == "Key" && t.Field(0).Type.Kind() == reflect.String &&
+		t.Field(1).Name == "Value" && t.Field(1).Type.Kind() == reflect.Interface
+}
+
+var (
+	mapType   = reflect.TypeFor[map[string]any]()
+	sliceType = reflect.TypeFor[[]any]()
+	timeType  = reflect.TypeFor[time.Time]()
+)
+
+// castInteger converts an integer to a numeric or string type, failing when it does not fit
+func castInteger(v reflect.Value, to reflect.Type) (any, error) {
+	switch to.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		if v.CanUint() {
+			if v.Uint() > math.MaxInt64 {
+				return nil, fmt.Errorf("number %v does not fit into %s", v, to)
+			}
+			n = int64(v.Uint())
+		} else {
+			n = v.Int()
+		}
+		if reflect.Zero(to).OverflowInt(n) {
+			return nil, fmt.Errorf("number %v does not fit into %s", v, to)
+		}
+		return reflect.ValueOf(n).Convert(to).Interface(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n uint64
+		if v.CanInt() {
+			if v.Int() < 0 {
+				return nil, fmt.Errorf("number %v does not fit into %s", v, to)
+			}
+			n = uint64(v.Int())
+		} else {
+			n = v.Uint()
+		}
+		if reflect.Zero(to).OverflowUint(n) {
+			return nil, fmt.Errorf("number %v does not fit into %s", v, to)
+		}
+		return reflect.ValueOf(n).Convert(to).Interface(), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Convert(to).Interface(), nil
+	case reflect.String:
+		return reflect.ValueOf(fmt.Sprint(v.Interface())).Convert(to).Interface(), nil
+	case reflect.Bool:
+		return !v.IsZero(), nil
+	default:
+		return nil, errors.ErrUnsupported
+	}
+}
+
+// cast converts a single document value to type to
+func (d *document) cast(from any, to reflect.Type) (reflect.Value, error) {
+	if from == nil {
+		return reflect.Zero(to), nil
+	}
+	if reflect.TypeOf(from).AssignableTo(to) {
+		return reflect.ValueOf(from), nil
+	}
+
+	casted, err := d.Cast(from, to)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return reflect.ValueOf(casted), nil
+}
+
+// castFloat converts a json number to a numeric or string type, failing when it does not fit
+func castFloat(f float64, to reflect.Type) (any, error) {
+	switch to.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if f != math.Trunc(f) || reflect.Zero(to).OverflowInt(int64(f)) || math.Abs(f) > 1<<63 {
+			return nil, fmt.Errorf("number %v does not fit into %s", f, to)
+		}
+		return reflect.ValueOf(int64(f)).Convert(to).Interface(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if f != math.Trunc(f) || f < 0 || f > 1<<64 || reflect.Zero(to).OverflowUint(uint64(f)) {
+			return nil, fmt.Errorf("number %v does not fit into %s", f, to)
+		}
+		return reflect.ValueOf(uint64(f)).Convert(to).Interface(), nil
+	case reflect.Float32, reflect.Float64:
+		if reflect.Zero(to).OverflowFloat(f) {
+			return nil, fmt.Errorf("number %v does not fit into %s", f, to)
+		}
+		return reflect.ValueOf(f).Convert(to).Interface(), nil
+	case reflect.String:
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	case reflect.Bool:
+		return f != 0, nil
+	default:
+		return nil, errors.ErrUnsupported
+	}
+}
@@ -0,0 +1,163 @@
+package scanner
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// A Decompressor wraps a reader of content in a content coding with a reader of the decoded content
+type Decompressor func(r io.Reader) (io.ReadCloser, error)
+
+var (
+	decompressorsMu sync.RWMutex
+	decompressors   = map[string]Decompressor{
+		"gzip": func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+		"deflate": inflate,
+	}
+)
+
+// inflate decodes deflate content, which is zlib wrapped by the standard but sent as raw deflate by some clients
+func inflate(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// RegisterDecompressor registers the decompressor of a content coding body scanners decode, gzip and deflate
+// are registered by default and the contentencoding packages register br and zstd
+func RegisterDecompressor(encoding string, d Decompressor) {
+	decompressorsMu.Lock()
+	defer decompressorsMu.Unlock()
+	decompressors[strings.ToLower(encoding)] = d
+}
+
+func decompressor(encoding string) (Decompressor, bool) {
+	decompressorsMu.RLock()
+	defer decompressorsMu.RUnlock()
+	if encoding == "x-gzip" {
+		encoding = "gzip"
+	}
+	d, ok := decompressors[encoding]
+	return d, ok
+}
+
+// An UnsupportedEncodingError describes a body in a content coding without a registered decompressor,
+// it usually maps to an http 415 status.
+type UnsupportedEncodingError struct {
+	Encoding string
+}
+
+func (e *UnsupportedEncodingError) Error() string {
+	return "scanner: no decompressor is registered for the " + e.Encoding + " content coding"
+}
+
+// DefaultMaxDecompressedBytes is the limit of the decoded size of compressed bodies, against decompression bombs
+const DefaultMaxDecompressedBytes = 32 << 20
+
+// WithContentEncoding makes body scanners decode their source with the content codings of a `Content-Encoding`
+// header, like `gzip` or `deflate, br`. The decoded body is limited to `scanner.DefaultMaxDecompressedBytes`
+// unless `scanner.WithMaxDecompressedBytes` sets another limit. `scanner.NewRequest` reads the header on its own.
+func WithContentEncoding(header string) Option {
+	return func(c *config) {
+		c.encodings = nil
+		for _, encoding := range strings.Split(header, ",") {
+			encoding = strings.ToLower(strings.TrimSpace(encoding))
+			if encoding != "" && encoding != "identity" {
+				c.encodings = append(c.encodings, encoding)
+			}
+		}
+	}
+}
+
+// WithMaxDecompressedBytes limits the decoded size of compressed bodies to n, reading past it fails with a
+// `*scanner.BodyTooLargeError`
+func WithMaxDecompressedBytes(n int64) Option {
+	return func(c *config) {
+		c.maxDecompressed = n
+	}
+}
+
+// decompress wraps r with the decompressors of the configured content codings, which are undone in the
+// reverse order they were applied in
+func (c *config) decompress(r io.Reader) io.Reader {
+	if len(c.encodings) == 0 {
+		return r
+	}
+
+	for _, encoding := range slices.Backward(c.encodings) {
+		d, ok := decompressor(encoding)
+		if !ok {
+			return errReader{&UnsupportedEncodingError{Encoding: encoding}}
+		}
+		// decompressors read headers when they are created, so they are created on the first read
+		src := r
+		r = &lazyReader{open: func() (io.ReadCloser, error) {
+			return d(src)
+		}}
+	}
+
+	limit := c.maxDecompressed
+	if limit <= 0 {
+		limit = DefaultMaxDecompressedBytes
+	}
+	return &limitedReader{r: r, n: limit, limit: limit}
+}
+
+// encodingOption returns the option that decodes the body of req with its content codings, nil when it has none
+func encodingOption(req *http.Request) Option {
+	header := strings.Join(req.Header.Values("Content-Encoding"), ",")
+	if strings.TrimSpace(header) == "" {
+		return nil
+	}
+	return WithContentEncoding(header)
+}
+
+// errReader fails every read with err
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
+
+// lazyReader opens its reader on the first read and closes it once it is read to the end
+type lazyReader struct {
+	open func() (io.ReadCloser, error)
+	r    io.Reader
+}
+
+func (l *lazyReader) Read(p []byte) (int, error) {
+	if l.r == nil {
+		r, err := l.open()
+		if err != nil {
+			l.r = errReader{err}
+		} else {
+			l.r = r
+		}
+	}
+
+	n, err := l.r.Read(p)
+	if err == io.EOF {
+		if closer, ok := l.r.(io.Closer); ok {
+			closer.Close()
+		}
+		l.r = errReader{io.EOF}
+	}
+	return n, err
+}
@@ -0,0 +1,13 @@
+package scanner
+
+import "strconv"
+
+// A BodyTooLargeError describes a source that exceeded the byte limit of its scanner,
+// it usually maps to an http 413 status.
+type BodyTooLargeError struct {
+	Limit int64
+}
+
+func (e *BodyTooLargeError) Error() string {
+	return "scanner: body exceeds the limit of " + strconv.FormatInt(e.Limit, 10) + " bytes"
+}
@@ -0,0 +1,118 @@
+package scanner
+
+import (
+	"reflect"
+
+	"github.com/canpacis/scanner/structd"
+)
+
+// A Peeker is a source of raw values by key. `*fasthttp.Args` and `*fasthttp.RequestHeader` implement it,
+// so fasthttp requests can be scanned without converting them to net/http types.
+type Peeker interface {
+	Peek(key string) []byte
+}
+
+// A MultiPeeker is a Peeker that can return every value of a key, like `*fasthttp.Args`
+type MultiPeeker interface {
+	Peeker
+	PeekMulti(key string) [][]byte
+}
+
+// PeekFunc adapts a function to a Peeker, e.g. `scanner.PeekFunc(ctx.Request.Header.Cookie)`
+type PeekFunc func(key string) []byte
+
+func (f PeekFunc) Peek(key string) []byte {
+	return f(key)
+}
+
+// A scanner to scan the values of a `scanner.Peeker` onto a struct with the given tag.
+// Fields of type []byte receive the peeked bytes without copying them, so they are only valid for as long as
+// the source is, e.g. for the lifetime of a fasthttp request handler. Other fields are cast as usual.
+type Args struct {
+	p      Peeker
+	key    string
+	config *config
+}
+
+func (a *Args) Get(key string) any {
+	b := a.p.Peek(key)
+	if b == nil {
+		return nil
+	}
+
+	return b
+}
+
+func (a *Args) GetAll(key string) []string {
+	m, ok := a.p.(MultiPeeker)
+	if !ok {
+		b := a.p.Peek(key)
+		if b == nil {
+			return nil
+		}
+		return []string{string(b)}
+	}
+
+	values := []string{}
+	for _, b := range m.PeekMulti(key) {
+		values = append(values, string(b))
+	}
+	return values
+}
+
+func (a *Args) Cast(from any, to reflect.Type) (any, error) {
+	if b, ok := from.([]byte); ok {
+		from = string(b)
+		if to.Kind() == reflect.String {
+			return reflect.ValueOf(from).Convert(to).Interface(), nil
+		}
+	}
+
+	return structd.DefaultCast(from, to)
+}
+
+// Scans the peeked values onto v
+func (a *Args) Scan(v any) error {
+	return a.config.decoder(a, a.key).Decode(v)
+}
+
+// NewArgs creates a scanner over p with the given tag, e.g. `scanner.NewArgs(ctx.QueryArgs(), "query")`
+func NewArgs(p Peeker, key string, opts ...Option) *Args {
+	return &Args{
+		p:      p,
+		key:    key,
+		config: newConfig(opts),
+	}
+}
+
+// A UserValuer holds request scoped values by key, routers for fasthttp store the path parameters
+// in `*fasthttp.RequestCtx` this way.
+type UserValuer interface {
+	UserValue(key any) any
+}
+
+// A scanner to scan the user values of a `scanner.UserValuer` onto a struct with the `path` tag
+type UserValues struct {
+	u      UserValuer
+	config *config
+}
+
+func (u *UserValues) Get(key string) any {
+	return u.u.UserValue(key)
+}
+
+func (u *UserValues) Cast(from any, to reflect.Type) (any, error) {
+	return structd.DefaultCast(from, to)
+}
+
+// Scans the user values onto v
+func (u *UserValues) Scan(v any) error {
+	return u.config.decoder(u, "path").Decode(v)
+}
+
+func NewUserValues(u UserValuer, opts ...Option) *UserValues {
+	return &UserValues{
+		u:      u,
+		config: newConfig(opts),
+	}
+}
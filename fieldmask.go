@@ -0,0 +1,107 @@
+package scanner
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+var errFieldMask = errors.New("scanner: malformed field mask")
+
+// FieldMask holds the dotted field paths of a sparse fieldset, parsed from either protobuf style
+// paths like `users.id,users.name` or grouped selections like `users(id,name)`. An empty mask includes every field.
+type FieldMask []string
+
+func (m *FieldMask) UnmarshalString(v string) error {
+	paths := []string{}
+
+	rest, err := parseFieldMask(v, "", &paths)
+	if err != nil {
+		return err
+	}
+	if rest != "" {
+		return errFieldMask
+	}
+
+	*m = paths
+	return nil
+}
+
+// parseFieldMask parses a comma separated list of paths and groups prefixed with prefix, it returns
+// the input left after a closing parenthesis
+func parseFieldMask(s, prefix string, paths *[]string) (string, error) {
+	for {
+		i := strings.IndexAny(s, ",()")
+		if i < 0 {
+			i = len(s)
+		}
+		name := strings.TrimSpace(s[:i])
+		s = s[i:]
+
+		if strings.HasPrefix(s, "(") {
+			if name == "" {
+				return "", errFieldMask
+			}
+			rest, err := parseFieldMask(s[1:], prefix+name+".", paths)
+			if err != nil {
+				return "", err
+			}
+			if !strings.HasPrefix(rest, ")") {
+				return "", errFieldMask
+			}
+			s = rest[1:]
+		} else if name != "" {
+			*paths = append(*paths, prefix+name)
+		}
+
+		switch {
+		case s == "":
+			if prefix != "" {
+				return "", errFieldMask
+			}
+			return "", nil
+		case s[0] == ')':
+			if prefix == "" {
+				return "", errFieldMask
+			}
+			return s, nil
+		case s[0] == ',':
+			s = s[1:]
+		default:
+			return "", errFieldMask
+		}
+	}
+}
+
+// Has reports whether path is included in the mask, either listed itself or through one of its parents
+func (m FieldMask) Has(path string) bool {
+	if len(m) == 0 {
+		return true
+	}
+
+	for _, p := range m {
+		if p == path || strings.HasPrefix(path, p+".") || strings.HasPrefix(p, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Sub returns the mask of the fields of path relative to it, it is meaningful only when the mask has path
+func (m FieldMask) Sub(path string) FieldMask {
+	if len(m) == 0 || slices.Contains(m, path) {
+		return FieldMask{}
+	}
+
+	sub := FieldMask{}
+	for _, p := range m {
+		if rest, ok := strings.CutPrefix(p, path+"."); ok {
+			sub = append(sub, rest)
+		}
+	}
+	return sub
+}
+
+func (m FieldMask) String() string {
+	return strings.Join(m, ",")
+}
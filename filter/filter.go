@@ -0,0 +1,341 @@
+// Package filter parses search expressions like `age>30 AND status:active` into a syntax tree, allowing
+// only the fields and operators of a schema. A schema's Cast can be registered with `scanner.WithCaster`
+// to bind expressions to `filter.Expr` fields.
+package filter
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// An Op is a comparison operator
+type Op string
+
+const (
+	Has Op = ":"
+	Eq  Op = "="
+	Ne  Op = "!="
+	Gt  Op = ">"
+	Ge  Op = ">="
+	Lt  Op = "<"
+	Le  Op = "<="
+)
+
+// An Expr is a node of a filter expression, one of *And, *Or, *Not or *Comparison
+type Expr interface {
+	String() string
+	expr()
+}
+
+// And matches when both of its operands match
+type And struct {
+	Left, Right Expr
+}
+
+func (e *And) String() string {
+	return "(" + e.Left.String() + " AND " + e.Right.String() + ")"
+}
+
+// Or matches when either of its operands match
+type Or struct {
+	Left, Right Expr
+}
+
+func (e *Or) String() string {
+	return "(" + e.Left.String() + " OR " + e.Right.String() + ")"
+}
+
+// Not matches when its operand does not match
+type Not struct {
+	Expr Expr
+}
+
+func (e *Not) String() string {
+	return "NOT " + e.Expr.String()
+}
+
+// Comparison compares a field to a value
+type Comparison struct {
+	Field string
+	Op    Op
+	Value string
+}
+
+func (e *Comparison) String() string {
+	return e.Field + string(e.Op) + strconv.Quote(e.Value)
+}
+
+func (*And) expr()        {}
+func (*Or) expr()         {}
+func (*Not) expr()        {}
+func (*Comparison) expr() {}
+
+// A SyntaxError describes a malformed expression
+type SyntaxError struct {
+	Offset int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return "filter: " + e.Msg + " at offset " + strconv.Itoa(e.Offset)
+}
+
+// A NotAllowedError describes a comparison the schema does not allow
+type NotAllowedError struct {
+	Field string
+	Op    Op
+}
+
+func (e *NotAllowedError) Error() string {
+	return "filter: " + e.Field + " cannot be compared with " + string(e.Op)
+}
+
+// A Schema lists the fields an expression can compare and the operators allowed for each
+type Schema map[string][]Op
+
+func (s Schema) allows(field string, op Op) bool {
+	for _, allowed := range s[field] {
+		if allowed == op {
+			return true
+		}
+	}
+	return false
+}
+
+var exprType = reflect.TypeFor[Expr]()
+
+// Cast parses string values onto `filter.Expr` fields, it can be passed to `scanner.WithCaster`
+func (s Schema) Cast(from any, to reflect.Type) (any, error) {
+	str, ok := from.(string)
+	if !ok || to != exprType {
+		return nil, errors.ErrUnsupported
+	}
+
+	return Parse(str, s)
+}
+
+// maxDepth bounds the nesting of expressions
+const maxDepth = 32
+
+// maxLength bounds the length of expressions
+const maxLength = 4096
+
+// Parse parses an expression, comparisons of fields and operators the schema does not allow fail with
+// a *NotAllowedError. `AND` binds tighter than `OR`, and parentheses group expressions.
+func Parse(s string, schema Schema) (Expr, error) {
+	if len(s) > maxLength {
+		return nil, &SyntaxError{Offset: maxLength, Msg: "expression is too long"}
+	}
+
+	p := &parser{lexer: lexer{src: s}, schema: schema}
+	p.next()
+
+	e, err := p.or(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != eof {
+		return nil, p.errorf("unexpected " + p.tok.describe())
+	}
+	return e, nil
+}
+
+type parser struct {
+	lexer
+	tok    token
+	schema Schema
+}
+
+func (p *parser) next() {
+	p.tok = p.lex()
+}
+
+func (p *parser) errorf(msg string) error {
+	return &SyntaxError{Offset: p.tok.offset, Msg: msg}
+}
+
+func (p *parser) or(depth int) (Expr, error) {
+	left, err := p.and(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	for p.tok.keyword("OR") {
+		p.next()
+		right, err := p.and(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) and(depth int) (Expr, error) {
+	left, err := p.unary(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	for p.tok.keyword("AND") {
+		p.next()
+		right, err := p.unary(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &And{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) unary(depth int) (Expr, error) {
+	if depth > maxDepth {
+		return nil, p.errorf("expression is nested too deeply")
+	}
+
+	switch {
+	case p.tok.keyword("NOT"):
+		p.next()
+		e, err := p.unary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Expr: e}, nil
+	case p.tok.kind == lparen:
+		p.next()
+		e, err := p.or(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != rparen {
+			return nil, p.errorf("expected ) but found " + p.tok.describe())
+		}
+		p.next()
+		return e, nil
+	default:
+		return p.comparison()
+	}
+}
+
+func (p *parser) comparison() (Expr, error) {
+	if p.tok.kind != word || p.tok.keyword("AND") || p.tok.keyword("OR") {
+		return nil, p.errorf("expected a field but found " + p.tok.describe())
+	}
+	field := p.tok.text
+	p.next()
+
+	if p.tok.kind != operator {
+		return nil, p.errorf("expected an operator but found " + p.tok.describe())
+	}
+	op := Op(p.tok.text)
+	p.next()
+
+	if p.tok.kind != word && p.tok.kind != str {
+		return nil, p.errorf("expected a value but found " + p.tok.describe())
+	}
+	value := p.tok.text
+	p.next()
+
+	if !p.schema.allows(field, op) {
+		return nil, &NotAllowedError{Field: field, Op: op}
+	}
+	return &Comparison{Field: field, Op: op, Value: value}, nil
+}
+
+type kind int
+
+const (
+	eof kind = iota
+	word
+	str
+	operator
+	lparen
+	rparen
+	invalid
+)
+
+type token struct {
+	kind   kind
+	text   string
+	offset int
+}
+
+func (t token) keyword(k string) bool {
+	return t.kind == word && t.text == k
+}
+
+func (t token) describe() string {
+	switch t.kind {
+	case eof:
+		return "end of expression"
+	case str:
+		return "string " + strconv.Quote(t.text)
+	default:
+		return strconv.Quote(t.text)
+	}
+}
+
+type lexer struct {
+	src string
+	pos int
+}
+
+// lex returns the next token of the source
+func (l *lexer) lex() token {
+	for l.pos < len(l.src) && (l.src[l.pos] == ' ' || l.src[l.pos] == '\t') {
+		l.pos++
+	}
+	start := l.pos
+	if l.pos >= len(l.src) {
+		return token{kind: eof, offset: start}
+	}
+
+	c := l.src[l.pos]
+	switch {
+	case c == '(':
+		l.pos++
+		return token{kind: lparen, text: "(", offset: start}
+	case c == ')':
+		l.pos++
+		return token{kind: rparen, text: ")", offset: start}
+	case c == '"':
+		return l.string()
+	case strings.IndexByte(":=!<>", c) >= 0:
+		for _, op := range []Op{Ne, Ge, Le, Has, Eq, Gt, Lt} {
+			if strings.HasPrefix(l.src[l.pos:], string(op)) {
+				l.pos += len(op)
+				return token{kind: operator, text: string(op), offset: start}
+			}
+		}
+		l.pos++
+		return token{kind: invalid, text: string(c), offset: start}
+	}
+
+	for l.pos < len(l.src) && strings.IndexByte(" \t()\":=!<>", l.src[l.pos]) < 0 {
+		l.pos++
+	}
+	return token{kind: word, text: l.src[start:l.pos], offset: start}
+}
+
+// string lexes a double quoted string with backslash escapes
+func (l *lexer) string() token {
+	start := l.pos
+	b := strings.Builder{}
+
+	for l.pos++; l.pos < len(l.src); l.pos++ {
+		switch c := l.src[l.pos]; {
+		case c == '\\' && l.pos+1 < len(l.src):
+			l.pos++
+			b.WriteByte(l.src[l.pos])
+		case c == '"':
+			l.pos++
+			return token{kind: str, text: b.String(), offset: start}
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return token{kind: invalid, text: l.src[start:], offset: start}
+}
@@ -0,0 +1,77 @@
+package filter_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/canpacis/scanner"
+	"github.com/canpacis/scanner/filter"
+	"github.com/stretchr/testify/assert"
+)
+
+var schema = filter.Schema{
+	"age":    {filter.Eq, filter.Gt, filter.Ge, filter.Lt, filter.Le},
+	"status": {filter.Has, filter.Ne},
+	"name":   {filter.Has},
+}
+
+func TestParse(t *testing.T) {
+	cases := map[string]string{
+		`age>30 AND status:active`:                   `(age>"30" AND status:"active")`,
+		`age>=18 AND age<65 OR status:retired`:       `((age>="18" AND age<"65") OR status:"retired")`,
+		`age>=18 AND (age<65 OR status:retired)`:     `(age>="18" AND (age<"65" OR status:"retired"))`,
+		`NOT status!=active`:                         `NOT status!="active"`,
+		`name:"Ada \"the countess\" Lovelace"`:       `name:"Ada \"the countess\" Lovelace"`,
+		`  ( ( name:ada ) )  `:                       `name:"ada"`,
+		`status:active OR status:pending OR age=1`:   `((status:"active" OR status:"pending") OR age="1")`,
+		`NOT (status:active AND NOT age<1)`:          `NOT (status:"active" AND NOT age<"1")`,
+		`age<=99 AND name:"AND"`:                     `(age<="99" AND name:"AND")`,
+		`status:"a b" AND age>1 AND name:x OR age=2`: `(((status:"a b" AND age>"1") AND name:"x") OR age="2")`,
+	}
+
+	for input, expected := range cases {
+		e, err := filter.Parse(input, schema)
+		if assert.NoError(t, err, input) {
+			assert.Equal(t, expected, e.String(), input)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, input := range []string{"", "age", "age>", "age>30 AND", "(age>30", "age>30)", `name:"open`, "age~3", "AND age>3", "age>30 status:x"} {
+		_, err := filter.Parse(input, schema)
+		var syntaxErr *filter.SyntaxError
+		assert.ErrorAs(t, err, &syntaxErr, input)
+	}
+
+	for _, input := range []string{"password:x", "status>3", "age>1 OR name=ada"} {
+		_, err := filter.Parse(input, schema)
+		var notAllowed *filter.NotAllowedError
+		assert.ErrorAs(t, err, &notAllowed, input)
+	}
+
+	deep := ""
+	for range 100 {
+		deep += "("
+	}
+	_, err := filter.Parse(deep+"age>1", schema)
+	assert.Error(t, err)
+}
+
+func TestScan(t *testing.T) {
+	type Params struct {
+		Filter filter.Expr `query:"q"`
+	}
+
+	p := Params{}
+	s := scanner.NewQuery(url.Values{"q": {"age>30 AND status:active"}}, scanner.WithCaster(schema.Cast))
+	assert.NoError(t, s.Scan(&p))
+	assert.Equal(t, &filter.And{
+		Left:  &filter.Comparison{Field: "age", Op: filter.Gt, Value: "30"},
+		Right: &filter.Comparison{Field: "status", Op: filter.Has, Value: "active"},
+	}, p.Filter)
+
+	s = scanner.NewQuery(url.Values{"q": {"secret:1"}}, scanner.WithCaster(schema.Cast))
+	var notAllowed *filter.NotAllowedError
+	assert.ErrorAs(t, s.Scan(&Params{}), &notAllowed)
+}
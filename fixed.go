@@ -0,0 +1,132 @@
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/canpacis/scanner/structd"
+)
+
+// fixedRecord is the getter of a single fixed-width record. Getters only receive the tag name, which is the
+// start offset of a field, so the byte ranges are read from the struct tags before decoding.
+type fixedRecord map[string]string
+
+func (r fixedRecord) Get(key string) any {
+	value, ok := r[key]
+	if !ok || value == "" {
+		return nil
+	}
+	return value
+}
+
+func (r fixedRecord) Cast(from any, to reflect.Type) (any, error) {
+	return structd.DefaultCast(from, to)
+}
+
+// newFixedRecord slices line into the trimmed byte ranges the `fixed` tags of v describe
+func newFixedRecord(line string, v any) (fixedRecord, error) {
+	r := fixedRecord{}
+	rt := reflect.TypeOf(v)
+	if rt == nil || rt.Kind() != reflect.Pointer || rt.Elem().Kind() != reflect.Struct {
+		return r, nil
+	}
+	rt = rt.Elem()
+
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		tag, ok := field.Tag.Lookup("fixed")
+		if !ok {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("scanner: fixed tag of %s must be in the form of `start,end`", field.Name)
+		}
+		start, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		end, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		if start < 0 || end < start {
+			return nil, fmt.Errorf("scanner: invalid fixed range %d,%d of %s", start, end, field.Name)
+		}
+
+		if start < len(line) {
+			r[parts[0]] = strings.TrimSpace(line[start:min(end, len(line))])
+		}
+	}
+
+	return r, nil
+}
+
+// A scanner to scan fixed-width records onto structs, fields name their byte range with the `fixed` tag,
+// e.g. `fixed:"0,10"` for the first ten bytes. Values are trimmed of their padding and cast to the field types.
+// Multi-record files are iterated like a `bufio.Scanner`:
+//
+//	for s.Next() {
+//		r := &Record{}
+//		if err := s.Scan(r); err != nil { ... }
+//	}
+type FixedWidth struct {
+	s       *bufio.Scanner
+	line    string
+	started bool
+	config  *config
+}
+
+// Next advances to the next record, it returns false at the end of the input or on an error
+func (f *FixedWidth) Next() bool {
+	f.started = true
+	for f.s.Scan() {
+		f.line = strings.TrimRight(f.s.Text(), "\r")
+		if f.line != "" {
+			return true
+		}
+	}
+
+	f.line = ""
+	return false
+}
+
+// Err returns the first error encountered while reading the input
+func (f *FixedWidth) Err() error {
+	return f.s.Err()
+}
+
+// Line returns the raw current record
+func (f *FixedWidth) Line() string {
+	return f.line
+}
+
+// Scans the current record onto v, the first record is read if `FixedWidth.Next` was not called yet
+func (f *FixedWidth) Scan(v any) error {
+	if !f.started && !f.Next() {
+		if err := f.Err(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+
+	r, err := newFixedRecord(f.line, v)
+	if err != nil {
+		return err
+	}
+	return f.config.decoder(r, "fixed").Decode(v)
+}
+
+func NewFixedWidth(r io.Reader, opts ...Option) *FixedWidth {
+	c := newConfig(opts)
+
+	return &FixedWidth{
+		s:      bufio.NewScanner(c.reader(r)),
+		config: c,
+	}
+}
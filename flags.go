@@ -0,0 +1,140 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+
+	"github.com/canpacis/scanner/structd"
+)
+
+// A FlagContext describes who feature flags are evaluated for, like a user or an organization
+type FlagContext struct {
+	// Key identifies the context, like the id of a user
+	Key string
+	// Kind is the kind of the context, `user` when it is empty
+	Kind string
+	// Attributes are the attributes targeting rules match
+	Attributes map[string]any
+}
+
+// A FlagSource evaluates feature flags, like LaunchDarkly or Unleash. Flag values are bools, strings,
+// numbers or json values.
+type FlagSource interface {
+	// Evaluate returns the values of the flags for fc, keyed by flag
+	Evaluate(ctx context.Context, fc FlagContext) (map[string]any, error)
+}
+
+// FlagSourceFunc is a function that implements FlagSource
+type FlagSourceFunc func(ctx context.Context, fc FlagContext) (map[string]any, error)
+
+func (f FlagSourceFunc) Evaluate(ctx context.Context, fc FlagContext) (map[string]any, error) {
+	return f(ctx, fc)
+}
+
+// StaticFlags is a FlagSource of the same values for every context, for tests and local development
+type StaticFlags map[string]any
+
+func (f StaticFlags) Evaluate(ctx context.Context, fc FlagContext) (map[string]any, error) {
+	return maps.Clone(f), nil
+}
+
+// A scanner to scan the feature flags of a context onto a struct with the `flag` tag. Fields of flags
+// the source does not return keep their value, so defaults can be set before scanning, and flags that
+// evaluate to false or zero turn them off.
+//
+//	type Flags struct {
+//		NewCheckout bool   `flag:"new-checkout"`
+//		Theme       string `flag:"theme"`
+//		MaxItems    int    `flag:"max-items"`
+//	}
+type Flags struct {
+	values map[string]any
+	config *config
+}
+
+func (s *Flags) Get(key string) any {
+	return s.values[key]
+}
+
+// Cast converts flag values to the types of their fields, numbers to any numeric type and json values
+// to the types they unmarshal to
+func (s *Flags) Cast(from any, to reflect.Type) (any, error) {
+	if _, ok := from.(string); ok {
+		return structd.DefaultCast(from, to)
+	}
+
+	rv := reflect.ValueOf(from)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return structd.DefaultCast(from, to)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if to.Kind() == reflect.String || !rv.CanConvert(to) {
+			return structd.DefaultCast(from, to)
+		}
+		converted := rv.Convert(to)
+		if rv.CanFloat() && converted.CanConvert(rv.Type()) && converted.Convert(rv.Type()).Float() != rv.Float() {
+			return nil, fmt.Errorf("scanner: flag value %v does not fit in %s", from, to)
+		}
+		return converted.Interface(), nil
+	}
+
+	b, err := json.Marshal(from)
+	if err != nil {
+		return nil, err
+	}
+	v := reflect.New(to)
+	if err := json.Unmarshal(b, v.Interface()); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
+
+// Scans the flag values onto v, flags that evaluate to false, zero or an empty string override the
+// defaults of their fields too
+func (s *Flags) Scan(v any) error {
+	if err := s.config.decoder(s, "flag").Decode(v); err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	rv = rv.Elem()
+	key := "flag"
+	if s.config.tag != "" {
+		key = s.config.tag
+	}
+
+	// the decoder skips zero values, so they are set here
+	for name, fields := range tagFields(rv.Type(), key) {
+		raw, ok := s.values[name]
+		if !ok || raw == nil || !reflect.ValueOf(raw).IsZero() {
+			continue
+		}
+		for _, field := range fields {
+			value := rv.FieldByName(field)
+			if value.Kind() == reflect.Pointer {
+				value.Set(reflect.New(value.Type().Elem()))
+			} else {
+				value.SetZero()
+			}
+		}
+	}
+	return nil
+}
+
+// NewFlags evaluates the flags of source for fc and creates a scanner of their values
+func NewFlags(ctx context.Context, source FlagSource, fc FlagContext, opts ...Option) (*Flags, error) {
+	values, err := source.Evaluate(ctx, fc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Flags{values: values, config: newConfig(opts)}, nil
+}
@@ -0,0 +1,88 @@
+// Package launchdarkly evaluates LaunchDarkly feature flags for `scanner.NewFlags` with the client side
+// evaluation api of LaunchDarkly, without its sdk.
+//
+//	flags, err := scanner.NewFlags(ctx, launchdarkly.New(clientSideID), scanner.FlagContext{Key: user.ID})
+package launchdarkly
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/canpacis/scanner"
+)
+
+// DefaultBaseURL is the base url of the client side evaluation api
+const DefaultBaseURL = "https://clientsdk.launchdarkly.com"
+
+// A Source evaluates the flags of a LaunchDarkly environment
+type Source struct {
+	// ClientSideID is the client side id of the environment
+	ClientSideID string
+	// BaseURL overrides `launchdarkly.DefaultBaseURL`, like for relay proxies
+	BaseURL string
+	// Client sends the requests, `http.DefaultClient` is used when it is nil
+	Client *http.Client
+}
+
+// New creates a source of the environment of clientSideID
+func New(clientSideID string) *Source {
+	return &Source{ClientSideID: clientSideID}
+}
+
+// Evaluate returns the values of the flags of the environment that are available to client side ids
+func (s *Source) Evaluate(ctx context.Context, fc scanner.FlagContext) (map[string]any, error) {
+	ldContext := map[string]any{}
+	for key, value := range fc.Attributes {
+		ldContext[key] = value
+	}
+	ldContext["key"] = fc.Key
+	ldContext["kind"] = fc.Kind
+	if fc.Kind == "" {
+		ldContext["kind"] = "user"
+	}
+	b, err := json.Marshal(ldContext)
+	if err != nil {
+		return nil, err
+	}
+
+	base := s.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	u := strings.TrimSuffix(base, "/") + "/sdk/evalx/" + s.ClientSideID + "/contexts/" + base64.RawURLEncoding.EncodeToString(b)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("launchdarkly: evaluating flags failed with status %s", res.Status)
+	}
+
+	flags := map[string]struct {
+		Value any `json:"value"`
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(&flags); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]any, len(flags))
+	for key, flag := range flags {
+		values[key] = flag.Value
+	}
+	return values, nil
+}
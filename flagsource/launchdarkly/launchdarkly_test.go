@@ -0,0 +1,58 @@
+package launchdarkly_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/canpacis/scanner"
+	"github.com/canpacis/scanner/flagsource/launchdarkly"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluate(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoded, ok := strings.CutPrefix(r.URL.Path, "/sdk/evalx/env-id/contexts/")
+		assert.True(ok)
+		b, err := base64.RawURLEncoding.DecodeString(encoded)
+		assert.NoError(err)
+		ldContext := map[string]any{}
+		assert.NoError(json.Unmarshal(b, &ldContext))
+		assert.Equal(map[string]any{"kind": "user", "key": "user-1", "country": "TR"}, ldContext)
+
+		fmt.Fprint(w, `{
+			"new-checkout": {"value": true, "variation": 0, "version": 4},
+			"theme": {"value": "dark", "variation": 1, "version": 2},
+			"max-items": {"value": 25, "variation": 0, "version": 1},
+			"banner": {"value": {"text": "sale", "color": "red"}, "variation": 0, "version": 1}
+		}`)
+	}))
+	defer server.Close()
+
+	type Banner struct {
+		Text  string `json:"text"`
+		Color string `json:"color"`
+	}
+	type Flags struct {
+		NewCheckout bool   `flag:"new-checkout"`
+		Theme       string `flag:"theme"`
+		MaxItems    int    `flag:"max-items"`
+		Banner      Banner `flag:"banner"`
+		Missing     string `flag:"missing"`
+	}
+
+	source := &launchdarkly.Source{ClientSideID: "env-id", BaseURL: server.URL}
+	s, err := scanner.NewFlags(context.Background(), source, scanner.FlagContext{Key: "user-1", Attributes: map[string]any{"country": "TR"}})
+	assert.NoError(err)
+
+	f := Flags{Missing: "default"}
+	assert.NoError(s.Scan(&f))
+	assert.Equal(Flags{NewCheckout: true, Theme: "dark", MaxItems: 25, Banner: Banner{Text: "sale", Color: "red"}, Missing: "default"}, f)
+}
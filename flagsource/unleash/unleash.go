@@ -0,0 +1,101 @@
+// Package unleash evaluates Unleash feature toggles for `scanner.NewFlags` with the frontend api of Unleash
+// or the Unleash edge and proxy, without its sdk.
+//
+//	flags, err := scanner.NewFlags(ctx, unleash.New(url, token), scanner.FlagContext{Key: user.ID})
+package unleash
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/canpacis/scanner"
+)
+
+// A Source evaluates the toggles of an Unleash frontend api
+type Source struct {
+	// URL is the url of the instance, like `https://unleash.example.com`
+	URL string
+	// Token is a frontend token
+	Token string
+	// Client sends the requests, `http.DefaultClient` is used when it is nil
+	Client *http.Client
+}
+
+// New creates a source of the instance at u that authenticates with a frontend token
+func New(u, token string) *Source {
+	return &Source{URL: u, Token: token}
+}
+
+// Evaluate returns the enabled toggles for fc, the key of the context is the user id and its attributes
+// are the properties of the context. A toggle is true, or the payload of its variant when it has one.
+// The frontend api leaves out disabled toggles.
+func (s *Source) Evaluate(ctx context.Context, fc scanner.FlagContext) (map[string]any, error) {
+	query := url.Values{}
+	if fc.Key != "" {
+		query.Set("userId", fc.Key)
+	}
+	for key, value := range fc.Attributes {
+		switch key {
+		case "sessionId", "remoteAddress", "environment", "appName":
+			query.Set(key, fmt.Sprint(value))
+		default:
+			query.Set("properties["+key+"]", fmt.Sprint(value))
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(s.URL, "/")+"/api/frontend?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", s.Token)
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unleash: evaluating toggles failed with status %s", res.Status)
+	}
+
+	body := struct {
+		Toggles []struct {
+			Name    string `json:"name"`
+			Enabled bool   `json:"enabled"`
+			Variant struct {
+				Enabled bool `json:"enabled"`
+				Payload *struct {
+					Type  string `json:"type"`
+					Value string `json:"value"`
+				} `json:"payload"`
+			} `json:"variant"`
+		} `json:"toggles"`
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]any, len(body.Toggles))
+	for _, toggle := range body.Toggles {
+		values[toggle.Name] = toggle.Enabled
+		if payload := toggle.Variant.Payload; toggle.Variant.Enabled && payload != nil {
+			values[toggle.Name] = payload.Value
+			if payload.Type == "json" {
+				var v any
+				if err := json.Unmarshal([]byte(payload.Value), &v); err == nil {
+					values[toggle.Name] = v
+				}
+			}
+		}
+	}
+	return values, nil
+}
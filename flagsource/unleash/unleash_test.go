@@ -0,0 +1,45 @@
+package unleash_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/canpacis/scanner"
+	"github.com/canpacis/scanner/flagsource/unleash"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluate(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("/api/frontend", r.URL.Path)
+		assert.Equal("token", r.Header.Get("Authorization"))
+		assert.Equal("user-1", r.URL.Query().Get("userId"))
+		assert.Equal("pro", r.URL.Query().Get("properties[plan]"))
+
+		fmt.Fprint(w, `{"toggles": [
+			{"name": "new-checkout", "enabled": true, "variant": {"name": "disabled", "enabled": false}},
+			{"name": "theme", "enabled": true, "variant": {"name": "dark", "enabled": true, "payload": {"type": "string", "value": "dark"}}},
+			{"name": "max-items", "enabled": true, "variant": {"name": "large", "enabled": true, "payload": {"type": "json", "value": "25"}}}
+		]}`)
+	}))
+	defer server.Close()
+
+	type Flags struct {
+		NewCheckout bool   `flag:"new-checkout"`
+		Theme       string `flag:"theme"`
+		MaxItems    int    `flag:"max-items"`
+		Disabled    bool   `flag:"disabled"`
+	}
+
+	s, err := scanner.NewFlags(context.Background(), unleash.New(server.URL, "token"), scanner.FlagContext{Key: "user-1", Attributes: map[string]any{"plan": "pro"}})
+	assert.NoError(err)
+
+	f := Flags{}
+	assert.NoError(s.Scan(&f))
+	assert.Equal(Flags{NewCheckout: true, Theme: "dark", MaxItems: 25}, f)
+}
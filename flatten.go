@@ -0,0 +1,185 @@
+package scanner
+
+import (
+	"encoding/json"
+	"io"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/canpacis/scanner/structd"
+)
+
+// jsonLayout is where the `flatten` and `remain` options of a struct's json tags move the keys of a body
+type jsonLayout struct {
+	// flatten are the fields whose objects have their keys matched against the struct, by key
+	flatten map[string]int
+	// remain is the index of the field that gathers the keys no field binds, -1 when there is none
+	remain int
+	// names are the names the fields of the struct bind
+	names []string
+}
+
+// binds reports whether a field of the struct binds key, matching case-insensitively like `encoding/json`
+func (l *jsonLayout) binds(key string) bool {
+	return slices.ContainsFunc(l.names, func(name string) bool {
+		return strings.EqualFold(name, key)
+	})
+}
+
+var jsonLayouts sync.Map
+
+// jsonLayoutOf returns the layout of the struct v points to, nil when its tags have neither option
+func jsonLayoutOf(v any) *jsonLayout {
+	rt := reflect.TypeOf(v)
+	if rt == nil || rt.Kind() != reflect.Pointer || rt.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	rt = rt.Elem()
+	if l, ok := jsonLayouts.Load(rt); ok {
+		return l.(*jsonLayout)
+	}
+
+	l := &jsonLayout{remain: -1, names: jsonNames(rt)}
+	for i := range rt.NumField() {
+		tag, ok := rt.Field(i).Tag.Lookup("json")
+		if !ok {
+			continue
+		}
+
+		spec := structd.ParseTag(tag)
+		switch {
+		case spec.Has("flatten") && spec.Name != "":
+			if l.flatten == nil {
+				l.flatten = map[string]int{}
+			}
+			l.flatten[spec.Name] = i
+		case spec.Has("remain") && rt.Field(i).IsExported() && rt.Field(i).Type.Kind() == reflect.Map && rt.Field(i).Type.Key().Kind() == reflect.String:
+			l.remain = i
+		}
+	}
+
+	var layout *jsonLayout
+	if len(l.flatten) > 0 || l.remain >= 0 {
+		layout = l
+	}
+	stored, _ := jsonLayouts.LoadOrStore(rt, layout)
+	return stored.(*jsonLayout)
+}
+
+// jsonNames returns the keys the exported fields of t bind, including the fields of embedded structs
+func jsonNames(t reflect.Type) []string {
+	names := []string{}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		spec := structd.ParseTag(tag)
+		if spec.Has("remain") {
+			continue
+		}
+
+		ft := field.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if field.Anonymous && spec.Name == "" && ft.Kind() == reflect.Struct {
+			names = append(names, jsonNames(ft)...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if spec.Name != "" {
+			names = append(names, spec.Name)
+		} else {
+			names = append(names, field.Name)
+		}
+	}
+	return names
+}
+
+// decodeFlat decodes a json object onto v after copying the keys of its flattened objects to the top level and
+// gathering the keys no field binds into its remain field. Keys of the top level win over flattened ones.
+func (s *JSON) decodeFlat(r io.Reader, v any, l *jsonLayout) error {
+	d := json.NewDecoder(r)
+	doc := map[string]json.RawMessage{}
+	if err := d.Decode(&doc); err != nil {
+		return err
+	}
+
+	flattened := map[int]json.RawMessage{}
+	for _, key := range slices.Sorted(maps.Keys(l.flatten)) {
+		raw, ok := doc[key]
+		if !ok || string(raw) == "null" {
+			continue
+		}
+		// the field receives its object on its own so strict decoding does not reject the keys it lifts
+		flattened[l.flatten[key]] = raw
+		delete(doc, key)
+
+		nested := map[string]json.RawMessage{}
+		if err := json.Unmarshal(raw, &nested); err != nil {
+			return err
+		}
+		for k, value := range nested {
+			if _, ok := doc[k]; !ok {
+				doc[k] = value
+			}
+		}
+	}
+
+	var remain map[string]json.RawMessage
+	if l.remain >= 0 {
+		remain = map[string]json.RawMessage{}
+		for k, value := range doc {
+			if !l.binds(k) {
+				remain[k] = value
+				delete(doc, k)
+			}
+		}
+	}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	d = json.NewDecoder(strings.NewReader(string(b)))
+	if s.config.strict {
+		d.DisallowUnknownFields()
+	}
+	if err := d.Decode(v); err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(v).Elem()
+	for _, i := range slices.Sorted(maps.Keys(flattened)) {
+		// unexported fields like `_ struct{}` only lift the keys of their objects
+		if !rv.Type().Field(i).IsExported() {
+			continue
+		}
+		if err := json.Unmarshal(flattened[i], rv.Field(i).Addr().Interface()); err != nil {
+			return err
+		}
+	}
+	if remain == nil {
+		return nil
+	}
+
+	field := rv.Field(l.remain)
+	gathered := reflect.New(field.Type())
+	b, err = json.Marshal(remain)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, gathered.Interface()); err != nil {
+		return err
+	}
+	field.Set(gathered.Elem())
+	return nil
+}
@@ -0,0 +1,135 @@
+package scanner
+
+import (
+	"maps"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/canpacis/scanner/structd"
+)
+
+// indexed splits a key like `items[0].name` or `items[0][name]` of the field name into the index and the
+// key within the element, `name`
+func indexed(name, key string) (int, string, bool) {
+	rest, ok := strings.CutPrefix(key, name+"[")
+	if !ok {
+		return 0, "", false
+	}
+	digits, rest, ok := strings.Cut(rest, "]")
+	if !ok {
+		return 0, "", false
+	}
+	i, err := strconv.Atoi(digits)
+	if err != nil || i < 0 {
+		return 0, "", false
+	}
+
+	switch {
+	case strings.HasPrefix(rest, "."):
+		return i, rest[1:], true
+	case strings.HasPrefix(rest, "["):
+		inner, remainder, ok := strings.Cut(rest[1:], "]")
+		if !ok {
+			return 0, "", false
+		}
+		return i, inner + remainder, true
+	default:
+		return 0, "", false
+	}
+}
+
+// structSlice reports whether t is a slice of structs or of pointers to structs
+func structSlice(t reflect.Type) bool {
+	if t.Kind() != reflect.Slice {
+		return false
+	}
+	elem := t.Elem()
+	if elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	return elem.Kind() == reflect.Struct
+}
+
+type indexedKey struct {
+	t   reflect.Type
+	key string
+}
+
+var indexedTypes sync.Map
+
+// hasIndexes reports whether the struct v points to has a slice of struct field with a key tag, the indexes
+// hook is only installed for such structs so others keep their generated decoders
+func hasIndexes(v any, key string) bool {
+	rt := reflect.TypeOf(v)
+	if rt == nil || rt.Kind() != reflect.Pointer || rt.Elem().Kind() != reflect.Struct {
+		return false
+	}
+	rt = rt.Elem()
+	if ok, found := indexedTypes.Load(indexedKey{rt, key}); found {
+		return ok.(bool)
+	}
+
+	ok := false
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		if _, tagged := field.Tag.Lookup(key); tagged && field.IsExported() && structSlice(field.Type) {
+			ok = true
+			break
+		}
+	}
+	indexedTypes.Store(indexedKey{rt, key}, ok)
+	return ok
+}
+
+// withIndexes binds the indexed keys of slice of struct fields, like `items[0].name=a&items[1].name=b`, to
+// their elements. Elements are decoded in the order of their indexes and gaps between them are dropped, so
+// a large index cannot allocate a large slice.
+func (v Form) withIndexes(key string) func(field reflect.StructField, raw any) (any, error) {
+	return func(field reflect.StructField, raw any) (any, error) {
+		if !structSlice(field.Type) {
+			return raw, nil
+		}
+		elem := field.Type.Elem()
+		target := elem
+		if target.Kind() == reflect.Pointer {
+			target = target.Elem()
+		}
+
+		name := structd.ParseTag(field.Tag.Get(key)).Name
+		elements := map[int]url.Values{}
+		for k, values := range *v.Values {
+			i, sub, ok := indexed(name, k)
+			if !ok {
+				continue
+			}
+			if elements[i] == nil {
+				elements[i] = url.Values{}
+			}
+			elements[i][sub] = values
+		}
+		if len(elements) == 0 {
+			return raw, nil
+		}
+
+		result := reflect.MakeSlice(field.Type, 0, len(elements))
+		for _, i := range slices.Sorted(maps.Keys(elements)) {
+			ptr := reflect.New(target)
+			values := elements[i]
+			nested := &Form{Values: &values, config: v.config.withinScan()}
+			if err := nested.Scan(ptr.Interface()); err != nil {
+				return nil, err
+			}
+
+			if elem.Kind() == reflect.Pointer {
+				result = reflect.Append(result, ptr)
+			} else {
+				result = reflect.Append(result, ptr.Elem())
+			}
+		}
+		return result.Interface(), nil
+	}
+}
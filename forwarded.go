@@ -0,0 +1,153 @@
+package scanner
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"reflect"
+	"strings"
+
+	"github.com/canpacis/scanner/structd"
+)
+
+// A scanner to scan the original client information of a request from the `Forwarded` (RFC 7239) or
+// `X-Forwarded-For`, `X-Forwarded-Proto` and `X-Forwarded-Host` headers. Only the hops appended by the
+// trusted proxies are honored. The available keys are `for` (the client ip), `proto` and `host`.
+type Forwarded struct {
+	values map[string]string
+	config *config
+}
+
+func (f *Forwarded) Get(key string) any {
+	return f.values[strings.ToLower(key)]
+}
+
+var addrType = reflect.TypeFor[netip.Addr]()
+
+func (f *Forwarded) Cast(from any, to reflect.Type) (any, error) {
+	if s, ok := from.(string); ok && to == addrType {
+		return netip.ParseAddr(s)
+	}
+
+	return structd.DefaultCast(from, to)
+}
+
+// Scans the forwarded client information onto v
+func (f *Forwarded) Scan(v any) error {
+	return f.config.decoder(f, "forwarded").Decode(v)
+}
+
+// parseNode extracts the ip address of a forwarded node like `"[2001:db8::1]:4711"` or `192.0.2.60`
+func parseNode(node string) (netip.Addr, bool) {
+	node = strings.Trim(strings.TrimSpace(node), `"`)
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		node = host
+	}
+
+	addr, err := netip.ParseAddr(strings.Trim(node, "[]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// forwardedHops parses the forwarded elements of a request in order from the client to the closest proxy
+func forwardedHops(h http.Header) []map[string]string {
+	hops := []map[string]string{}
+
+	if values := h.Values("Forwarded"); len(values) > 0 {
+		for _, value := range values {
+			for _, element := range splitList(value) {
+				hop := map[string]string{}
+				for _, pair := range strings.Split(element, ";") {
+					key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+					if ok {
+						hop[strings.ToLower(key)] = strings.Trim(value, `"`)
+					}
+				}
+				hops = append(hops, hop)
+			}
+		}
+		return hops
+	}
+
+	nodes := headerList(h, "X-Forwarded-For")
+	proto := headerList(h, "X-Forwarded-Proto")
+	host := headerList(h, "X-Forwarded-Host")
+	// proxies append to the headers together, so their values are paired with the hops from the right and
+	// the values a client sent ahead of them are left to the untrusted hops
+	for i, node := range nodes {
+		hop := map[string]string{"for": node}
+		if j := len(proto) - len(nodes) + i; j >= 0 {
+			hop["proto"] = proto[j]
+		}
+		if j := len(host) - len(nodes) + i; j >= 0 {
+			hop["host"] = host[j]
+		}
+		hops = append(hops, hop)
+	}
+
+	return hops
+}
+
+// headerList returns the elements of every value of the comma separated header key
+func headerList(h http.Header, key string) []string {
+	list := []string{}
+	for _, value := range h.Values(key) {
+		list = append(list, splitList(value)...)
+	}
+	return list
+}
+
+func NewForwarded(req *http.Request, trusted []netip.Prefix, opts ...Option) *Forwarded {
+	isTrusted := func(addr netip.Addr) bool {
+		for _, prefix := range trusted {
+			if prefix.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	values := map[string]string{
+		"proto": "http",
+		"host":  req.Host,
+	}
+	if req.TLS != nil {
+		values["proto"] = "https"
+	}
+
+	remote, ok := parseNode(req.RemoteAddr)
+	if ok {
+		values["for"] = remote.String()
+	}
+
+	if ok && isTrusted(remote) {
+		hops := forwardedHops(req.Header)
+
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := hops[i]
+			addr, ok := parseNode(hop["for"])
+			if !ok {
+				break
+			}
+
+			values["for"] = addr.String()
+			if proto := hop["proto"]; proto != "" {
+				values["proto"] = strings.ToLower(proto)
+			}
+			if host := hop["host"]; host != "" {
+				values["host"] = host
+			}
+
+			if !isTrusted(addr) {
+				break
+			}
+		}
+	}
+
+	return &Forwarded{
+		values: values,
+		config: newConfig(opts),
+	}
+}
@@ -0,0 +1,112 @@
+package scanner
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+
+	"github.com/BurntSushi/toml"
+	"github.com/canpacis/scanner/structd"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrUnterminatedFrontMatter is returned when a front matter block is opened but never closed
+var ErrUnterminatedFrontMatter = errors.New("scanner: unterminated front matter")
+
+// frontMatterBody is the getter of the `body` tag, every key resolves to the document body
+type frontMatterBody string
+
+func (b frontMatterBody) Get(key string) any {
+	return string(b)
+}
+
+func (b frontMatterBody) Cast(from any, to reflect.Type) (any, error) {
+	if to.Kind() == reflect.Slice && to.Elem().Kind() == reflect.Uint8 {
+		return []byte(from.(string)), nil
+	}
+	return structd.DefaultCast(from, to)
+}
+
+// A scanner to scan markdown documents with YAML (`---`) or TOML (`+++`) front matter onto structs.
+// Front matter values are bound with the `front` tag, nested tables decode onto nested structs,
+// and the document body after the front matter is bound to fields with the `body` tag.
+type FrontMatter struct {
+	r      io.Reader
+	config *config
+}
+
+// split separates the front matter from the body of a document and decodes it
+func (s *FrontMatter) split(b []byte) (map[string]any, []byte, error) {
+	values := map[string]any{}
+
+	var fence string
+	switch {
+	case hasFence(b, "---"):
+		fence = "---"
+	case hasFence(b, "+++"):
+		fence = "+++"
+	default:
+		return values, b, nil
+	}
+
+	_, rest, _ := bytes.Cut(b, []byte("\n"))
+	start := len(b) - len(rest)
+	for {
+		line, next, found := bytes.Cut(rest, []byte("\n"))
+		if string(bytes.TrimRight(line, " \t\r")) == fence {
+			break
+		}
+		if !found {
+			return nil, nil, ErrUnterminatedFrontMatter
+		}
+		rest = next
+	}
+	matter := b[start : len(b)-len(rest)]
+	_, rest, _ = bytes.Cut(rest, []byte("\n"))
+
+	var err error
+	if fence == "---" {
+		err = yaml.Unmarshal(matter, &values)
+	} else {
+		err = toml.Unmarshal(matter, &values)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return values, rest, nil
+}
+
+// hasFence reports whether the first line of b is fence
+func hasFence(b []byte, fence string) bool {
+	line, _, _ := bytes.Cut(b, []byte("\n"))
+	return string(bytes.TrimRight(line, " \t\r")) == fence
+}
+
+// Scans the front matter and the body onto v
+func (s *FrontMatter) Scan(v any) error {
+	b, err := io.ReadAll(s.r)
+	if err != nil {
+		return err
+	}
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+
+	values, body, err := s.split(b)
+	if err != nil {
+		return err
+	}
+
+	if err := s.config.decoder(&document{values: values, key: "front", config: s.config}, "front").Decode(v); err != nil {
+		return err
+	}
+	return s.config.decoder(frontMatterBody(body), "body").Decode(v)
+}
+
+func NewFrontMatter(r io.Reader, opts ...Option) *FrontMatter {
+	c := newConfig(withoutTag(opts))
+
+	return &FrontMatter{
+		r:      c.reader(r),
+		config: c,
+	}
+}
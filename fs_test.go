@@ -38,8 +38,9 @@ func (fi *FileInfo) Sys() any {
 }
 
 type File struct {
-	info   *FileInfo
-	closed bool
+	info    *FileInfo
+	content []byte
+	closed  bool
 }
 
 func (f *File) Stat() (fs.FileInfo, error) {
@@ -61,6 +62,7 @@ func (f *File) Close() error {
 
 func NewFile(name string, content []byte) *File {
 	return &File{
+		content: content,
 		info: &FileInfo{
 			name:    name,
 			mode:    fs.ModePerm,
@@ -79,6 +81,9 @@ func (fsys FS) Open(name string) (fs.File, error) {
 	if !ok {
 		return nil, fs.ErrNotExist
 	}
+	// every open reads the file from its start like a new handle
+	file.info.buf = bytes.NewBuffer(file.content)
+	file.closed = false
 	return file, nil
 }
 
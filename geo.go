@@ -0,0 +1,184 @@
+package scanner
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	errLatitude  = errors.New("scanner: latitude must be between -90 and 90")
+	errLongitude = errors.New("scanner: longitude must be between -180 and 180")
+)
+
+// parseCoordinates parses n comma separated numbers
+func parseCoordinates(s string, n int) ([]float64, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != n {
+		return nil, errors.New("scanner: expected " + strconv.Itoa(n) + " comma separated coordinates")
+	}
+
+	values := make([]float64, n)
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+func checkLatLng(lat, lng float64) error {
+	if lat < -90 || lat > 90 {
+		return errLatitude
+	}
+	if lng < -180 || lng > 180 {
+		return errLongitude
+	}
+	return nil
+}
+
+// LatLng is a point on the earth, it is parsed from `lat,lng` pairs like `41.01,28.97`
+// and from GeoJSON point objects in json bodies.
+type LatLng struct {
+	Lat float64
+	Lng float64
+}
+
+func (l *LatLng) UnmarshalString(s string) error {
+	values, err := parseCoordinates(s, 2)
+	if err != nil {
+		return err
+	}
+	if err := checkLatLng(values[0], values[1]); err != nil {
+		return err
+	}
+
+	*l = LatLng{Lat: values[0], Lng: values[1]}
+	return nil
+}
+
+type geoJSONPoint struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+func (l *LatLng) UnmarshalJSON(b []byte) error {
+	point := geoJSONPoint{}
+	if err := json.Unmarshal(b, &point); err != nil {
+		return err
+	}
+	if point.Type != "Point" || len(point.Coordinates) < 2 {
+		return errors.New("scanner: expected a GeoJSON point")
+	}
+
+	// GeoJSON positions are in longitude, latitude order
+	lng, lat := point.Coordinates[0], point.Coordinates[1]
+	if err := checkLatLng(lat, lng); err != nil {
+		return err
+	}
+
+	*l = LatLng{Lat: lat, Lng: lng}
+	return nil
+}
+
+func (l LatLng) MarshalJSON() ([]byte, error) {
+	return json.Marshal(geoJSONPoint{Type: "Point", Coordinates: []float64{l.Lng, l.Lat}})
+}
+
+func (l LatLng) String() string {
+	return strconv.FormatFloat(l.Lat, 'f', -1, 64) + "," + strconv.FormatFloat(l.Lng, 'f', -1, 64)
+}
+
+// BBox is a bounding box, it is parsed from `minLng,minLat,maxLng,maxLat` like the GeoJSON `bbox` member
+type BBox struct {
+	Min LatLng
+	Max LatLng
+}
+
+func (b *BBox) UnmarshalString(s string) error {
+	values, err := parseCoordinates(s, 4)
+	if err != nil {
+		return err
+	}
+
+	box := BBox{Min: LatLng{Lat: values[1], Lng: values[0]}, Max: LatLng{Lat: values[3], Lng: values[2]}}
+	if err := checkLatLng(box.Min.Lat, box.Min.Lng); err != nil {
+		return err
+	}
+	if err := checkLatLng(box.Max.Lat, box.Max.Lng); err != nil {
+		return err
+	}
+	if box.Min.Lat > box.Max.Lat {
+		return errors.New("scanner: bounding box minimum latitude is above its maximum")
+	}
+
+	*b = box
+	return nil
+}
+
+// Contains reports whether the point is inside the box, boxes whose minimum longitude is greater than
+// their maximum cross the antimeridian
+func (b BBox) Contains(p LatLng) bool {
+	if p.Lat < b.Min.Lat || p.Lat > b.Max.Lat {
+		return false
+	}
+	if b.Min.Lng <= b.Max.Lng {
+		return p.Lng >= b.Min.Lng && p.Lng <= b.Max.Lng
+	}
+	return p.Lng >= b.Min.Lng || p.Lng <= b.Max.Lng
+}
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// Geohash is a validated geohash string like `sxk97`
+type Geohash string
+
+func (g *Geohash) UnmarshalString(s string) error {
+	s = strings.ToLower(s)
+	if s == "" || len(s) > 12 {
+		return errors.New("scanner: geohash must be 1 to 12 characters long")
+	}
+	for _, c := range s {
+		if !strings.ContainsRune(geohashAlphabet, c) {
+			return errors.New("scanner: invalid geohash character " + strconv.QuoteRune(c))
+		}
+	}
+
+	*g = Geohash(s)
+	return nil
+}
+
+// Bounds returns the cell of the geohash
+func (g Geohash) Bounds() BBox {
+	lat := [2]float64{-90, 90}
+	lng := [2]float64{-180, 180}
+	even := true
+
+	for _, c := range string(g) {
+		bits := strings.IndexRune(geohashAlphabet, c)
+		for i := 4; i >= 0; i-- {
+			r := &lat
+			if even {
+				r = &lng
+			}
+			mid := (r[0] + r[1]) / 2
+			if bits&(1<<i) != 0 {
+				r[0] = mid
+			} else {
+				r[1] = mid
+			}
+			even = !even
+		}
+	}
+
+	return BBox{Min: LatLng{Lat: lat[0], Lng: lng[0]}, Max: LatLng{Lat: lat[1], Lng: lng[1]}}
+}
+
+// Center returns the center of the geohash cell
+func (g Geohash) Center() LatLng {
+	b := g.Bounds()
+	return LatLng{Lat: (b.Min.Lat + b.Max.Lat) / 2, Lng: (b.Min.Lng + b.Max.Lng) / 2}
+}
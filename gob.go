@@ -0,0 +1,25 @@
+package scanner
+
+import (
+	"encoding/gob"
+	"io"
+)
+
+// A scanner to scan `encoding/gob` streams onto values. A stream can carry many values,
+// every call to Scan decodes the next one and `io.EOF` is returned at the end of the stream.
+type Gob struct {
+	d *gob.Decoder
+}
+
+// Scans the next value of the stream onto v
+func (s *Gob) Scan(v any) error {
+	return s.d.Decode(v)
+}
+
+func NewGob(r io.Reader, opts ...Option) *Gob {
+	c := newConfig(opts)
+
+	return &Gob{
+		d: gob.NewDecoder(c.reader(r)),
+	}
+}
@@ -0,0 +1,28 @@
+package scanner
+
+// GraphQLRequest is the body of a graphql request over http
+type GraphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// A scanner to scan the variables of a graphql request onto a struct with the `gql` tag,
+// variables are cast to the field types, including nested input objects and lists.
+type GraphQL struct {
+	req    *GraphQLRequest
+	config *config
+}
+
+// Scans the graphql variables onto v
+func (s *GraphQL) Scan(v any) error {
+	d := &document{values: s.req.Variables, key: "gql", config: s.config}
+	return s.config.decoder(d, "gql").Decode(v)
+}
+
+func NewGraphQL(req *GraphQLRequest, opts ...Option) *GraphQL {
+	return &GraphQL{
+		req:    req,
+		config: newConfig(opts),
+	}
+}
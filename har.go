@@ -0,0 +1,164 @@
+package scanner
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HARNameValue is a name value pair of a HAR request, like a header or a query parameter
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData is the body of a HAR request
+type HARPostData struct {
+	MimeType string         `json:"mimeType"`
+	Text     string         `json:"text"`
+	Params   []HARNameValue `json:"params"`
+}
+
+// HARRequest is a request recorded in a HAR file
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	Cookies     []HARNameValue `json:"cookies"`
+	PostData    *HARPostData   `json:"postData"`
+}
+
+// HARResponse is the response of a recorded request
+type HARResponse struct {
+	Status     int            `json:"status"`
+	StatusText string         `json:"statusText"`
+	Headers    []HARNameValue `json:"headers"`
+}
+
+// HAREntry is a single recorded exchange of a HAR file, it scans its request as if it arrived live
+// with the `header`, `query`, `cookie`, `json` and `form` tags of `scanner.Request`
+type HAREntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+
+	opts []Option
+}
+
+// HTTPRequest reconstructs the recorded request
+func (e *HAREntry) HTTPRequest() (*http.Request, error) {
+	var body io.Reader = http.NoBody
+	contentType := ""
+	if data := e.Request.PostData; data != nil {
+		contentType = data.MimeType
+		switch {
+		case data.Text != "":
+			body = strings.NewReader(data.Text)
+		case len(data.Params) > 0:
+			form := url.Values{}
+			for _, param := range data.Params {
+				form.Add(param.Name, param.Value)
+			}
+			body = strings.NewReader(form.Encode())
+		}
+	}
+
+	req, err := http.NewRequest(e.Request.Method, e.Request.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	for _, header := range e.Request.Headers {
+		if strings.HasPrefix(header.Name, ":") {
+			continue
+		}
+		req.Header.Add(header.Name, header.Value)
+	}
+	if contentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if req.Header.Get("Cookie") == "" {
+		for _, cookie := range e.Request.Cookies {
+			req.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+		}
+	}
+
+	return req, nil
+}
+
+// Scans the request of the entry onto v
+func (e *HAREntry) Scan(v any) error {
+	req, err := e.HTTPRequest()
+	if err != nil {
+		return err
+	}
+
+	return NewRequest(req, e.opts...).Scan(v)
+}
+
+// A HAR is a parsed HTTP Archive
+type HAR struct {
+	Entries []*HAREntry
+}
+
+// Route returns scanners of the recorded requests that match a `http.ServeMux` pattern, like
+// `POST /orders/{id}`, with their path values set for the `path` tag
+func (h *HAR) Route(pattern string) ([]*Request, error) {
+	var matched *http.Request
+	mux := http.NewServeMux()
+	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		matched = r
+	})
+
+	requests := []*Request{}
+	for _, entry := range h.Entries {
+		req, err := entry.HTTPRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		matched = nil
+		mux.ServeHTTP(discardWriter{}, req)
+		if matched != nil {
+			requests = append(requests, NewRequest(matched, entry.opts...))
+		}
+	}
+
+	return requests, nil
+}
+
+// discardWriter is the response writer of route matching
+type discardWriter struct{}
+
+func (discardWriter) Header() http.Header {
+	return http.Header{}
+}
+
+func (discardWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (discardWriter) WriteHeader(int) {}
+
+// NewHAR parses a HAR file
+func NewHAR(r io.Reader, opts ...Option) (*HAR, error) {
+	c := newConfig(opts)
+
+	doc := struct {
+		Log struct {
+			Entries []*HAREntry `json:"entries"`
+		} `json:"log"`
+	}{}
+	if err := json.NewDecoder(c.reader(r)).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	for _, entry := range doc.Log.Entries {
+		entry.opts = opts
+	}
+	return &HAR{Entries: doc.Log.Entries}, nil
+}
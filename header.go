@@ -0,0 +1,34 @@
+package scanner
+
+import "strings"
+
+// splitList splits a comma separated header list into its trimmed, non-empty elements,
+// commas inside quoted strings are not treated as separators
+func splitList(s string) []string {
+	elements := []string{}
+	quoted := false
+	escaped := false
+	start := 0
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && s[i] == '\\':
+			escaped = true
+		case s[i] == '"':
+			quoted = !quoted
+		case !quoted && s[i] == ',':
+			if element := strings.TrimSpace(s[start:i]); element != "" {
+				elements = append(elements, element)
+			}
+			start = i + 1
+		}
+	}
+
+	if element := strings.TrimSpace(s[start:]); element != "" {
+		elements = append(elements, element)
+	}
+
+	return elements
+}
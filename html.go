@@ -0,0 +1,339 @@
+package scanner
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/canpacis/scanner/structd"
+	"golang.org/x/net/html"
+)
+
+// selector is a parsed css selector, compounds are matched right to left and combinators[i]
+// joins compounds[i] to the one before it, either ' ' for descendants or '>' for children
+type selector struct {
+	compounds   []compound
+	combinators []byte
+}
+
+// compound is a sequence of simple selectors that all match a single element
+type compound struct {
+	tag     string
+	id      string
+	classes []string
+	attrs   []attrSelector
+}
+
+type attrSelector struct {
+	name  string
+	value string
+	exact bool
+}
+
+// parseSelector parses the supported subset of css selectors: type, #id, .class, [attr] and [attr=value]
+// simple selectors combined with descendant and child combinators
+func parseSelector(s string) (*selector, error) {
+	sel := &selector{}
+	current := compound{}
+	empty := true
+	combinator := byte(' ')
+
+	push := func() {
+		if empty {
+			return
+		}
+		sel.compounds = append(sel.compounds, current)
+		sel.combinators = append(sel.combinators, combinator)
+		current = compound{}
+		empty = true
+		combinator = ' '
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			push()
+			i++
+		case c == '>':
+			push()
+			if len(sel.compounds) == 0 {
+				return nil, fmt.Errorf("scanner: selector %q starts with a combinator", s)
+			}
+			combinator = '>'
+			i++
+		case c == '#' || c == '.':
+			j := i + 1
+			for j < len(s) && isIdentByte(s[j]) {
+				j++
+			}
+			if j == i+1 {
+				return nil, fmt.Errorf("scanner: empty name in selector %q", s)
+			}
+			if c == '#' {
+				current.id = s[i+1 : j]
+			} else {
+				current.classes = append(current.classes, s[i+1:j])
+			}
+			empty = false
+			i = j
+		case c == '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("scanner: unterminated attribute in selector %q", s)
+			}
+			name, value, exact := strings.Cut(s[i+1:i+end], "=")
+			current.attrs = append(current.attrs, attrSelector{
+				name:  strings.ToLower(strings.TrimSpace(name)),
+				value: strings.Trim(strings.TrimSpace(value), `"'`),
+				exact: exact,
+			})
+			empty = false
+			i += end + 1
+		case isIdentByte(c) || c == '*':
+			j := i + 1
+			for j < len(s) && isIdentByte(s[j]) {
+				j++
+			}
+			if c != '*' {
+				current.tag = strings.ToLower(s[i:j])
+			}
+			empty = false
+			i = j
+		default:
+			return nil, fmt.Errorf("scanner: unsupported character %q in selector %q", c, s)
+		}
+	}
+	push()
+
+	if len(sel.compounds) == 0 {
+		return nil, fmt.Errorf("scanner: empty selector")
+	}
+	return sel, nil
+}
+
+func isIdentByte(c byte) bool {
+	return c == '-' || c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func (c compound) match(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	if c.tag != "" && n.Data != c.tag {
+		return false
+	}
+	if c.id != "" && attr(n, "id") != c.id {
+		return false
+	}
+	for _, class := range c.classes {
+		found := false
+		for _, field := range strings.Fields(attr(n, "class")) {
+			if field == class {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, a := range c.attrs {
+		value, ok := lookupAttr(n, a.name)
+		if !ok || a.exact && value != a.value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// match reports whether n matches the compounds up to and including i
+func (s *selector) match(n *html.Node, i int) bool {
+	if !s.compounds[i].match(n) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+
+	if s.combinators[i] == '>' {
+		return n.Parent != nil && s.match(n.Parent, i-1)
+	}
+	for p := n.Parent; p != nil; p = p.Parent {
+		if s.match(p, i-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectAll returns every element under root that matches s in document order
+func (s *selector) selectAll(root *html.Node) []*html.Node {
+	nodes := []*html.Node{}
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if s.match(n, len(s.compounds)-1) {
+			nodes = append(nodes, n)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+
+	return nodes
+}
+
+func lookupAttr(n *html.Node, name string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func attr(n *html.Node, name string) string {
+	value, _ := lookupAttr(n, name)
+	return value
+}
+
+// text returns the text content of n with its whitespace collapsed
+func text(n *html.Node) string {
+	b := &strings.Builder{}
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+			b.WriteByte(' ')
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+var optionSelector = &selector{compounds: []compound{{tag: "option"}}, combinators: []byte{' '}}
+
+// value returns the value of a form control or the text content of any other element
+func value(n *html.Node) (string, bool) {
+	switch n.Data {
+	case "input":
+		switch strings.ToLower(attr(n, "type")) {
+		case "checkbox", "radio":
+			if _, ok := lookupAttr(n, "checked"); !ok {
+				return "", false
+			}
+			if v, ok := lookupAttr(n, "value"); ok {
+				return v, true
+			}
+			return "on", true
+		}
+		return attr(n, "value"), true
+	case "select":
+		var first *html.Node
+		for _, option := range optionSelector.selectAll(n) {
+			if first == nil {
+				first = option
+			}
+			if _, ok := lookupAttr(option, "selected"); ok {
+				return value(option)
+			}
+		}
+		if first == nil {
+			return "", false
+		}
+		return value(first)
+	case "option":
+		if v, ok := lookupAttr(n, "value"); ok {
+			return v, true
+		}
+		return text(n), true
+	case "textarea":
+		b := &strings.Builder{}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.TextNode {
+				b.WriteString(c.Data)
+			}
+		}
+		return b.String(), true
+	default:
+		return text(n), true
+	}
+}
+
+// htmlDocument is the getter of a parsed html document, keys are css selectors optionally followed by
+// `@attr` to read an attribute instead of the element value
+type htmlDocument struct {
+	root *html.Node
+}
+
+func (d *htmlDocument) Get(key string) any {
+	values := d.GetAll(key)
+	if len(values) == 0 {
+		return nil
+	}
+	return values[0]
+}
+
+func (d *htmlDocument) GetAll(key string) []string {
+	query, attribute, byAttr := strings.Cut(key, "@")
+	sel, err := parseSelector(query)
+	if err != nil {
+		return nil
+	}
+
+	values := []string{}
+	for _, n := range sel.selectAll(d.root) {
+		if byAttr {
+			if v, ok := lookupAttr(n, strings.ToLower(attribute)); ok {
+				values = append(values, v)
+			}
+			continue
+		}
+		if v, ok := value(n); ok {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func (d *htmlDocument) Cast(from any, to reflect.Type) (any, error) {
+	if from == "on" && to.Kind() == reflect.Bool {
+		return reflect.ValueOf(true).Convert(to).Interface(), nil
+	}
+	return structd.DefaultCast(from, to)
+}
+
+// A scanner to extract values from html documents onto structs. Fields select elements with css selectors
+// in the `html` tag, like `html:"h1.title"` or `html:"input[name=csrf]"`. Form controls bind their current
+// value and other elements their text content, a trailing `@attr` binds an attribute instead, like
+// `html:"a.next@href"`. Slice fields receive every matching element.
+type HTML struct {
+	r      io.Reader
+	config *config
+}
+
+// Scans the document onto v
+func (s *HTML) Scan(v any) error {
+	root, err := html.Parse(s.r)
+	if err != nil {
+		return err
+	}
+
+	return s.config.decoder(&htmlDocument{root: root}, "html").Decode(v)
+}
+
+func NewHTML(r io.Reader, opts ...Option) *HTML {
+	c := newConfig(opts)
+
+	return &HTML{
+		r:      c.reader(r),
+		config: c,
+	}
+}
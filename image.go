@@ -0,0 +1,179 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	"io"
+	"mime/multipart"
+	"reflect"
+	"time"
+
+	"github.com/canpacis/scanner/structd"
+)
+
+// ImageInfo describes an uploaded image without keeping its pixels, it can be the destination of an `image` field
+// next to the field of the image itself.
+type ImageInfo struct {
+	Format string
+	Width  int
+	Height int
+	// Frames is the number of frames of an animation, 1 for still images
+	Frames int
+	// Duration is the total delay of the frames of an animation
+	Duration time.Duration
+	// LoopCount is the number of times an animation loops, 0 loops forever and -1 plays it once
+	LoopCount int
+}
+
+// An UnsupportedImageError describes an uploaded image in a format without a registered decoder
+type UnsupportedImageError struct {
+	Format string
+}
+
+func (e *UnsupportedImageError) Error() string {
+	return "scanner: no decoder is registered for " + e.Format + " images, import github.com/canpacis/scanner/imageformat/" + e.Format
+}
+
+func (e *UnsupportedImageError) Unwrap() error {
+	return image.ErrFormat
+}
+
+// sniffImage names the formats decoders are provided for in the imageformat packages
+func sniffImage(header []byte) string {
+	if len(header) < 12 {
+		return ""
+	}
+
+	switch {
+	case string(header[:4]) == "RIFF" && string(header[8:]) == "WEBP":
+		return "webp"
+	case string(header[4:]) == "ftypavif" || string(header[4:]) == "ftypavis":
+		return "avif"
+	default:
+		return ""
+	}
+}
+
+var (
+	imageType     = reflect.TypeFor[image.Image]()
+	gifType       = reflect.TypeFor[*gif.GIF]()
+	imageInfoType = reflect.TypeFor[ImageInfo]()
+)
+
+// imageSource is an uploaded file that can be decoded by more than one field
+type imageSource struct {
+	file multipart.File
+	read bool
+	img  image.Image
+}
+
+// reader returns the file, rewinding it when it was read before
+func (s *imageSource) reader() (io.Reader, error) {
+	if s.read {
+		if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+	s.read = true
+	return s.file, nil
+}
+
+// decodeImage decodes an uploaded file to to, an `image.Image` receives the first frame of an animation
+// and a `*gif.GIF` every frame
+func decodeImage(s *imageSource, to reflect.Type) (any, error) {
+	r, header, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+
+	var v any
+	switch to {
+	case gifType:
+		v, err = gif.DecodeAll(r)
+	case imageInfoType:
+		v, err = decodeImageInfo(r)
+	default:
+		s.img, _, err = image.Decode(r)
+		v = s.img
+	}
+	return v, unsupportedImage(err, header)
+}
+
+// image returns the decoded file, decoding it when no field did
+func (s *imageSource) image() (image.Image, error) {
+	if s.img != nil {
+		return s.img, nil
+	}
+	v, err := decodeImage(s, imageType)
+	if err != nil {
+		return nil, err
+	}
+	return v.(image.Image), nil
+}
+
+// open returns a buffered reader of the file and its first bytes to sniff its format
+func (s *imageSource) open() (*bufio.Reader, []byte, error) {
+	file, err := s.reader()
+	if err != nil {
+		return nil, nil, err
+	}
+	r := bufio.NewReader(file)
+	header, _ := r.Peek(12)
+	return r, bytes.Clone(header), nil
+}
+
+// unsupportedImage reports decoding errors of formats that have a decoder in the imageformat packages
+// as a `*scanner.UnsupportedImageError`
+func unsupportedImage(err error, header []byte) error {
+	if errors.Is(err, image.ErrFormat) {
+		if format := sniffImage(header); format != "" {
+			return &UnsupportedImageError{Format: format}
+		}
+	}
+	return err
+}
+
+// decodeImageInfo reads the format and dimensions of an image, and the frames of gif animations
+func decodeImageInfo(r io.Reader) (ImageInfo, error) {
+	buf := &bytes.Buffer{}
+	config, format, err := image.DecodeConfig(io.TeeReader(r, buf))
+	if err != nil {
+		return ImageInfo{}, err
+	}
+
+	info := ImageInfo{Format: format, Width: config.Width, Height: config.Height, Frames: 1, LoopCount: -1}
+	if format != "gif" {
+		return info, nil
+	}
+
+	g, err := gif.DecodeAll(io.MultiReader(buf, r))
+	if err != nil {
+		return ImageInfo{}, err
+	}
+	info.Frames = len(g.Image)
+	info.LoopCount = g.LoopCount
+	for _, delay := range g.Delay {
+		info.Duration += time.Duration(delay) * 10 * time.Millisecond
+	}
+	return info, nil
+}
+
+func (v Image) Cast(from any, to reflect.Type) (any, error) {
+	if o, ok := from.(*imageOutputSource); ok && to == imageOutputType {
+		return encodeImage(o)
+	}
+	s, ok := from.(*imageSource)
+	if !ok {
+		return structd.DefaultCast(from, to)
+	}
+
+	switch to {
+	case imageType, gifType, imageInfoType:
+		return decodeImage(s, to)
+	default:
+		return structd.DefaultCast(from, to)
+	}
+}
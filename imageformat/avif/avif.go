@@ -0,0 +1,29 @@
+// Package avif registers an AVIF decoder for the Image scanner, and an encoder for `scanner.ImageOutput` fields
+// that request `format=avif`. Import it for its side effects:
+//
+//	import _ "github.com/canpacis/scanner/imageformat/avif"
+//
+// The codec runs libavif compiled to WebAssembly, so it needs no cgo but adds to the size of the binary.
+package avif
+
+import (
+	"image"
+	"io"
+
+	"github.com/canpacis/scanner"
+	"github.com/gen2brain/avif"
+)
+
+func init() {
+	scanner.RegisterImageEncoder("avif", func(w io.Writer, img image.Image, quality int) error {
+		if quality == 0 {
+			quality = avif.DefaultQuality
+		}
+		return avif.Encode(w, img, avif.Options{
+			Quality:           quality,
+			QualityAlpha:      quality,
+			Speed:             avif.DefaultSpeed,
+			ChromaSubsampling: image.YCbCrSubsampleRatio420,
+		})
+	})
+}
@@ -0,0 +1,48 @@
+package avif_test
+
+import (
+	"bytes"
+	"image"
+	"mime/multipart"
+	"os"
+	"testing"
+
+	"github.com/canpacis/scanner"
+	_ "github.com/canpacis/scanner/imageformat/avif"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecode(t *testing.T) {
+	assert := assert.New(t)
+
+	content, err := os.ReadFile("testdata/test.avif")
+	assert.NoError(err)
+
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	part, err := w.CreateFormFile("photo", "test.avif")
+	assert.NoError(err)
+	part.Write(content)
+	assert.NoError(w.Close())
+
+	values, err := scanner.MultipartValuesFromReader(multipart.NewReader(body, w.Boundary()), 1<<20)
+	assert.NoError(err)
+
+	type Params struct {
+		Photo image.Image         `image:"photo"`
+		Info  scanner.ImageInfo   `image:"photo"`
+		Small scanner.ImageOutput `image:"photo,quality=40"`
+	}
+
+	s := scanner.NewImage(values)
+	defer s.Close()
+
+	p := Params{}
+	assert.NoError(s.Scan(&p))
+	assert.NotNil(p.Photo)
+	assert.Equal("avif", p.Info.Format)
+	assert.Equal(p.Photo.Bounds().Dx(), p.Info.Width)
+	assert.Equal(p.Photo.Bounds().Dy(), p.Info.Height)
+	assert.Equal("avif", p.Small.Format)
+	assert.NotEmpty(p.Small.Bytes)
+}
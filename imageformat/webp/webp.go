@@ -0,0 +1,9 @@
+// Package webp registers the WebP decoder of golang.org/x/image for the Image scanner, import it for its
+// side effect:
+//
+//	import _ "github.com/canpacis/scanner/imageformat/webp"
+package webp
+
+import (
+	_ "golang.org/x/image/webp"
+)
@@ -0,0 +1,126 @@
+package scanner
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/canpacis/scanner/structd"
+)
+
+// An ImageEncoder encodes an image to w, quality is in the range [1,100] and 0 asks for the default of the encoder
+type ImageEncoder func(w io.Writer, img image.Image, quality int) error
+
+var (
+	encodersMu sync.RWMutex
+	encoders   = map[string]ImageEncoder{
+		"png": func(w io.Writer, img image.Image, quality int) error {
+			return png.Encode(w, img)
+		},
+		"jpeg": func(w io.Writer, img image.Image, quality int) error {
+			if quality == 0 {
+				quality = jpeg.DefaultQuality
+			}
+			return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+		},
+		"gif": func(w io.Writer, img image.Image, quality int) error {
+			return gif.Encode(w, img, nil)
+		},
+	}
+)
+
+// RegisterImageEncoder registers an encoder for the format an `scanner.ImageOutput` field can request,
+// png, jpeg and gif are registered by default
+func RegisterImageEncoder(format string, encoder ImageEncoder) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	encoders[format] = encoder
+}
+
+func imageEncoder(format string) (ImageEncoder, bool) {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	encoder, ok := encoders[format]
+	return encoder, ok
+}
+
+// ImageOutput is an uploaded image decoded and encoded again once during scanning, to normalize uploads.
+// The target format and quality are the `format=` and `quality=` options of its tag, like
+// `image:"avatar,format=jpeg,quality=80"`, the format defaults to the format of the upload.
+type ImageOutput struct {
+	Image  image.Image
+	Format string
+	Bytes  []byte
+}
+
+// Reader returns a reader of the encoded image
+func (o ImageOutput) Reader() io.Reader {
+	return bytes.NewReader(o.Bytes)
+}
+
+var imageOutputType = reflect.TypeFor[ImageOutput]()
+
+// imageOutputSource is an uploaded file with the encoding options of an ImageOutput field
+type imageOutputSource struct {
+	source  *imageSource
+	format  string
+	quality int
+}
+
+// withImageOutput passes the tag options of ImageOutput fields to the cast of their files
+func withImageOutput(key string) func(field reflect.StructField, raw any) (any, error) {
+	return func(field reflect.StructField, raw any) (any, error) {
+		source, ok := raw.(*imageSource)
+		if !ok || field.Type != imageOutputType {
+			return raw, nil
+		}
+
+		output := &imageOutputSource{source: source}
+		spec := structd.ParseTag(field.Tag.Get(key))
+		output.format = spec.Options["format"]
+		if value, ok := spec.Lookup("quality"); ok {
+			quality, err := strconv.Atoi(value)
+			if err != nil || quality < 1 || quality > 100 {
+				return nil, fmt.Errorf("scanner: quality of %s must be between 1 and 100, got %q", field.Name, value)
+			}
+			output.quality = quality
+		}
+		return output, nil
+	}
+}
+
+// encodeImage decodes an uploaded file and encodes it in the format of the output
+func encodeImage(o *imageOutputSource) (ImageOutput, error) {
+	r, header, err := o.source.open()
+	if err != nil {
+		return ImageOutput{}, err
+	}
+	img, format, err := image.Decode(r)
+	if err != nil {
+		return ImageOutput{}, unsupportedImage(err, header)
+	}
+
+	if o.format != "" {
+		format = o.format
+	}
+	if format == "jpg" {
+		format = "jpeg"
+	}
+
+	encoder, ok := imageEncoder(format)
+	if !ok {
+		return ImageOutput{}, fmt.Errorf("scanner: no encoder is registered for %s images", format)
+	}
+	buf := &bytes.Buffer{}
+	if err := encoder(buf, img, o.quality); err != nil {
+		return ImageOutput{}, err
+	}
+	return ImageOutput{Image: img, Format: format, Bytes: buf.Bytes()}, nil
+}
@@ -0,0 +1,158 @@
+package scanner
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	colorType     = reflect.TypeFor[color.Color]()
+	nrgbaType     = reflect.TypeFor[color.NRGBA]()
+	rgbaType      = reflect.TypeFor[color.RGBA]()
+	pointType     = reflect.TypeFor[image.Point]()
+	rectangleType = reflect.TypeFor[image.Rectangle]()
+)
+
+// ImageCast casts strings to image parameters. Colors like `#ff8800`, `#f80`, `rgb(255,136,0)` or
+// `rgba(255,136,0,0.5)` are cast to `color.Color`, `color.NRGBA` and `color.RGBA`, dimensions like `200x300`
+// to `image.Point` and geometries like `200x300+10+20` to `image.Rectangle`. The query, form and path
+// scanners use it, it can be registered on others with `scanner.WithCaster`.
+func ImageCast(from any, to reflect.Type) (any, error) {
+	s, ok := from.(string)
+	if !ok {
+		return nil, errors.ErrUnsupported
+	}
+
+	switch to {
+	case colorType, nrgbaType:
+		return parseColor(s)
+	case rgbaType:
+		c, err := parseColor(s)
+		if err != nil {
+			return nil, err
+		}
+		return color.RGBAModel.Convert(c), nil
+	case pointType:
+		return parseDimensions(s)
+	case rectangleType:
+		return parseGeometry(s)
+	default:
+		return nil, errors.ErrUnsupported
+	}
+}
+
+// parseColor parses hex and css rgb colors
+func parseColor(s string) (color.NRGBA, error) {
+	invalid := errors.New("scanner: invalid color " + strconv.Quote(s))
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	if args, ok := strings.CutPrefix(s, "rgba("); ok {
+		return parseRGB(args, 4, invalid)
+	}
+	if args, ok := strings.CutPrefix(s, "rgb("); ok {
+		return parseRGB(args, 3, invalid)
+	}
+
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 || len(hex) == 4 {
+		expanded := make([]byte, 0, len(hex)*2)
+		for i := range len(hex) {
+			expanded = append(expanded, hex[i], hex[i])
+		}
+		hex = string(expanded)
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return color.NRGBA{}, invalid
+	}
+
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, invalid
+	}
+	return color.NRGBA{R: uint8(n >> 24), G: uint8(n >> 16), B: uint8(n >> 8), A: uint8(n)}, nil
+}
+
+// parseRGB parses the arguments of a css `rgb()` or `rgba()` function, the alpha is between 0 and 1
+func parseRGB(args string, n int, invalid error) (color.NRGBA, error) {
+	args, ok := strings.CutSuffix(args, ")")
+	parts := strings.Split(args, ",")
+	if !ok || len(parts) != n {
+		return color.NRGBA{}, invalid
+	}
+
+	channels := [4]uint8{255, 255, 255, 255}
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if i == 3 {
+			a, err := strconv.ParseFloat(part, 64)
+			if err != nil || a < 0 || a > 1 {
+				return color.NRGBA{}, invalid
+			}
+			channels[i] = uint8(a*255 + 0.5)
+			continue
+		}
+
+		c, err := strconv.ParseUint(part, 10, 8)
+		if err != nil {
+			return color.NRGBA{}, invalid
+		}
+		channels[i] = uint8(c)
+	}
+
+	return color.NRGBA{R: channels[0], G: channels[1], B: channels[2], A: channels[3]}, nil
+}
+
+// parseDimensions parses a `WIDTHxHEIGHT` pair
+func parseDimensions(s string) (image.Point, error) {
+	w, h, ok := strings.Cut(strings.ToLower(strings.TrimSpace(s)), "x")
+	if !ok {
+		return image.Point{}, errors.New("scanner: invalid dimensions " + strconv.Quote(s))
+	}
+
+	x, err := strconv.Atoi(w)
+	if err != nil || x < 0 {
+		return image.Point{}, errors.New("scanner: invalid width in " + strconv.Quote(s))
+	}
+	y, err := strconv.Atoi(h)
+	if err != nil || y < 0 {
+		return image.Point{}, errors.New("scanner: invalid height in " + strconv.Quote(s))
+	}
+	return image.Pt(x, y), nil
+}
+
+// parseGeometry parses a `WIDTHxHEIGHT+X+Y` geometry, the offsets are optional
+func parseGeometry(s string) (image.Rectangle, error) {
+	s = strings.TrimSpace(s)
+	size, offset := s, ""
+	if i := strings.IndexAny(s, "+-"); i >= 0 {
+		size, offset = s[:i], s[i:]
+	}
+
+	dimensions, err := parseDimensions(size)
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+
+	origin := image.Point{}
+	if offset != "" {
+		i := strings.IndexAny(offset[1:], "+-")
+		if i < 0 {
+			return image.Rectangle{}, errors.New("scanner: invalid geometry offset in " + strconv.Quote(s))
+		}
+		x, errX := strconv.Atoi(offset[:i+1])
+		y, errY := strconv.Atoi(offset[i+1:])
+		if errX != nil || errY != nil {
+			return image.Rectangle{}, errors.New("scanner: invalid geometry offset in " + strconv.Quote(s))
+		}
+		origin = image.Pt(x, y)
+	}
+
+	return image.Rectangle{Min: origin, Max: origin.Add(dimensions)}, nil
+}
@@ -0,0 +1,104 @@
+package scanner
+
+import (
+	"io"
+	"mime/multipart"
+	"slices"
+)
+
+// A ContentInspector inspects uploaded files before they are bound, like an antivirus scanner.
+// Returning an error rejects the upload, header is nil when the headers of the file are not known.
+type ContentInspector interface {
+	Inspect(field string, header *multipart.FileHeader, r io.Reader) error
+}
+
+// ContentInspectorFunc is a function that implements ContentInspector
+type ContentInspectorFunc func(field string, header *multipart.FileHeader, r io.Reader) error
+
+func (f ContentInspectorFunc) Inspect(field string, header *multipart.FileHeader, r io.Reader) error {
+	return f(field, header, r)
+}
+
+// A RejectedUploadError describes an uploaded file a ContentInspector rejected,
+// it usually maps to an http 422 status
+type RejectedUploadError struct {
+	Field    string
+	Filename string
+	Err      error
+}
+
+func (e *RejectedUploadError) Error() string {
+	msg := "scanner: upload " + e.Field
+	if e.Filename != "" {
+		msg += " (" + e.Filename + ")"
+	}
+	return msg + " is rejected: " + e.Err.Error()
+}
+
+func (e *RejectedUploadError) Unwrap() error {
+	return e.Err
+}
+
+// WithInspector runs every uploaded file of multipart and image scanners through the inspectors in order,
+// before any field is bound. Files are rewound after they are inspected.
+func WithInspector(inspectors ...ContentInspector) Option {
+	return func(c *config) {
+		c.inspectors = append(c.inspectors, inspectors...)
+	}
+}
+
+// inspect runs the files through the inspectors
+func (v *MultipartValues) inspect(inspectors []ContentInspector) error {
+	fields := make([]string, 0, len(v.Files))
+	for field := range v.Files {
+		fields = append(fields, field)
+	}
+	slices.Sort(fields)
+
+	for _, field := range fields {
+		file := v.Files[field]
+		header := v.Headers[field]
+
+		for _, inspector := range inspectors {
+			err := inspector.Inspect(field, header, file)
+			if _, seekErr := file.Seek(0, io.SeekStart); seekErr != nil && err == nil {
+				err = seekErr
+			}
+			if err != nil {
+				rejected := &RejectedUploadError{Field: field, Err: err}
+				if header != nil {
+					rejected.Filename = header.Filename
+				}
+				return rejected
+			}
+		}
+	}
+
+	return nil
+}
+
+// inspect runs the files of the scanner through the inspectors once
+func (s *Multipart) inspect() error {
+	if s.inspected || len(s.config.inspectors) == 0 {
+		return nil
+	}
+	if err := s.v.inspect(s.config.inspectors); err != nil {
+		return err
+	}
+
+	s.inspected = true
+	return nil
+}
+
+// inspect runs the files of the scanner through the inspectors once
+func (s *Image) inspect() error {
+	if s.inspected || len(s.config.inspectors) == 0 {
+		return nil
+	}
+	if err := s.values.inspect(s.config.inspectors); err != nil {
+		return err
+	}
+
+	s.inspected = true
+	return nil
+}
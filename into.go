@@ -0,0 +1,63 @@
+package scanner
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Into scans s onto a new T and returns it, T is usually a struct
+func Into[T any](s Scanner) (*T, error) {
+	v := new(T)
+	if err := s.Scan(v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// MustInto is like `scanner.Into` but panics when scanning fails, it is meant for tests and initialization
+func MustInto[T any](s Scanner) *T {
+	v, err := Into[T](s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// ScanValue scans s onto a new T and returns it by value
+func ScanValue[T any](s Scanner) (T, error) {
+	var v T
+	err := s.Scan(&v)
+	return v, err
+}
+
+// ScanAll scans every scanner onto a new T concurrently with at most workers goroutines, GOMAXPROCS when
+// workers is not positive. The results and errors are in the order of items, a failed item has a nil
+// result and its error at the same index. Decode plans of T are built once and shared by all workers.
+func ScanAll[T any](items []Scanner, workers int) ([]*T, []error) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(items))
+
+	results := make([]*T, len(items))
+	errs := make([]error, len(items))
+	indexes := make(chan int)
+
+	wg := sync.WaitGroup{}
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i], errs[i] = Into[T](items[i])
+			}
+		}()
+	}
+	for i := range items {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results, errs
+}
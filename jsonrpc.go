@@ -0,0 +1,151 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// Error codes of the JSON-RPC 2.0 specification
+const (
+	JSONRPCParseError     = -32700
+	JSONRPCInvalidRequest = -32600
+	JSONRPCInvalidParams  = -32602
+)
+
+// A JSONRPCError describes a malformed JSON-RPC request, its code can be sent back in the error response
+type JSONRPCError struct {
+	Code    int
+	Message string
+	Err     error
+}
+
+func (e *JSONRPCError) Error() string {
+	msg := "scanner: json-rpc error " + strconv.Itoa(e.Code) + ": " + e.Message
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *JSONRPCError) Unwrap() error {
+	return e.Err
+}
+
+type jsonrpcEnvelope struct {
+	Version *string          `json:"jsonrpc"`
+	Method  *string          `json:"method"`
+	Params  json.RawMessage  `json:"params"`
+	ID      *json.RawMessage `json:"id"`
+}
+
+// A scanner to scan the params of a JSON-RPC 2.0 request from an `io.Reader` onto a struct with the `rpc` tag.
+// Named params are bound by their names and positional params by their indexes, e.g. `rpc:"0"`.
+// The envelope is validated and its method and id are available after the first scan.
+type JSONRPC struct {
+	r       io.Reader
+	params  map[string]any
+	method  string
+	id      any
+	request bool
+	config  *config
+}
+
+// parse reads and validates the request envelope
+func (s *JSONRPC) parse() error {
+	if s.params != nil {
+		return nil
+	}
+
+	b, err := io.ReadAll(s.r)
+	if err != nil {
+		return err
+	}
+
+	envelope := jsonrpcEnvelope{}
+	if err := json.Unmarshal(b, &envelope); err != nil {
+		return &JSONRPCError{Code: JSONRPCParseError, Message: "parse error", Err: err}
+	}
+	if envelope.Version == nil || *envelope.Version != "2.0" {
+		return &JSONRPCError{Code: JSONRPCInvalidRequest, Message: `invalid request: jsonrpc must be "2.0"`}
+	}
+	if envelope.Method == nil || *envelope.Method == "" {
+		return &JSONRPCError{Code: JSONRPCInvalidRequest, Message: "invalid request: missing method"}
+	}
+
+	if envelope.ID != nil {
+		var id any
+		if err := json.Unmarshal(*envelope.ID, &id); err != nil {
+			return &JSONRPCError{Code: JSONRPCInvalidRequest, Message: "invalid request: malformed id", Err: err}
+		}
+		switch id.(type) {
+		case string, float64, nil:
+		default:
+			return &JSONRPCError{Code: JSONRPCInvalidRequest, Message: "invalid request: id must be a string, number or null"}
+		}
+		s.id = id
+		s.request = true
+	}
+
+	params := map[string]any{}
+	raw := bytes.TrimSpace(envelope.Params)
+	switch {
+	case len(raw) == 0:
+	case raw[0] == '{':
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return &JSONRPCError{Code: JSONRPCInvalidParams, Message: "invalid params", Err: err}
+		}
+	case raw[0] == '[':
+		positional := []any{}
+		if err := json.Unmarshal(raw, &positional); err != nil {
+			return &JSONRPCError{Code: JSONRPCInvalidParams, Message: "invalid params", Err: err}
+		}
+		for i, param := range positional {
+			params[strconv.Itoa(i)] = param
+		}
+	default:
+		return &JSONRPCError{Code: JSONRPCInvalidRequest, Message: "invalid request: params must be an array or an object"}
+	}
+
+	s.method = *envelope.Method
+	s.params = params
+	return nil
+}
+
+// Scans the request params onto v
+func (s *JSONRPC) Scan(v any) error {
+	if err := s.parse(); err != nil {
+		return err
+	}
+
+	d := &document{values: s.params, key: "rpc", config: s.config}
+	if err := s.config.decoder(d, "rpc").Decode(v); err != nil {
+		return &JSONRPCError{Code: JSONRPCInvalidParams, Message: "invalid params", Err: err}
+	}
+	return nil
+}
+
+// Method returns the method of the request
+func (s *JSONRPC) Method() string {
+	return s.method
+}
+
+// ID returns the id of the request, a string, a float64 or nil
+func (s *JSONRPC) ID() any {
+	return s.id
+}
+
+// IsNotification reports whether the request has no id and expects no response
+func (s *JSONRPC) IsNotification() bool {
+	return !s.request
+}
+
+func NewJSONRPC(r io.Reader, opts ...Option) *JSONRPC {
+	c := newConfig(opts)
+
+	return &JSONRPC{
+		r:      c.reader(r),
+		config: c,
+	}
+}
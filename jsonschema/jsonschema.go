@@ -0,0 +1,491 @@
+// Package jsonschema validates json documents against a JSON Schema for `scanner.WithSchema`. It supports the
+// validation keywords of draft 2020-12 and references within the schema, without format assertions or remote
+// references.
+//
+//	schema, err := jsonschema.Parse(spec)
+//	s := scanner.NewJSON(r.Body, scanner.WithSchema(schema))
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/canpacis/scanner"
+)
+
+// A Schema is a parsed JSON Schema
+type Schema struct {
+	root     any
+	patterns map[string]*regexp.Regexp
+	// refs are the references compile followed, against references that refer to themselves
+	refs map[string]bool
+}
+
+// Parse parses a JSON Schema, its patterns are compiled and its references resolved up front
+func Parse(b []byte) (*Schema, error) {
+	var root any
+	if err := json.Unmarshal(b, &root); err != nil {
+		return nil, fmt.Errorf("jsonschema: %w", err)
+	}
+
+	s := &Schema{root: root, patterns: map[string]*regexp.Regexp{}, refs: map[string]bool{}}
+	if err := s.compile(root); err != nil {
+		return nil, err
+	}
+	s.refs = nil
+	return s, nil
+}
+
+// MustParse is like Parse but panics when the schema is invalid, for schemas known at compile time
+func MustParse(b []byte) *Schema {
+	s, err := Parse(b)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// compile compiles the patterns of a schema and checks its references. The targets of references are
+// compiled too, so every pattern a validation reaches is compiled even when it is under a keyword like `default`.
+func (s *Schema) compile(schema any) error {
+	switch schema := schema.(type) {
+	case map[string]any:
+		if pattern, ok := schema["pattern"].(string); ok {
+			if err := s.pattern(pattern); err != nil {
+				return err
+			}
+		}
+		if properties, ok := schema["patternProperties"].(map[string]any); ok {
+			for pattern := range properties {
+				if err := s.pattern(pattern); err != nil {
+					return err
+				}
+			}
+		}
+		if ref, ok := schema["$ref"].(string); ok && !s.refs[ref] {
+			s.refs[ref] = true
+			target, err := s.resolve(ref)
+			if err != nil {
+				return err
+			}
+			if err := s.compile(target); err != nil {
+				return err
+			}
+		}
+
+		for _, key := range slices.Sorted(maps.Keys(schema)) {
+			switch key {
+			case "enum", "const", "default", "examples":
+				continue
+			case "properties", "patternProperties", "dependentSchemas", "$defs", "definitions":
+				// the keys of these keywords are names, not keywords, so only their values are schemas
+				if subschemas, ok := schema[key].(map[string]any); ok {
+					for _, name := range slices.Sorted(maps.Keys(subschemas)) {
+						if err := s.compile(subschemas[name]); err != nil {
+							return err
+						}
+					}
+					continue
+				}
+			}
+			if err := s.compile(schema[key]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range schema {
+			if err := s.compile(item); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Schema) pattern(pattern string) error {
+	if _, ok := s.patterns[pattern]; ok {
+		return nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("jsonschema: invalid pattern %q: %w", pattern, err)
+	}
+	s.patterns[pattern] = re
+	return nil
+}
+
+// resolve returns the subschema a local reference like `#/$defs/address` points to
+func (s *Schema) resolve(ref string) (any, error) {
+	if ref != "#" && !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("jsonschema: unsupported reference %q", ref)
+	}
+
+	current := s.root
+	for _, segment := range strings.Split(strings.TrimPrefix(strings.TrimPrefix(ref, "#"), "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		segment = strings.NewReplacer("~1", "/", "~0", "~").Replace(segment)
+		switch node := current.(type) {
+		case map[string]any:
+			next, ok := node[segment]
+			if !ok {
+				return nil, fmt.Errorf("jsonschema: unresolved reference %q", ref)
+			}
+			current = next
+		case []any:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("jsonschema: unresolved reference %q", ref)
+			}
+			current = node[i]
+		default:
+			return nil, fmt.Errorf("jsonschema: unresolved reference %q", ref)
+		}
+	}
+	return current, nil
+}
+
+// Validate returns the violations of doc, the properties of objects are validated in the order of their names
+func (s *Schema) Validate(doc any) []scanner.SchemaViolation {
+	v := &validator{schema: s}
+	v.validate(s.root, doc, "", 0)
+	return v.violations
+}
+
+// maxDepth bounds the references a validation follows, against schemas that refer to themselves forever
+const maxDepth = 64
+
+type validator struct {
+	schema     *Schema
+	violations []scanner.SchemaViolation
+}
+
+func (v *validator) report(pointer, keyword, format string, args ...any) {
+	v.violations = append(v.violations, scanner.SchemaViolation{
+		Pointer: pointer,
+		Keyword: keyword,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// valid reports whether doc is valid against schema without reporting its violations
+func (v *validator) valid(schema, doc any, pointer string, depth int) bool {
+	nested := &validator{schema: v.schema}
+	nested.validate(schema, doc, pointer, depth)
+	return len(nested.violations) == 0
+}
+
+func (v *validator) validate(schema, doc any, pointer string, depth int) {
+	if depth > maxDepth {
+		v.report(pointer, "$ref", "exceeds the depth of %d references", maxDepth)
+		return
+	}
+
+	var node map[string]any
+	switch schema := schema.(type) {
+	case bool:
+		if !schema {
+			v.report(pointer, "false", "is not allowed")
+		}
+		return
+	case map[string]any:
+		node = schema
+	default:
+		return
+	}
+
+	if ref, ok := node["$ref"].(string); ok {
+		target, _ := v.schema.resolve(ref)
+		v.validate(target, doc, pointer, depth+1)
+	}
+
+	if types, ok := node["type"]; ok && !matchesType(types, doc) {
+		v.report(pointer, "type", "must be of type %s", typeNames(types))
+		return
+	}
+	if values, ok := node["enum"].([]any); ok && !slices.ContainsFunc(values, func(value any) bool { return equal(value, doc) }) {
+		v.report(pointer, "enum", "must be one of %s", literals(values))
+	}
+	if value, ok := node["const"]; ok && !equal(value, doc) {
+		v.report(pointer, "const", "must be %s", literal(value))
+	}
+
+	switch doc := doc.(type) {
+	case string:
+		v.validateString(node, doc, pointer)
+	case float64:
+		v.validateNumber(node, doc, pointer)
+	case []any:
+		v.validateArray(node, doc, pointer, depth)
+	case map[string]any:
+		v.validateObject(node, doc, pointer, depth)
+	}
+
+	v.validateComposition(node, doc, pointer, depth)
+}
+
+func (v *validator) validateString(node map[string]any, doc, pointer string) {
+	length := utf8.RuneCountInString(doc)
+	if n, ok := number(node["minLength"]); ok && float64(length) < n {
+		v.report(pointer, "minLength", "must be at least %s characters long", format(n))
+	}
+	if n, ok := number(node["maxLength"]); ok && float64(length) > n {
+		v.report(pointer, "maxLength", "must be at most %s characters long", format(n))
+	}
+	if pattern, ok := node["pattern"].(string); ok && !v.schema.patterns[pattern].MatchString(doc) {
+		v.report(pointer, "pattern", "must match the pattern %q", pattern)
+	}
+}
+
+func (v *validator) validateNumber(node map[string]any, doc float64, pointer string) {
+	if n, ok := number(node["minimum"]); ok && doc < n {
+		v.report(pointer, "minimum", "must be greater than or equal to %s", format(n))
+	}
+	if n, ok := number(node["maximum"]); ok && doc > n {
+		v.report(pointer, "maximum", "must be less than or equal to %s", format(n))
+	}
+	if n, ok := number(node["exclusiveMinimum"]); ok && doc <= n {
+		v.report(pointer, "exclusiveMinimum", "must be greater than %s", format(n))
+	}
+	if n, ok := number(node["exclusiveMaximum"]); ok && doc >= n {
+		v.report(pointer, "exclusiveMaximum", "must be less than %s", format(n))
+	}
+	if n, ok := number(node["multipleOf"]); ok && n > 0 {
+		if q := doc / n; math.Abs(q-math.Round(q)) > 1e-9 {
+			v.report(pointer, "multipleOf", "must be a multiple of %s", format(n))
+		}
+	}
+}
+
+func (v *validator) validateArray(node map[string]any, doc []any, pointer string, depth int) {
+	if n, ok := number(node["minItems"]); ok && float64(len(doc)) < n {
+		v.report(pointer, "minItems", "must have at least %s items", format(n))
+	}
+	if n, ok := number(node["maxItems"]); ok && float64(len(doc)) > n {
+		v.report(pointer, "maxItems", "must have at most %s items", format(n))
+	}
+	if unique, _ := node["uniqueItems"].(bool); unique {
+		for i := range doc {
+			if slices.ContainsFunc(doc[:i], func(item any) bool { return equal(item, doc[i]) }) {
+				v.report(pointer+"/"+strconv.Itoa(i), "uniqueItems", "must be unique")
+			}
+		}
+	}
+
+	prefix, _ := node["prefixItems"].([]any)
+	for i, item := range doc {
+		if i < len(prefix) {
+			v.validate(prefix[i], item, pointer+"/"+strconv.Itoa(i), depth)
+		} else if items, ok := node["items"]; ok {
+			v.validate(items, item, pointer+"/"+strconv.Itoa(i), depth)
+		}
+	}
+
+	if contains, ok := node["contains"]; ok {
+		matched := 0
+		for i, item := range doc {
+			if v.valid(contains, item, pointer+"/"+strconv.Itoa(i), depth) {
+				matched++
+			}
+		}
+		least, ok := number(node["minContains"])
+		if !ok {
+			least = 1
+		}
+		if float64(matched) < least {
+			v.report(pointer, "contains", "must contain at least %s matching items", format(least))
+		}
+		if most, ok := number(node["maxContains"]); ok && float64(matched) > most {
+			v.report(pointer, "maxContains", "must contain at most %s matching items", format(most))
+		}
+	}
+}
+
+func (v *validator) validateObject(node map[string]any, doc map[string]any, pointer string, depth int) {
+	if n, ok := number(node["minProperties"]); ok && float64(len(doc)) < n {
+		v.report(pointer, "minProperties", "must have at least %s properties", format(n))
+	}
+	if n, ok := number(node["maxProperties"]); ok && float64(len(doc)) > n {
+		v.report(pointer, "maxProperties", "must have at most %s properties", format(n))
+	}
+
+	// missing properties are reported at their own pointer, which is where the field that binds them points to
+	if required, ok := node["required"].([]any); ok {
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				if _, ok := doc[name]; !ok {
+					v.report(pointer+"/"+escape(name), "required", "is required")
+				}
+			}
+		}
+	}
+	if dependent, ok := node["dependentRequired"].(map[string]any); ok {
+		for _, key := range slices.Sorted(maps.Keys(dependent)) {
+			if _, ok := doc[key]; !ok {
+				continue
+			}
+			names, _ := dependent[key].([]any)
+			for _, name := range names {
+				if name, ok := name.(string); ok {
+					if _, ok := doc[name]; !ok {
+						v.report(pointer+"/"+escape(name), "dependentRequired", "is required when %q is present", key)
+					}
+				}
+			}
+		}
+	}
+
+	properties, _ := node["properties"].(map[string]any)
+	patterns, _ := node["patternProperties"].(map[string]any)
+	names, hasNames := node["propertyNames"]
+	for _, key := range slices.Sorted(maps.Keys(doc)) {
+		at := pointer + "/" + escape(key)
+		if hasNames && !v.valid(names, key, at, depth) {
+			v.report(at, "propertyNames", "is not an allowed property name")
+		}
+
+		matched := false
+		if schema, ok := properties[key]; ok {
+			v.validate(schema, doc[key], at, depth)
+			matched = true
+		}
+		for _, pattern := range slices.Sorted(maps.Keys(patterns)) {
+			if v.schema.patterns[pattern].MatchString(key) {
+				v.validate(patterns[pattern], doc[key], at, depth)
+				matched = true
+			}
+		}
+		if additional, ok := node["additionalProperties"]; ok && !matched {
+			if allowed, ok := additional.(bool); ok && !allowed {
+				v.report(at, "additionalProperties", "is not an allowed property")
+				continue
+			}
+			v.validate(additional, doc[key], at, depth)
+		}
+	}
+}
+
+func (v *validator) validateComposition(node map[string]any, doc any, pointer string, depth int) {
+	if schemas, ok := node["allOf"].([]any); ok {
+		for _, schema := range schemas {
+			v.validate(schema, doc, pointer, depth)
+		}
+	}
+	if schemas, ok := node["anyOf"].([]any); ok {
+		if !slices.ContainsFunc(schemas, func(schema any) bool { return v.valid(schema, doc, pointer, depth) }) {
+			v.report(pointer, "anyOf", "must match at least one of %d schemas", len(schemas))
+		}
+	}
+	if schemas, ok := node["oneOf"].([]any); ok {
+		matched := 0
+		for _, schema := range schemas {
+			if v.valid(schema, doc, pointer, depth) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			v.report(pointer, "oneOf", "must match exactly one of %d schemas, matched %d", len(schemas), matched)
+		}
+	}
+	if schema, ok := node["not"]; ok && v.valid(schema, doc, pointer, depth) {
+		v.report(pointer, "not", "must not match the schema")
+	}
+	if condition, ok := node["if"]; ok {
+		if v.valid(condition, doc, pointer, depth) {
+			if then, ok := node["then"]; ok {
+				v.validate(then, doc, pointer, depth)
+			}
+		} else if otherwise, ok := node["else"]; ok {
+			v.validate(otherwise, doc, pointer, depth)
+		}
+	}
+}
+
+// matchesType reports whether doc is of the type or one of the types of a `type` keyword
+func matchesType(types, doc any) bool {
+	switch types := types.(type) {
+	case string:
+		return isType(types, doc)
+	case []any:
+		return slices.ContainsFunc(types, func(t any) bool {
+			name, _ := t.(string)
+			return isType(name, doc)
+		})
+	default:
+		return true
+	}
+}
+
+func isType(name string, doc any) bool {
+	switch doc := doc.(type) {
+	case nil:
+		return name == "null"
+	case bool:
+		return name == "boolean"
+	case string:
+		return name == "string"
+	case float64:
+		return name == "number" || name == "integer" && doc == math.Trunc(doc)
+	case []any:
+		return name == "array"
+	case map[string]any:
+		return name == "object"
+	default:
+		return false
+	}
+}
+
+func typeNames(types any) string {
+	switch types := types.(type) {
+	case []any:
+		names := []string{}
+		for _, t := range types {
+			names = append(names, fmt.Sprint(t))
+		}
+		return strings.Join(names, " or ")
+	default:
+		return fmt.Sprint(types)
+	}
+}
+
+// equal reports whether two json values are equal
+func equal(a, b any) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+func number(v any) (float64, bool) {
+	n, ok := v.(float64)
+	return n, ok
+}
+
+func format(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+func literal(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func literals(values []any) string {
+	result := []string{}
+	for _, value := range values {
+		result = append(result, literal(value))
+	}
+	return strings.Join(result, ", ")
+}
+
+// escape escapes a key for a json pointer
+func escape(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
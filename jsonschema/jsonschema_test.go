@@ -0,0 +1,136 @@
+package jsonschema_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/canpacis/scanner"
+	"github.com/canpacis/scanner/jsonschema"
+	"github.com/canpacis/scanner/structd"
+	"github.com/stretchr/testify/assert"
+)
+
+const order = `{
+	"type": "object",
+	"required": ["id", "items"],
+	"additionalProperties": false,
+	"properties": {
+		"id": {"type": "string", "pattern": "^ord_[a-z0-9]+$"},
+		"note": {"type": ["string", "null"], "maxLength": 8},
+		"status": {"enum": ["open", "paid"]},
+		"items": {"type": "array", "minItems": 1, "items": {"$ref": "#/$defs/item"}}
+	},
+	"$defs": {
+		"item": {
+			"type": "object",
+			"required": ["sku"],
+			"properties": {
+				"sku": {"type": "string", "minLength": 1},
+				"quantity": {"type": "integer", "minimum": 1},
+				"price": {"type": "number", "exclusiveMinimum": 0, "multipleOf": 0.01}
+			}
+		}
+	}
+}`
+
+func TestValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	schema, err := jsonschema.Parse([]byte(order))
+	assert.NoError(err)
+
+	valid := map[string]any{
+		"id":     "ord_1",
+		"note":   nil,
+		"status": "paid",
+		"items":  []any{map[string]any{"sku": "a", "quantity": float64(2), "price": 9.99}},
+	}
+	assert.Empty(schema.Validate(valid))
+
+	invalid := map[string]any{
+		"id":     "1",
+		"note":   "too long a note",
+		"status": "lost",
+		"items":  []any{map[string]any{"quantity": 1.5, "price": float64(0)}},
+		"extra":  true,
+	}
+	assert.Equal([]scanner.SchemaViolation{
+		{Pointer: "/extra", Keyword: "additionalProperties", Message: "is not an allowed property"},
+		{Pointer: "/id", Keyword: "pattern", Message: `must match the pattern "^ord_[a-z0-9]+$"`},
+		{Pointer: "/items/0/sku", Keyword: "required", Message: "is required"},
+		{Pointer: "/items/0/price", Keyword: "exclusiveMinimum", Message: "must be greater than 0"},
+		{Pointer: "/items/0/quantity", Keyword: "type", Message: "must be of type integer"},
+		{Pointer: "/note", Keyword: "maxLength", Message: "must be at most 8 characters long"},
+		{Pointer: "/status", Keyword: "enum", Message: `must be one of "open", "paid"`},
+	}, schema.Validate(invalid))
+
+	composed := jsonschema.MustParse([]byte(`{
+		"oneOf": [{"type": "string"}, {"type": "integer"}],
+		"not": {"const": "none"}
+	}`))
+	assert.Empty(composed.Validate("some"))
+	assert.Len(composed.Validate("none"), 1)
+	assert.Equal("oneOf", composed.Validate(true)[0].Keyword)
+
+	_, err = jsonschema.Parse([]byte(`{"pattern": "("}`))
+	assert.ErrorContains(err, "invalid pattern")
+	_, err = jsonschema.Parse([]byte(`{"$ref": "#/$defs/missing"}`))
+	assert.ErrorContains(err, "unresolved reference")
+	_, err = jsonschema.Parse([]byte(`{"$ref": "https://example.com/schema.json"}`))
+	assert.ErrorContains(err, "unsupported reference")
+
+	// properties named like keywords are schemas too
+	keywords := jsonschema.MustParse([]byte(`{"properties": {"default": {"type": "string", "pattern": "^[a-z]+$"}}}`))
+	assert.Empty(keywords.Validate(map[string]any{"default": "abc"}))
+	assert.Equal("pattern", keywords.Validate(map[string]any{"default": "ABC"})[0].Keyword)
+	_, err = jsonschema.Parse([]byte(`{"$defs": {"enum": {"pattern": "("}}}`))
+	assert.ErrorContains(err, "invalid pattern")
+	// patterns reached through references into values are compiled too
+	referenced := jsonschema.MustParse([]byte(`{"$ref": "#/default/0", "default": [{"pattern": "^a"}]}`))
+	assert.Equal("pattern", referenced.Validate("b")[0].Keyword)
+	_, err = jsonschema.Parse([]byte(`{"$ref": "#/examples/0", "examples": [{"patternProperties": {"(": true}}]}`))
+	assert.ErrorContains(err, "invalid pattern")
+
+	recursive := jsonschema.MustParse([]byte(`{"$ref": "#"}`))
+	assert.Equal("$ref", recursive.Validate(nil)[0].Keyword)
+}
+
+func TestScan(t *testing.T) {
+	assert := assert.New(t)
+
+	type Item struct {
+		SKU      string  `json:"sku"`
+		Quantity int     `json:"quantity"`
+		Price    float64 `json:"price"`
+	}
+	type Order struct {
+		ID    string `json:"id"`
+		Items []Item `json:"items"`
+	}
+
+	schema := jsonschema.MustParse([]byte(order))
+
+	o := Order{}
+	body := `{"id":"ord_1","items":[{"sku":"a","quantity":2,"price":9.99}]}`
+	assert.NoError(scanner.NewJSON(strings.NewReader(body), scanner.WithSchema(schema)).Scan(&o))
+	assert.Equal(Order{ID: "ord_1", Items: []Item{{SKU: "a", Quantity: 2, Price: 9.99}}}, o)
+
+	o = Order{}
+	body = `{"id":"ord_1","items":[{"sku":"a","quantity":0}]}`
+	err := scanner.NewJSON(strings.NewReader(body), scanner.WithSchema(schema)).Scan(&o)
+	var ferr *structd.FieldError
+	assert.ErrorAs(err, &ferr)
+	assert.Equal("Order", ferr.Struct)
+	assert.Equal("Items[0].Quantity", ferr.Field)
+	var violation *scanner.SchemaViolation
+	assert.True(errors.As(err, &violation))
+	assert.Equal("minimum", violation.Keyword)
+	assert.EqualError(err, "structd: invalid value for Go struct field Order.Items[0].Quantity: schema: /items/0/quantity must be greater than or equal to 1")
+	// the body is not bound when it violates the schema
+	assert.Equal(Order{}, o)
+
+	err = scanner.NewJSON(strings.NewReader(`{"items":[]}`), scanner.WithSchema(schema)).Scan(&Order{})
+	assert.ErrorContains(err, "Order.ID: schema: /id is required")
+	assert.ErrorContains(err, "Order.Items: schema: /items must have at least 1 items")
+}
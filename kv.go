@@ -0,0 +1,179 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/canpacis/scanner/structd"
+)
+
+// A KVSource lists the keys of a key value store, like etcd or Consul KV
+type KVSource interface {
+	// List returns the values of the keys under prefix, keyed by their name without the prefix
+	List(ctx context.Context, prefix string) (map[string]string, error)
+}
+
+// KVSourceFunc is a function that implements KVSource
+type KVSourceFunc func(ctx context.Context, prefix string) (map[string]string, error)
+
+func (f KVSourceFunc) List(ctx context.Context, prefix string) (map[string]string, error) {
+	return f(ctx, prefix)
+}
+
+// A scanner to scan the keys of a key value store under a prefix onto a struct with the `kv` tag,
+// `kv:"db/host"` binds the key `<prefix>db/host`
+type KV struct {
+	values map[string]string
+	config *config
+}
+
+func (s *KV) Get(key string) any {
+	value, ok := s.values[key]
+	if !ok {
+		return nil
+	}
+	return value
+}
+
+// Keys returns the keys under the prefix, without it
+func (s *KV) Keys() []string {
+	return slices.Sorted(maps.Keys(s.values))
+}
+
+func (s *KV) Cast(from any, to reflect.Type) (any, error) {
+	return structd.DefaultCast(from, to)
+}
+
+// Scans the listed keys onto v
+func (s *KV) Scan(v any) error {
+	return s.config.decoder(s, "kv").Decode(v)
+}
+
+// NewKV lists the keys of source under prefix and creates a scanner of them
+func NewKV(ctx context.Context, source KVSource, prefix string, opts ...Option) (*KV, error) {
+	values, err := source.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KV{values: values, config: newConfig(opts)}, nil
+}
+
+// A KVWatcher keeps a struct in sync with the keys of a key value store using the `kv` tag.
+// On every poll, only the fields whose keys changed are re-bound.
+type KVWatcher struct {
+	mu          sync.Mutex
+	source      KVSource
+	prefix      string
+	v           any
+	values      map[string]string
+	subscribers []func(changed []string)
+	config      *config
+}
+
+// Subscribe registers fn to be called with the changed field paths after every poll that changed v
+func (w *KVWatcher) Subscribe(fn func(changed []string)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Poll lists the keys again, re-binds the fields of the keys that changed and returns their paths
+func (w *KVWatcher) Poll(ctx context.Context) ([]string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	values, err := w.source.List(ctx, w.prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	key := "kv"
+	if w.config.tag != "" {
+		key = w.config.tag
+	}
+	rv := reflect.ValueOf(w.v).Elem()
+	fields := tagFields(rv.Type(), key)
+
+	changed := map[string]string{}
+	paths := []string{}
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		fieldNames := fields[name]
+		value, ok := values[name]
+		prev, had := w.values[name]
+		switch {
+		case ok && (!had || prev != value):
+			changed[name] = value
+		case !ok && had:
+			for _, path := range fieldNames {
+				field := rv.FieldByName(path)
+				field.Set(reflect.Zero(field.Type()))
+			}
+		default:
+			continue
+		}
+		paths = append(paths, fieldNames...)
+	}
+
+	if len(changed) > 0 {
+		s := &KV{values: changed, config: w.config}
+		if err := s.Scan(w.v); err != nil {
+			return nil, err
+		}
+	}
+	w.values = values
+	slices.Sort(paths)
+
+	if len(paths) > 0 {
+		for _, fn := range w.subscribers {
+			fn(paths)
+		}
+	}
+
+	return paths, nil
+}
+
+// Watch polls the keys every interval until ctx is done or a poll fails
+func (w *KVWatcher) Watch(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if _, err := w.Poll(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// NewKVWatcher binds the keys of source under prefix onto v, which must be a pointer to a struct, and returns
+// a watcher that keeps it up to date
+func NewKVWatcher(ctx context.Context, source KVSource, prefix string, v any, opts ...Option) (*KVWatcher, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("scanner: watcher target must be a non-nil pointer to a struct")
+	}
+
+	w := &KVWatcher{
+		source: source,
+		prefix: prefix,
+		v:      v,
+		values: map[string]string{},
+		config: newConfig(opts),
+	}
+	if _, err := w.Poll(ctx); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
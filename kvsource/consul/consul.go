@@ -0,0 +1,96 @@
+// Package consul lists the keys of Consul KV for `scanner.NewKV` and `scanner.NewKVWatcher` over the http api
+// of Consul, without its client library.
+//
+//	w, err := scanner.NewKVWatcher(ctx, consul.FromEnv(), "services/api/", &config)
+package consul
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// A Source lists the keys of a Consul agent
+type Source struct {
+	// Addr is the address of the agent, like `http://127.0.0.1:8500`
+	Addr string
+	// Token is the acl token of the requests, if any
+	Token string
+	// Datacenter is the datacenter of the keys, the datacenter of the agent is used when it is empty
+	Datacenter string
+	// Client sends the requests, `http.DefaultClient` is used when it is nil
+	Client *http.Client
+}
+
+// New creates a source of the agent at addr
+func New(addr string) *Source {
+	return &Source{Addr: addr}
+}
+
+// FromEnv creates a source from the `CONSUL_HTTP_ADDR` and `CONSUL_HTTP_TOKEN` environment variables,
+// the address defaults to `http://127.0.0.1:8500`
+func FromEnv() *Source {
+	addr := os.Getenv("CONSUL_HTTP_ADDR")
+	if addr == "" {
+		addr = "127.0.0.1:8500"
+	}
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	return &Source{Addr: addr, Token: os.Getenv("CONSUL_HTTP_TOKEN")}
+}
+
+// List returns the keys under prefix, folders without a value are left out
+func (s *Source) List(ctx context.Context, prefix string) (map[string]string, error) {
+	query := url.Values{"recurse": {"true"}}
+	if s.Datacenter != "" {
+		query.Set("dc", s.Datacenter)
+	}
+	u := strings.TrimSuffix(s.Addr, "/") + "/v1/kv/" + strings.TrimPrefix(prefix, "/") + "?" + query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.Token != "" {
+		req.Header.Set("X-Consul-Token", s.Token)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	values := map[string]string{}
+	if res.StatusCode == http.StatusNotFound {
+		return values, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("consul: listing %s failed with status %s", prefix, res.Status)
+	}
+
+	pairs := []struct {
+		Key   string
+		Value []byte
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(&pairs); err != nil {
+		return nil, err
+	}
+	for _, pair := range pairs {
+		if pair.Value == nil {
+			continue
+		}
+		values[strings.TrimPrefix(pair.Key, strings.TrimPrefix(prefix, "/"))] = string(pair.Value)
+	}
+
+	return values, nil
+}
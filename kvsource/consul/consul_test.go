@@ -0,0 +1,48 @@
+package consul_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/canpacis/scanner"
+	"github.com/canpacis/scanner/kvsource/consul"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestList(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("true", r.URL.Query().Get("recurse"))
+		switch r.URL.Path {
+		case "/v1/kv/services/api/":
+			// "ODA4MA==" is 8080 and "ZGIuaW50ZXJuYWw=" db.internal
+			fmt.Fprint(w, `[
+				{"Key": "services/api/", "Value": null},
+				{"Key": "services/api/port", "Value": "ODA4MA=="},
+				{"Key": "services/api/db/host", "Value": "ZGIuaW50ZXJuYWw="}
+			]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	type Config struct {
+		Port   int    `kv:"port"`
+		DBHost string `kv:"db/host"`
+	}
+
+	s, err := scanner.NewKV(context.Background(), consul.New(server.URL), "services/api/")
+	assert.NoError(err)
+	c := Config{}
+	assert.NoError(s.Scan(&c))
+	assert.Equal(Config{Port: 8080, DBHost: "db.internal"}, c)
+
+	values, err := consul.New(server.URL).List(context.Background(), "services/missing/")
+	assert.NoError(err)
+	assert.Empty(values)
+}
@@ -0,0 +1,97 @@
+// Package etcd lists the keys of etcd for `scanner.NewKV` and `scanner.NewKVWatcher` over the json gateway
+// of the etcd v3 api, without its client library.
+//
+//	w, err := scanner.NewKVWatcher(ctx, etcd.New("http://127.0.0.1:2379"), "/services/api/", &config)
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// A Source lists the keys of an etcd cluster
+type Source struct {
+	// Endpoint is the address of a member, like `http://127.0.0.1:2379`
+	Endpoint string
+	// Token is the auth token of the requests, if any
+	Token string
+	// Client sends the requests, `http.DefaultClient` is used when it is nil
+	Client *http.Client
+}
+
+// New creates a source of the member at endpoint
+func New(endpoint string) *Source {
+	return &Source{Endpoint: endpoint}
+}
+
+// rangeEnd returns the end of the range of keys that start with prefix
+func rangeEnd(prefix []byte) []byte {
+	end := bytes.Clone(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	// every key is after a prefix of 0xff bytes
+	return []byte{0}
+}
+
+// List returns the keys under prefix
+func (s *Source) List(ctx context.Context, prefix string) (map[string]string, error) {
+	key := []byte(prefix)
+	if len(key) == 0 {
+		// the whole key space
+		key = []byte{0}
+	}
+	payload, err := json.Marshal(map[string][]byte{
+		"key":       key,
+		"range_end": rangeEnd([]byte(prefix)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(s.Endpoint, "/")+"/v3/kv/range", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.Token != "" {
+		req.Header.Set("Authorization", s.Token)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("etcd: listing %s failed with status %s", prefix, res.Status)
+	}
+
+	body := struct {
+		KVs []struct {
+			Key   []byte `json:"key"`
+			Value []byte `json:"value"`
+		} `json:"kvs"`
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	for _, kv := range body.KVs {
+		values[strings.TrimPrefix(string(kv.Key), prefix)] = string(kv.Value)
+	}
+	return values, nil
+}
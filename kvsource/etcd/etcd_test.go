@@ -0,0 +1,44 @@
+package etcd_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/canpacis/scanner"
+	"github.com/canpacis/scanner/kvsource/etcd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestList(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("/v3/kv/range", r.URL.Path)
+		body := map[string][]byte{}
+		json.NewDecoder(r.Body).Decode(&body)
+		assert.Equal("/services/api/", string(body["key"]))
+		assert.Equal("/services/api0", string(body["range_end"]))
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"kvs": []map[string][]byte{
+				{"key": []byte("/services/api/port"), "value": []byte("8080")},
+				{"key": []byte("/services/api/db/host"), "value": []byte("db.internal")},
+			},
+		})
+	}))
+	defer server.Close()
+
+	type Config struct {
+		Port   int    `kv:"port"`
+		DBHost string `kv:"db/host"`
+	}
+
+	s, err := scanner.NewKV(context.Background(), etcd.New(server.URL), "/services/api/")
+	assert.NoError(err)
+	c := Config{}
+	assert.NoError(s.Scan(&c))
+	assert.Equal(Config{Port: 8080, DBHost: "db.internal"}, c)
+}
@@ -0,0 +1,128 @@
+package scanner
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// APIGatewayProxyRequest holds the fields of an AWS API Gateway REST (v1) proxy event that the scanners use.
+// It decodes from the raw event json and mirrors `events.APIGatewayProxyRequest` of aws-lambda-go,
+// so the package does not depend on it.
+type APIGatewayProxyRequest struct {
+	Resource                        string              `json:"resource"`
+	Path                            string              `json:"path"`
+	HTTPMethod                      string              `json:"httpMethod"`
+	Headers                         map[string]string   `json:"headers"`
+	MultiValueHeaders               map[string][]string `json:"multiValueHeaders"`
+	QueryStringParameters           map[string]string   `json:"queryStringParameters"`
+	MultiValueQueryStringParameters map[string][]string `json:"multiValueQueryStringParameters"`
+	PathParameters                  map[string]string   `json:"pathParameters"`
+	Body                            string              `json:"body"`
+	IsBase64Encoded                 bool                `json:"isBase64Encoded"`
+}
+
+// APIGatewayV2HTTPRequest holds the fields of an AWS API Gateway HTTP API (v2) event that the scanners use.
+// It decodes from the raw event json and mirrors `events.APIGatewayV2HTTPRequest` of aws-lambda-go.
+type APIGatewayV2HTTPRequest struct {
+	RawPath               string            `json:"rawPath"`
+	RawQueryString        string            `json:"rawQueryString"`
+	Cookies               []string          `json:"cookies"`
+	Headers               map[string]string `json:"headers"`
+	QueryStringParameters map[string]string `json:"queryStringParameters"`
+	PathParameters        map[string]string `json:"pathParameters"`
+	Body                  string            `json:"body"`
+	IsBase64Encoded       bool              `json:"isBase64Encoded"`
+	RequestContext        struct {
+		HTTP struct {
+			Method string `json:"method"`
+			Path   string `json:"path"`
+		} `json:"http"`
+	} `json:"requestContext"`
+}
+
+// eventRequest creates the `*http.Request` an api gateway event describes
+func eventRequest(method, path string, query url.Values, body string, base64Encoded bool) (*http.Request, error) {
+	if base64Encoded {
+		b, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return nil, err
+		}
+		body = string(b)
+	}
+
+	u := &url.URL{Path: path, RawQuery: query.Encode()}
+	req, err := http.NewRequest(method, u.String(), strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body == "" {
+		req.Body = http.NoBody
+	}
+
+	return req, nil
+}
+
+// NewAPIGateway creates a request scanner for an API Gateway REST (v1) proxy event
+func NewAPIGateway(ev *APIGatewayProxyRequest, opts ...Option) (*Request, error) {
+	query := url.Values{}
+	for key, value := range ev.QueryStringParameters {
+		query.Set(key, value)
+	}
+	for key, values := range ev.MultiValueQueryStringParameters {
+		query[key] = values
+	}
+
+	req, err := eventRequest(ev.HTTPMethod, ev.Path, query, ev.Body, ev.IsBase64Encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range ev.Headers {
+		req.Header.Set(key, value)
+	}
+	for key, values := range ev.MultiValueHeaders {
+		req.Header[http.CanonicalHeaderKey(key)] = values
+	}
+	for key, value := range ev.PathParameters {
+		req.SetPathValue(key, value)
+	}
+
+	return NewRequest(req, opts...), nil
+}
+
+// NewAPIGatewayV2 creates a request scanner for an API Gateway HTTP API (v2) event
+func NewAPIGatewayV2(ev *APIGatewayV2HTTPRequest, opts ...Option) (*Request, error) {
+	query, err := newConfig(opts).parseQuery(ev.RawQueryString)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range ev.QueryStringParameters {
+		if _, ok := query[key]; !ok {
+			query.Set(key, value)
+		}
+	}
+
+	path := ev.RawPath
+	if path == "" {
+		path = ev.RequestContext.HTTP.Path
+	}
+
+	req, err := eventRequest(ev.RequestContext.HTTP.Method, path, query, ev.Body, ev.IsBase64Encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range ev.Headers {
+		req.Header.Set(key, value)
+	}
+	if len(ev.Cookies) > 0 {
+		req.Header.Set("Cookie", strings.Join(ev.Cookies, "; "))
+	}
+	for key, value := range ev.PathParameters {
+		req.SetPathValue(key, value)
+	}
+
+	return NewRequest(req, opts...), nil
+}
@@ -0,0 +1,102 @@
+package scanner
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/canpacis/scanner/structd"
+)
+
+// A scanner to scan the target urls of RFC 8288 `Link` headers by their relation types onto a struct,
+// e.g. `link:"next"` for the pagination links of an api response.
+type Link struct {
+	links  map[string]string
+	config *config
+}
+
+func (l *Link) Get(rel string) any {
+	return l.links[strings.ToLower(rel)]
+}
+
+var urlType = reflect.TypeFor[url.URL]()
+
+func (l *Link) Cast(from any, to reflect.Type) (any, error) {
+	if s, ok := from.(string); ok {
+		switch to {
+		case urlType:
+			u, err := url.Parse(s)
+			if err != nil {
+				return nil, err
+			}
+			return *u, nil
+		case reflect.PointerTo(urlType):
+			return url.Parse(s)
+		}
+	}
+
+	return structd.DefaultCast(from, to)
+}
+
+// Scans the link targets onto v
+func (l *Link) Scan(v any) error {
+	return l.config.decoder(l, "link").Decode(v)
+}
+
+// parseLinks parses a Link header value into a map of relation types to target urls,
+// the first link of a relation wins
+func parseLinks(value string, links map[string]string) {
+	for {
+		start := strings.IndexByte(value, '<')
+		if start < 0 {
+			return
+		}
+		end := strings.IndexByte(value[start:], '>')
+		if end < 0 {
+			return
+		}
+		target := value[start+1 : start+end]
+		value = value[start+end+1:]
+
+		// parameters run until the next comma outside of a quoted string
+		quoted := false
+		i := 0
+		for ; i < len(value); i++ {
+			if value[i] == '"' {
+				quoted = !quoted
+			}
+			if value[i] == ',' && !quoted {
+				break
+			}
+		}
+		params := value[:i]
+		value = value[min(i+1, len(value)):]
+
+		for _, param := range strings.Split(params, ";") {
+			key, rel, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || !strings.EqualFold(strings.TrimSpace(key), "rel") {
+				continue
+			}
+
+			for _, r := range strings.Fields(strings.Trim(strings.TrimSpace(rel), `"`)) {
+				r = strings.ToLower(r)
+				if _, ok := links[r]; !ok {
+					links[r] = target
+				}
+			}
+		}
+	}
+}
+
+func NewLink(h http.Header, opts ...Option) *Link {
+	links := map[string]string{}
+	for _, value := range h.Values("Link") {
+		parseLinks(value, links)
+	}
+
+	return &Link{
+		links:  links,
+		config: newConfig(opts),
+	}
+}
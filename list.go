@@ -0,0 +1,202 @@
+package scanner
+
+import (
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A ListError describes an invalid pagination, sorting or filtering parameter, it usually maps to an http 400 status
+type ListError struct {
+	Param  string
+	Value  string
+	Reason string
+}
+
+func (e *ListError) Error() string {
+	return "scanner: invalid " + e.Param + " parameter " + strconv.Quote(e.Value) + ": " + e.Reason
+}
+
+// Page is a 1 based page number with its size
+type Page struct {
+	Number int
+	Size   int
+}
+
+// Offset returns the number of items before the page
+func (p Page) Offset() int {
+	return (p.Number - 1) * p.Size
+}
+
+// Limit returns the number of items on the page
+func (p Page) Limit() int {
+	return p.Size
+}
+
+// SortDirection is the direction of a sort column
+type SortDirection int
+
+const (
+	Ascending SortDirection = iota
+	Descending
+)
+
+func (d SortDirection) String() string {
+	if d == Descending {
+		return "desc"
+	}
+	return "asc"
+}
+
+// SortField is a single column of a sort parameter
+type SortField struct {
+	Column    string
+	Direction SortDirection
+}
+
+// Sort holds the columns of a sort parameter like `-created_at,name` in order of precedence,
+// a leading `-` sorts a column in descending order.
+type Sort []SortField
+
+func (s *Sort) UnmarshalString(v string) error {
+	fields := Sort{}
+
+	for _, column := range strings.Split(v, ",") {
+		column = strings.TrimSpace(column)
+		field := SortField{Column: column, Direction: Ascending}
+		switch {
+		case strings.HasPrefix(column, "-"):
+			field = SortField{Column: column[1:], Direction: Descending}
+		case strings.HasPrefix(column, "+"):
+			field.Column = column[1:]
+		}
+		if field.Column == "" {
+			continue
+		}
+		fields = append(fields, field)
+	}
+
+	*s = fields
+	return nil
+}
+
+// Filter holds the values of bracketed filter parameters like `filter[status]=open` by their field
+type Filter map[string]string
+
+// ListOptions configures the parameters a `scanner.List` accepts
+type ListOptions struct {
+	// SortColumns are the columns that can be sorted by, any column is accepted when empty
+	SortColumns []string
+	// FilterFields are the fields that can be filtered by, any field is accepted when empty
+	FilterFields []string
+	// DefaultSize is the page size when none is given, 20 when zero
+	DefaultSize int
+	// MaxSize is the largest page size accepted, 100 when zero
+	MaxSize int
+}
+
+// A scanner to scan the pagination, sorting and filtering parameters of a list endpoint like
+// `?page=2&per_page=50&sort=-created_at,name&filter[status]=open&fields=id,name`. It binds `scanner.Page`,
+// `scanner.Sort`, `scanner.Filter` and `scanner.FieldMask` fields with the `list:"page"`, `list:"sort"`,
+// `list:"filter"` and `list:"fields"` tags.
+type List struct {
+	values  url.Values
+	options ListOptions
+	params  map[string]any
+	config  *config
+}
+
+func (s *List) Get(key string) any {
+	return s.params[key]
+}
+
+// parse reads and validates the list parameters
+func (s *List) parse() error {
+	size := s.options.DefaultSize
+	if size <= 0 {
+		size = 20
+	}
+	limit := s.options.MaxSize
+	if limit <= 0 {
+		limit = 100
+	}
+	page := Page{Number: 1, Size: size}
+
+	if raw := s.values.Get("page"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return &ListError{Param: "page", Value: raw, Reason: "must be a positive integer"}
+		}
+		page.Number = n
+	}
+	if raw := s.values.Get("per_page"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return &ListError{Param: "per_page", Value: raw, Reason: "must be a positive integer"}
+		}
+		if n > limit {
+			return &ListError{Param: "per_page", Value: raw, Reason: "must be at most " + strconv.Itoa(limit)}
+		}
+		page.Size = n
+	}
+
+	sort := Sort{}
+	if raw := s.values.Get("sort"); raw != "" {
+		if err := sort.UnmarshalString(raw); err != nil {
+			return err
+		}
+		for _, field := range sort {
+			if len(s.options.SortColumns) > 0 && !slices.Contains(s.options.SortColumns, field.Column) {
+				return &ListError{Param: "sort", Value: raw, Reason: "cannot sort by " + field.Column}
+			}
+		}
+	}
+
+	filter := Filter{}
+	for _, key := range slices.Sorted(maps.Keys(s.values)) {
+		v := s.values[key]
+		field, ok := strings.CutPrefix(key, "filter[")
+		if !ok || !strings.HasSuffix(field, "]") || len(v) == 0 {
+			continue
+		}
+		field = strings.TrimSuffix(field, "]")
+		if len(s.options.FilterFields) > 0 && !slices.Contains(s.options.FilterFields, field) {
+			return &ListError{Param: "filter", Value: key, Reason: "cannot filter by " + field}
+		}
+		filter[field] = v[0]
+	}
+
+	fields := FieldMask{}
+	if raw := s.values.Get("fields"); raw != "" {
+		if err := fields.UnmarshalString(raw); err != nil {
+			return &ListError{Param: "fields", Value: raw, Reason: "malformed field mask"}
+		}
+	}
+
+	s.params = map[string]any{
+		"page":   page,
+		"sort":   sort,
+		"filter": filter,
+		"fields": fields,
+	}
+	return nil
+}
+
+// Scans the list parameters onto v, invalid parameters are reported as a `*scanner.ListError`
+func (s *List) Scan(v any) error {
+	if err := s.parse(); err != nil {
+		return err
+	}
+
+	return s.config.decoder(s, "list").Decode(v)
+}
+
+func NewList[V Values](v V, options ListOptions, opts ...Option) *List {
+	return &List{
+		values:  *toValues(v),
+		options: options,
+		config:  newConfig(opts),
+	}
+}
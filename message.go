@@ -0,0 +1,136 @@
+package scanner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/canpacis/scanner/structd"
+)
+
+// A Message is a message consumed from a queue or a stream
+type Message interface {
+	Headers() map[string][]string
+	Key() []byte
+	Body() io.Reader
+}
+
+// RawMessage is a ready to use `scanner.Message`, the adapters for the common queue clients create one
+type RawMessage struct {
+	Header  map[string][]string
+	MsgKey  []byte
+	Payload []byte
+}
+
+func (m *RawMessage) Headers() map[string][]string {
+	return m.Header
+}
+
+func (m *RawMessage) Key() []byte {
+	return m.MsgKey
+}
+
+func (m *RawMessage) Body() io.Reader {
+	return bytes.NewReader(m.Payload)
+}
+
+// KafkaMessage adapts the fields of a kafka-go message, e.g. `scanner.KafkaMessage(msg.Key, msg.Value, msg.Headers)`
+func KafkaMessage[H ~struct {
+	Key   string
+	Value []byte
+}](key, value []byte, headers []H) *RawMessage {
+	h := http.Header{}
+	for _, header := range headers {
+		kv := struct {
+			Key   string
+			Value []byte
+		}(header)
+		h.Add(kv.Key, string(kv.Value))
+	}
+
+	return &RawMessage{Header: h, MsgKey: key, Payload: value}
+}
+
+// NATSMessage adapts the fields of a NATS message, the subject is used as its key,
+// e.g. `scanner.NATSMessage(msg.Subject, msg.Header, msg.Data)`
+func NATSMessage(subject string, header map[string][]string, data []byte) *RawMessage {
+	return &RawMessage{Header: header, MsgKey: []byte(subject), Payload: data}
+}
+
+// AMQPMessage adapts the fields of an amqp091 delivery, the routing key is used as its key and the content
+// type is added to the headers, e.g. `scanner.AMQPMessage(d.RoutingKey, d.ContentType, d.Headers, d.Body)`
+func AMQPMessage(routingKey, contentType string, headers map[string]any, body []byte) *RawMessage {
+	h := http.Header{}
+	for key, value := range headers {
+		h.Add(key, fmt.Sprint(value))
+	}
+	if contentType != "" {
+		h.Set("Content-Type", contentType)
+	}
+
+	return &RawMessage{Header: h, MsgKey: []byte(routingKey), Payload: body}
+}
+
+// messageMeta is the getter of the `meta` tag, it exposes the message key under `key` and the headers otherwise
+type messageMeta struct {
+	key    []byte
+	header *Header
+}
+
+func (m *messageMeta) Get(key string) any {
+	if key == "key" {
+		return string(m.key)
+	}
+
+	return m.header.Get(key)
+}
+
+func (m *messageMeta) Cast(from any, to reflect.Type) (any, error) {
+	return structd.DefaultCast(from, to)
+}
+
+// A scanner to scan a `scanner.Message` onto a struct. Headers are bound with the `header` tag, the `meta` tag
+// additionally exposes the message key as `key`, and json bodies are decoded onto the `json` tags.
+type MessageScanner struct {
+	m    Message
+	opts []Option
+}
+
+// Scans the message onto v
+func (s *MessageScanner) Scan(v any) error {
+	h := http.Header{}
+	for key, values := range s.m.Headers() {
+		h[http.CanonicalHeaderKey(key)] = values
+	}
+	header := NewHeader(&h, s.opts...)
+
+	meta := &messageMeta{key: s.m.Key(), header: header}
+	if err := header.config.decoder(meta, "meta").Decode(v); err != nil {
+		return err
+	}
+	if err := header.Scan(v); err != nil {
+		return err
+	}
+
+	contentType := h.Get("Content-Type")
+	if contentType != "" && !isJSON(contentType) && !strings.HasPrefix(contentType, "text/json") {
+		return nil
+	}
+
+	err := NewJSON(s.m.Body(), s.opts...).Scan(v)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
+
+func NewMessage(m Message, opts ...Option) *MessageScanner {
+	return &MessageScanner{
+		m:    m,
+		opts: withoutTag(opts),
+	}
+}
@@ -0,0 +1,165 @@
+package scanner
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// RequestMeta holds the cross-cutting metadata of a request. A struct that embeds it has it filled by
+// the `scanner.Meta` and `scanner.Request` scanners.
+type RequestMeta struct {
+	// RequestID is the `X-Request-Id` header, a random id is generated when it is missing or malformed
+	RequestID string
+	// IdempotencyKey is the `Idempotency-Key` header, it is empty when missing
+	IdempotencyKey string
+	// TraceID and ParentID are read from a W3C `traceparent` header, a random trace id is generated
+	// and ParentID is left empty when it is missing or malformed
+	TraceID  string
+	ParentID string
+	Sampled  bool
+}
+
+var requestMetaType = reflect.TypeFor[RequestMeta]()
+
+// randomHex returns n random bytes in hex
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// isHex reports whether s is n lowercase hex digits
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// validID reports whether a client supplied id is safe to log and propagate
+func validID(s string) bool {
+	if s == "" || len(s) > 200 {
+		return false
+	}
+	for _, c := range s {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// parseTraceparent parses a `traceparent` header value, versions after 00 may have more fields
+func parseTraceparent(s string) (trace, parent string, sampled, ok bool) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || !isHex(parts[0], 2) || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return "", "", false, false
+	}
+	if !isHex(parts[1], 32) || !isHex(parts[2], 16) || !isHex(parts[3], 2) {
+		return "", "", false, false
+	}
+	// all zero ids are invalid
+	if strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		return "", "", false, false
+	}
+
+	flags, _ := hex.DecodeString(parts[3])
+	return parts[1], parts[2], flags[0]&1 == 1, true
+}
+
+// NewRequestMeta extracts the metadata of a request, generating the ids it is missing
+func NewRequestMeta(req *http.Request) RequestMeta {
+	meta := RequestMeta{
+		RequestID:      strings.TrimSpace(req.Header.Get("X-Request-Id")),
+		IdempotencyKey: strings.Trim(strings.TrimSpace(req.Header.Get("Idempotency-Key")), `"`),
+	}
+	if !validID(meta.RequestID) {
+		meta.RequestID = randomHex(16)
+	}
+	if !validID(meta.IdempotencyKey) {
+		meta.IdempotencyKey = ""
+	}
+
+	trace, parent, sampled, ok := parseTraceparent(req.Header.Get("Traceparent"))
+	if !ok {
+		trace = randomHex(16)
+	}
+	meta.TraceID, meta.ParentID, meta.Sampled = trace, parent, sampled
+
+	return meta
+}
+
+// A scanner to scan the metadata of a request. It fills embedded `scanner.RequestMeta` structs and binds
+// the `meta` tag with the `request-id`, `idempotency-key`, `trace-id`, `parent-id` and `sampled` keys.
+type Meta struct {
+	req    *http.Request
+	meta   *RequestMeta
+	config *config
+}
+
+// extract extracts the metadata once, so every field sees the same generated ids
+func (s *Meta) extract() RequestMeta {
+	if s.meta == nil {
+		meta := NewRequestMeta(s.req)
+		s.meta = &meta
+	}
+	return *s.meta
+}
+
+func (s *Meta) Get(key string) any {
+	meta := s.extract()
+
+	switch strings.ToLower(key) {
+	case "request-id":
+		return meta.RequestID
+	case "idempotency-key":
+		return meta.IdempotencyKey
+	case "trace-id":
+		return meta.TraceID
+	case "parent-id":
+		return meta.ParentID
+	case "sampled":
+		return meta.Sampled
+	default:
+		return nil
+	}
+}
+
+// Scans the request metadata onto v
+func (s *Meta) Scan(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() && rv.Elem().Kind() == reflect.Struct {
+		rv = rv.Elem()
+		for i := range rv.NumField() {
+			field := rv.Type().Field(i)
+			if !field.Anonymous || !field.IsExported() {
+				continue
+			}
+
+			switch field.Type {
+			case requestMetaType:
+				rv.Field(i).Set(reflect.ValueOf(s.extract()))
+			case reflect.PointerTo(requestMetaType):
+				meta := s.extract()
+				rv.Field(i).Set(reflect.ValueOf(&meta))
+			}
+		}
+	}
+
+	return s.config.decoder(s, "meta").Decode(v)
+}
+
+func NewMeta(req *http.Request, opts ...Option) *Meta {
+	return &Meta{
+		req:    req,
+		config: newConfig(opts),
+	}
+}
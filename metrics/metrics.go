@@ -0,0 +1,147 @@
+// Package metrics exposes the outcomes of scans as Prometheus metrics. A Collector is a `scanner.ScanObserver`
+// and a `prometheus.Collector`:
+//
+//	m := metrics.New("app")
+//	prometheus.MustRegister(m)
+//	s := scanner.NewJSON(r.Body, scanner.WithObserver(m))
+package metrics
+
+import (
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/canpacis/scanner"
+	"github.com/canpacis/scanner/structd"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// A Collector counts scans and failures and measures scan and cast durations and upload sizes
+type Collector struct {
+	scans        *prometheus.CounterVec
+	failures     *prometheus.CounterVec
+	scanDuration *prometheus.HistogramVec
+	castDuration *prometheus.HistogramVec
+	castFailures *prometheus.CounterVec
+	uploadSize   *prometheus.HistogramVec
+}
+
+// New creates a collector of metrics in namespace, all of them are in the `scanner` subsystem
+func New(namespace string) *Collector {
+	return &Collector{
+		scans: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "scanner", Name: "scans_total",
+			Help: "Scans by scanner kind and target type.",
+		}, []string{"kind", "target"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "scanner", Name: "scan_failures_total",
+			Help: "Failed scans by scanner kind, target type and error type.",
+		}, []string{"kind", "target", "error"}),
+		scanDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Subsystem: "scanner", Name: "scan_duration_seconds",
+			Help:    "Duration of scans by scanner kind.",
+			Buckets: prometheus.ExponentialBuckets(0.000_01, 4, 10),
+		}, []string{"kind"}),
+		castDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Subsystem: "scanner", Name: "cast_duration_seconds",
+			Help:    "Duration of casts by scanner kind and field type.",
+			Buckets: prometheus.ExponentialBuckets(0.000_001, 4, 10),
+		}, []string{"kind", "type"}),
+		castFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "scanner", Name: "cast_failures_total",
+			Help: "Failed casts by scanner kind and field type.",
+		}, []string{"kind", "type"}),
+		uploadSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Subsystem: "scanner", Name: "upload_size_bytes",
+			Help:    "Size of uploaded files by scanner kind.",
+			Buckets: prometheus.ExponentialBuckets(1024, 4, 10),
+		}, []string{"kind"}),
+	}
+}
+
+func (c *Collector) collectors() []prometheus.Collector {
+	return []prometheus.Collector{c.scans, c.failures, c.scanDuration, c.castDuration, c.castFailures, c.uploadSize}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, collector := range c.collectors() {
+		collector.Describe(ch)
+	}
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, collector := range c.collectors() {
+		collector.Collect(ch)
+	}
+}
+
+func typeName(t reflect.Type) string {
+	if t == nil {
+		return "nil"
+	}
+	return t.String()
+}
+
+func (c *Collector) ObserveScan(kind string, target reflect.Type, d time.Duration, err error) {
+	c.scans.WithLabelValues(kind, typeName(target)).Inc()
+	c.scanDuration.WithLabelValues(kind).Observe(d.Seconds())
+	if err != nil {
+		c.failures.WithLabelValues(kind, typeName(target), ErrorType(err)).Inc()
+	}
+}
+
+func (c *Collector) ObserveCast(kind string, to reflect.Type, d time.Duration, err error) {
+	c.castDuration.WithLabelValues(kind, typeName(to)).Observe(d.Seconds())
+	if err != nil {
+		c.castFailures.WithLabelValues(kind, typeName(to)).Inc()
+	}
+}
+
+func (c *Collector) ObserveUpload(kind string, field string, size int64) {
+	if size >= 0 {
+		c.uploadSize.WithLabelValues(kind).Observe(float64(size))
+	}
+}
+
+// ErrorType classifies a scan error for the `error` label of failures, like `constraint` or `body_too_large`
+func ErrorType(err error) string {
+	var (
+		tooLarge   *scanner.BodyTooLargeError
+		rejected   *scanner.RejectedUploadError
+		missing    *scanner.MissingSecretError
+		csrf       *scanner.CSRFError
+		webhook    *scanner.WebhookError
+		panicked   *structd.DecodePanicError
+		constraint *structd.ConstraintError
+		tag        *structd.TagError
+		unmarshal  *structd.UnmarshalerError
+		typeErr    *structd.UnmarshalTypeError
+		cast       *structd.CastError
+		limit      *structd.LimitError
+	)
+
+	switch {
+	case errors.As(err, &tooLarge):
+		return "body_too_large"
+	case errors.As(err, &rejected):
+		return "rejected_upload"
+	case errors.As(err, &missing):
+		return "missing_secret"
+	case errors.As(err, &csrf):
+		return "csrf"
+	case errors.As(err, &webhook):
+		return "webhook"
+	case errors.As(err, &panicked):
+		return "panic"
+	case errors.As(err, &limit):
+		return "limit"
+	case errors.As(err, &constraint):
+		return "constraint"
+	case errors.As(err, &tag):
+		return "tag"
+	case errors.As(err, &unmarshal), errors.As(err, &typeErr), errors.As(err, &cast):
+		return "cast"
+	default:
+		return "other"
+	}
+}
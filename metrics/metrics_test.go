@@ -0,0 +1,63 @@
+package metrics_test
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/canpacis/scanner"
+	"github.com/canpacis/scanner/metrics"
+	"github.com/canpacis/scanner/structd"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	assert := assert.New(t)
+
+	m := metrics.New("app")
+	registry := prometheus.NewPedanticRegistry()
+	assert.NoError(registry.Register(m))
+
+	type Params struct {
+		Page int `query:"page"`
+	}
+	assert.NoError(scanner.NewQuery(url.Values{"page": {"2"}}, scanner.WithObserver(m)).Scan(&Params{}))
+	assert.Error(scanner.NewQuery(url.Values{"page": {"two"}}, scanner.WithObserver(m)).Scan(&Params{}))
+
+	type Body struct {
+		Name string `json:"name"`
+	}
+	s := scanner.NewJSON(bytes.NewReader([]byte(`{"name":"ada"}`)), scanner.WithMaxBytes(4), scanner.WithObserver(m))
+	assert.Error(s.Scan(&Body{}))
+
+	expected := `
+# HELP app_scanner_scan_failures_total Failed scans by scanner kind, target type and error type.
+# TYPE app_scanner_scan_failures_total counter
+app_scanner_scan_failures_total{error="body_too_large",kind="json",target="metrics_test.Body"} 1
+app_scanner_scan_failures_total{error="cast",kind="query",target="metrics_test.Params"} 1
+# HELP app_scanner_scans_total Scans by scanner kind and target type.
+# TYPE app_scanner_scans_total counter
+app_scanner_scans_total{kind="json",target="metrics_test.Body"} 1
+app_scanner_scans_total{kind="query",target="metrics_test.Params"} 2
+# HELP app_scanner_cast_failures_total Failed casts by scanner kind and field type.
+# TYPE app_scanner_cast_failures_total counter
+app_scanner_cast_failures_total{kind="query",type="int"} 1
+`
+	assert.NoError(testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"app_scanner_scans_total", "app_scanner_scan_failures_total", "app_scanner_cast_failures_total"))
+	assert.Equal(1, testutil.CollectAndCount(m, "app_scanner_cast_duration_seconds"))
+	assert.Equal(2, testutil.CollectAndCount(m, "app_scanner_scan_duration_seconds"))
+}
+
+func TestErrorType(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("body_too_large", metrics.ErrorType(&scanner.BodyTooLargeError{Limit: 1}))
+	assert.Equal("missing_secret", metrics.ErrorType(&scanner.MissingSecretError{Name: "db"}))
+	assert.Equal("limit", metrics.ErrorType(&structd.LimitError{Limit: structd.LimitKeys, Max: 1, Key: "q"}))
+	assert.Equal("other", metrics.ErrorType(errors.New("unexpected")))
+}
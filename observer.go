@@ -0,0 +1,110 @@
+package scanner
+
+import (
+	"io"
+	"mime/multipart"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/canpacis/scanner/structd"
+)
+
+// A ScanObserver receives the outcomes of scans, like a metrics collector. Kind is the default tag key of
+// the scanner that decoded the values, like `query` or `multipart`.
+type ScanObserver interface {
+	// ObserveScan is called after values are decoded onto a struct of type target, err is nil on success
+	ObserveScan(kind string, target reflect.Type, d time.Duration, err error)
+	// ObserveCast is called after a raw value is cast to a field of type to
+	ObserveCast(kind string, to reflect.Type, d time.Duration, err error)
+	// ObserveUpload is called once for every uploaded file of a multipart or image scanner
+	ObserveUpload(kind string, field string, size int64)
+}
+
+// WithObserver reports the scans, casts and uploads of a scanner to observer
+func WithObserver(observer ScanObserver) Option {
+	return func(c *config) {
+		c.observer = observer
+	}
+}
+
+// observedDecoder reports the outcome of its decodes to an observer
+type observedDecoder struct {
+	*structd.Decoder
+	kind     string
+	observer ScanObserver
+}
+
+func (d observedDecoder) Decode(v any) error {
+	start := time.Now()
+	err := d.Decoder.Decode(v)
+	observeScan(d.observer, d.kind, v, start, err)
+	return err
+}
+
+// observeScan reports a scan of v that started at start to observer
+func observeScan(observer ScanObserver, kind string, v any, start time.Time, err error) {
+	target := reflect.TypeOf(v)
+	if target != nil && target.Kind() == reflect.Pointer {
+		target = target.Elem()
+	}
+	observer.ObserveScan(kind, target, time.Since(start), err)
+}
+
+// observed runs scan with a copy of c and reports it to the observer of c as a single scan of kind, the
+// decodes of scan report their casts but not scans of their own
+func (c *config) observed(kind string, v any, scan func(c *config) error) error {
+	if c == nil || c.observer == nil {
+		return scan(c)
+	}
+
+	start := time.Now()
+	err := scan(c.withinScan())
+	observeScan(c.observer, kind, v, start, err)
+	return err
+}
+
+// withinScan returns a copy of c for decodes that are part of a scan that is observed already
+func (c *config) withinScan() *config {
+	if c == nil || c.observer == nil || c.scanning {
+		return c
+	}
+	copied := *c
+	copied.scanning = true
+	return &copied
+}
+
+// observeUploads reports the sizes of the files to the observer of c
+func (c *config) observeUploads(kind string, v *MultipartValues) {
+	if c.observer == nil {
+		return
+	}
+
+	fields := make([]string, 0, len(v.Files))
+	for field := range v.Files {
+		fields = append(fields, field)
+	}
+	slices.Sort(fields)
+
+	for _, field := range fields {
+		var size int64
+		if header := v.Headers[field]; header != nil {
+			size = header.Size
+		} else {
+			size = fileSize(v.Files[field])
+		}
+		c.observer.ObserveUpload(kind, field, size)
+	}
+}
+
+// fileSize returns the size of a file by seeking to its end, or -1 when it cannot be seeked
+func fileSize(file multipart.File) int64 {
+	end, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return -1
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return -1
+	}
+	return end
+}
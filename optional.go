@@ -0,0 +1,120 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+// Optional holds a scanned value along with whether it was present in the source,
+// so a value that was not sent can be told apart from one sent as its zero value.
+type Optional[T any] struct {
+	Value   T
+	Present bool
+}
+
+// Get returns the value and whether it was present
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.Present
+}
+
+// Or returns the value if it was present, fallback otherwise
+func (o Optional[T]) Or(fallback T) T {
+	if !o.Present {
+		return fallback
+	}
+	return o.Value
+}
+
+func (o Optional[T]) WrappedType() reflect.Type {
+	return reflect.TypeFor[T]()
+}
+
+func (o *Optional[T]) Wrap(v any) {
+	o.Value = v.(T)
+	o.Present = true
+}
+
+func (o *Optional[T]) UnmarshalJSON(b []byte) error {
+	o.Present = true
+	return json.Unmarshal(b, &o.Value)
+}
+
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.Present {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.Value)
+}
+
+// Some returns a present optional holding v
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{Value: v, Present: true}
+}
+
+// Null holds a scanned value that may be explicitly null, telling apart a key that was absent, sent as null
+// and sent with a value, like the fields of a partial update. Sources without nulls, like query strings,
+// only produce absent and valid values.
+type Null[T any] struct {
+	Value T
+	// Present is whether the key was in the source, as null or with a value
+	Present bool
+	// Valid is whether the key had a value that is not null
+	Valid bool
+}
+
+// Get returns the value and whether it was present and not null
+func (n Null[T]) Get() (T, bool) {
+	return n.Value, n.Valid
+}
+
+// IsNull reports whether the key was sent as null
+func (n Null[T]) IsNull() bool {
+	return n.Present && !n.Valid
+}
+
+// Or returns the value if it was present and not null, fallback otherwise
+func (n Null[T]) Or(fallback T) T {
+	if !n.Valid {
+		return fallback
+	}
+	return n.Value
+}
+
+func (n Null[T]) WrappedType() reflect.Type {
+	return reflect.TypeFor[T]()
+}
+
+func (n *Null[T]) Wrap(v any) {
+	n.Value = v.(T)
+	n.Present = true
+	n.Valid = true
+}
+
+func (n *Null[T]) UnmarshalJSON(b []byte) error {
+	var zero T
+	n.Value = zero
+	n.Present = true
+	n.Valid = !bytes.Equal(bytes.TrimSpace(b), []byte("null"))
+	if !n.Valid {
+		return nil
+	}
+	return json.Unmarshal(b, &n.Value)
+}
+
+func (n Null[T]) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.Value)
+}
+
+// Valid returns a Null holding v
+func Valid[T any](v T) Null[T] {
+	return Null[T]{Value: v, Present: true, Valid: true}
+}
+
+// Nulled returns a Null that was sent as null
+func Nulled[T any]() Null[T] {
+	return Null[T]{Present: true}
+}
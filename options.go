@@ -0,0 +1,271 @@
+package scanner
+
+import (
+	"io"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"github.com/canpacis/scanner/structd"
+)
+
+// An Option configures a scanner
+type Option func(*config)
+
+type config struct {
+	maxBytes    int64
+	tag         string
+	strict      bool
+	rawBody     bool
+	replay      bool
+	logger      *slog.Logger
+	casters     []Caster
+	cookieCodec CookieCodec
+	inspectors  []ContentInspector
+	directory   directoryConfig
+	observer    ScanObserver
+	// literalPlus and lenientEscapes configure the decoding of raw query and form strings
+	literalPlus    bool
+	lenientEscapes bool
+	schema         SchemaValidator
+	// encodings are the content codings of body sources in the order they were applied
+	encodings       []string
+	maxDecompressed int64
+	charset         string
+	// scanning is set within scans that are reported to the observer by their scanner
+	scanning       bool
+	decoderOptions []structd.Option
+}
+
+// A Caster converts a raw value to type to, returning `errors.ErrUnsupported` passes the value to the next caster
+type Caster = structd.Caster
+
+// WithMaxBytes limits the number of bytes a scanner reads from its source to n, reading past it fails
+// with a `*scanner.BodyTooLargeError`
+func WithMaxBytes(n int64) Option {
+	return func(c *config) {
+		c.maxBytes = n
+	}
+}
+
+// Limits bound the keys, value lengths and slice elements a single scan reads, zero fields are unlimited
+type Limits = structd.Limits
+
+// WithLimits makes scans fail with a `*structd.LimitError` on input that exceeds l, like a query value
+// with a huge comma separated list
+func WithLimits(l Limits) Option {
+	return func(c *config) {
+		c.decoderOptions = append(c.decoderOptions, structd.WithLimits(l))
+	}
+}
+
+// WithLocation sets the location naive date and time values are parsed in
+func WithLocation(loc *time.Location) Option {
+	return func(c *config) {
+		c.decoderOptions = append(c.decoderOptions, structd.WithLocation(loc))
+	}
+}
+
+// WithStrict makes scanners reject input they cannot fully bind, json bodies with unknown fields fail to scan
+func WithStrict() Option {
+	return func(c *config) {
+		c.strict = true
+	}
+}
+
+// WithRawBody makes body scanners keep the raw bytes they decode and bind them to `raw:"body"` fields,
+// for audit logs and signature checks that need the exact bytes
+func WithRawBody() Option {
+	return func(c *config) {
+		c.rawBody = true
+	}
+}
+
+// WithReplay makes scanners of readers and files keep or rewind their source, so a scanner can scan
+// more than one value. Json bodies and directory files are buffered, multipart files are rewound.
+func WithReplay() Option {
+	return func(c *config) {
+		c.replay = true
+	}
+}
+
+// WithLogger sets the logger scanners report recoverable failures to, `slog.Default()` is used otherwise
+func WithLogger(l *slog.Logger) Option {
+	return func(c *config) {
+		c.logger = l
+	}
+}
+
+// WithCaster registers casters that are tried in order before the cast of the scanner
+func WithCaster(casters ...Caster) Option {
+	return func(c *config) {
+		c.casters = append(c.casters, casters...)
+	}
+}
+
+// WithDecoderOptions passes options to the struct decoders of a scanner, like `structd.WithAfterDecode`
+func WithDecoderOptions(opts ...structd.Option) Option {
+	return func(c *config) {
+		c.decoderOptions = append(c.decoderOptions, opts...)
+	}
+}
+
+// WithTag overrides the tag key a scanner binds, e.g. `NewQuery(v, WithTag("url"))` binds `url:"page"` fields.
+// Scanners that bind many tags, like `scanner.Request`, ignore it.
+func WithTag(tag string) Option {
+	return func(c *config) {
+		c.tag = tag
+	}
+}
+
+// withoutTag returns opts with the tag override removed, for scanners that bind many tags
+func withoutTag(opts []Option) []Option {
+	return append(opts[:len(opts):len(opts)], WithTag(""))
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// decoder creates a struct decoder for the getter with the configured tag and decoder options,
+// key is the default tag of the scanner
+func (c *config) decoder(getter structd.Getter, key string) decoder {
+	if c == nil {
+		return structd.New(getter, key)
+	}
+	kind := key
+	if c.tag != "" {
+		key = c.tag
+	}
+	if c.observer != nil {
+		d := structd.New(withCasters(getter, c.casters, kind, c.observer), key, append(c.decoderOptions[:len(c.decoderOptions):len(c.decoderOptions)], structd.WithoutGenerated())...)
+		if c.scanning {
+			return d
+		}
+		return observedDecoder{Decoder: d, kind: kind, observer: c.observer}
+	}
+	if len(c.casters) > 0 {
+		getter = withCasters(getter, c.casters, "", nil)
+		return structd.New(getter, key, append(c.decoderOptions[:len(c.decoderOptions):len(c.decoderOptions)], structd.WithoutGenerated())...)
+	}
+
+	return structd.New(getter, key, c.decoderOptions...)
+}
+
+// decoder decodes the values of a getter onto a struct
+type decoder interface {
+	Decode(v any) error
+}
+
+// log returns the configured logger
+func (c *config) log() *slog.Logger {
+	if c == nil || c.logger == nil {
+		return slog.Default()
+	}
+	return c.logger
+}
+
+// castGetter tries the registered casters before the cast of the getter it wraps, and reports the casts
+// to the observer when there is one
+type castGetter struct {
+	structd.Getter
+	casters  []Caster
+	kind     string
+	observer ScanObserver
+}
+
+func (g *castGetter) Cast(from any, to reflect.Type) (any, error) {
+	if g.observer == nil {
+		return g.cast(from, to)
+	}
+
+	start := time.Now()
+	v, err := g.cast(from, to)
+	g.observer.ObserveCast(g.kind, to, time.Since(start), err)
+	return v, err
+}
+
+func (g *castGetter) cast(from any, to reflect.Type) (any, error) {
+	chain := g.casters[:len(g.casters):len(g.casters)]
+	if c, ok := g.Getter.(interface {
+		Cast(any, reflect.Type) (any, error)
+	}); ok {
+		chain = append(chain, c.Cast)
+	}
+	return structd.Chain(chain...)(from, to)
+}
+
+// Keys returns the keys of the getter it wraps, none when it cannot list them
+func (g *castGetter) Keys() []string {
+	if k, ok := g.Getter.(structd.KeyGetter); ok {
+		return k.Keys()
+	}
+	return nil
+}
+
+// MatchKey matches the keys of the getter it wraps like it does
+func (g *castGetter) MatchKey(name, key string) bool {
+	if m, ok := g.Getter.(structd.KeyMatcher); ok {
+		return m.MatchKey(name, key)
+	}
+	return name == key
+}
+
+// multiCastGetter is a castGetter of a `structd.MultiGetter`
+type multiCastGetter struct {
+	*castGetter
+}
+
+func (g multiCastGetter) GetAll(key string) []string {
+	return g.Getter.(structd.MultiGetter).GetAll(key)
+}
+
+// withCasters wraps getter with the casters and the observer, keeping it a `structd.MultiGetter` if it is one
+func withCasters(getter structd.Getter, casters []Caster, kind string, observer ScanObserver) structd.Getter {
+	g := &castGetter{Getter: getter, casters: casters, kind: kind, observer: observer}
+	if _, ok := getter.(structd.MultiGetter); ok {
+		return multiCastGetter{g}
+	}
+	return g
+}
+
+// reader wraps the source of a body scanner according to the configured limits, content codings and charset
+func (c *config) reader(r io.Reader) io.Reader {
+	return c.transcode(c.decompress(c.limit(r)))
+}
+
+// limit wraps r according to the configured byte limit
+func (c *config) limit(r io.Reader) io.Reader {
+	if c.maxBytes <= 0 {
+		return r
+	}
+
+	return &limitedReader{r: r, n: c.maxBytes, limit: c.maxBytes}
+}
+
+type limitedReader struct {
+	r     io.Reader
+	n     int64
+	limit int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+
+	n, err := l.r.Read(p)
+	if int64(n) <= l.n {
+		l.n -= int64(n)
+		return n, err
+	}
+
+	n = int(l.n)
+	l.n = 0
+	return n, &BodyTooLargeError{Limit: l.limit}
+}
@@ -0,0 +1,180 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// A scanner to scan an RFC 7386 JSON merge patch from an `io.Reader` onto an existing struct.
+// Keys set to null reset their fields to the zero value and the patched keys are recorded for `Patch.Changed`.
+type Patch struct {
+	r       io.Reader
+	changed map[string]bool
+	config  *config
+}
+
+// Scans the merge patch onto v
+func (s *Patch) Scan(v any) error {
+	b, err := io.ReadAll(s.r)
+	if err != nil {
+		return err
+	}
+
+	doc := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return err
+	}
+	if doc == nil {
+		return errors.New("scanner: merge patch must be a json object")
+	}
+
+	d := json.NewDecoder(bytes.NewReader(b))
+	if s.config.strict {
+		d.DisallowUnknownFields()
+	}
+	if err := d.Decode(v); err != nil {
+		return err
+	}
+
+	s.changed = map[string]bool{}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+	s.merge(rv, doc, "")
+
+	return nil
+}
+
+// Changed reports whether the patch contained the given key, nested keys are separated by dots e.g. `address.city`
+func (s *Patch) Changed(field string) bool {
+	return s.changed[field]
+}
+
+// merge records the keys of doc and resets the values of the keys that are set to null
+func (s *Patch) merge(rv reflect.Value, doc map[string]json.RawMessage, prefix string) {
+	for key, raw := range doc {
+		s.changed[prefix+key] = true
+
+		null := bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+		var nested map[string]json.RawMessage
+		if !null {
+			json.Unmarshal(raw, &nested)
+		}
+
+		switch rv.Kind() {
+		case reflect.Struct:
+			field, ok := jsonField(rv, key)
+			if !ok {
+				continue
+			}
+			if null {
+				// nullable fields record the null they decoded instead of being reset
+				if _, ok := field.Addr().Interface().(interface{ IsNull() bool }); !ok {
+					field.Set(reflect.Zero(field.Type()))
+				}
+				continue
+			}
+			if nested != nil {
+				for field.Kind() == reflect.Pointer && !field.IsNil() {
+					field = field.Elem()
+				}
+				s.merge(field, nested, prefix+key+".")
+			}
+		case reflect.Map:
+			if null {
+				if k, ok := jsonMapKey(rv.Type().Key(), key); ok {
+					rv.SetMapIndex(k, reflect.Value{})
+				}
+			}
+		}
+	}
+}
+
+// jsonMapKey converts a json object key to a map key of type t like encoding/json, from strings,
+// text unmarshalers and integers
+func jsonMapKey(t reflect.Type, key string) (reflect.Value, bool) {
+	k := reflect.New(t)
+	if u, ok := k.Interface().(encoding.TextUnmarshaler); ok {
+		if err := u.UnmarshalText([]byte(key)); err != nil {
+			return reflect.Value{}, false
+		}
+		return k.Elem(), true
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(key).Convert(t), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(key, 10, 64)
+		if err != nil || reflect.Zero(t).OverflowInt(n) {
+			return reflect.Value{}, false
+		}
+		return reflect.ValueOf(n).Convert(t), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(key, 10, 64)
+		if err != nil || reflect.Zero(t).OverflowUint(n) {
+			return reflect.Value{}, false
+		}
+		return reflect.ValueOf(n).Convert(t), true
+	default:
+		return reflect.Value{}, false
+	}
+}
+
+// jsonField finds the field of a struct value that encoding/json would decode key into
+func jsonField(rv reflect.Value, key string) (reflect.Value, bool) {
+	i, ok := jsonFieldIndex(rv.Type(), key)
+	if !ok {
+		return reflect.Value{}, false
+	}
+	return rv.Field(i), true
+}
+
+// jsonFieldIndex finds the index of the field of a struct type that encoding/json would decode key into,
+// exact matches of names win over case-insensitive ones
+func jsonFieldIndex(rt reflect.Type, key string) (int, bool) {
+	fold := -1
+
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			tag, _, _ = strings.Cut(tag, ",")
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+
+		if name == key {
+			return i, true
+		}
+		if fold < 0 && strings.EqualFold(name, key) {
+			fold = i
+		}
+	}
+
+	return fold, fold >= 0
+}
+
+func NewPatch(r io.Reader, opts ...Option) *Patch {
+	c := newConfig(opts)
+
+	return &Patch{
+		r:      c.reader(r),
+		config: c,
+	}
+}
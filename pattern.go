@@ -0,0 +1,154 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrPatternMismatch is returned when an input does not match a pattern
+var ErrPatternMismatch = errors.New("scanner: input does not match the pattern")
+
+var placeholderName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// A Pattern matches text against literal segments with named placeholders, like
+// `order {id} shipped at {time}`. Placeholders capture as little as possible by default,
+// `{name:regexp}` restricts them to a regular expression like `{id:\d+}`. `{{` and `}}` match literal braces.
+type Pattern struct {
+	re *regexp.Regexp
+}
+
+// ParsePattern compiles a pattern
+func ParsePattern(pattern string) (*Pattern, error) {
+	b := &strings.Builder{}
+	b.WriteString(`(?s)^`)
+
+	names := map[string]bool{}
+	literal := &strings.Builder{}
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '{' && strings.HasPrefix(pattern[i:], "{{"), c == '}' && strings.HasPrefix(pattern[i:], "}}"):
+			literal.WriteByte(c)
+			i++
+		case c == '{':
+			end := closingBrace(pattern, i)
+			if end < 0 {
+				return nil, fmt.Errorf("scanner: unterminated placeholder in pattern %q", pattern)
+			}
+			name, expr, custom := strings.Cut(pattern[i+1:end], ":")
+			if !placeholderName.MatchString(name) {
+				return nil, fmt.Errorf("scanner: invalid placeholder name %q in pattern %q", name, pattern)
+			}
+			if names[name] {
+				return nil, fmt.Errorf("scanner: duplicate placeholder %q in pattern %q", name, pattern)
+			}
+			names[name] = true
+			if !custom {
+				expr = ".+?"
+			}
+			if _, err := regexp.Compile(expr); err != nil {
+				return nil, err
+			}
+
+			b.WriteString(regexp.QuoteMeta(literal.String()))
+			literal.Reset()
+			fmt.Fprintf(b, "(?P<%s>%s)", name, expr)
+			i = end
+		case c == '}':
+			return nil, fmt.Errorf("scanner: unexpected } in pattern %q", pattern)
+		default:
+			literal.WriteByte(c)
+		}
+	}
+	b.WriteString(regexp.QuoteMeta(literal.String()))
+	b.WriteString(`$`)
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, err
+	}
+	return &Pattern{re: re}, nil
+}
+
+// closingBrace returns the index of the brace that closes the placeholder opened at start, counting nested
+// braces of regular expressions like `{year:\d{4}}`
+func closingBrace(pattern string, start int) int {
+	depth := 0
+	for i := start; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// MustParsePattern is like `scanner.ParsePattern` but panics when the pattern is invalid
+func MustParsePattern(pattern string) *Pattern {
+	p, err := ParsePattern(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Match returns the captured placeholders of input
+func (p *Pattern) Match(input string) (map[string]string, error) {
+	match := p.re.FindStringSubmatch(input)
+	if match == nil {
+		return nil, ErrPatternMismatch
+	}
+
+	values := map[string]string{}
+	for i, name := range p.re.SubexpNames() {
+		if name != "" {
+			values[name] = match[i]
+		}
+	}
+	return values, nil
+}
+
+// Scanner creates a scanner of the placeholders input captures
+func (p *Pattern) Scanner(input string, opts ...Option) *PatternScanner {
+	return &PatternScanner{
+		pattern: p,
+		input:   input,
+		config:  newConfig(opts),
+	}
+}
+
+// A scanner to scan the placeholders a pattern captures from a string onto a struct with the `pattern` tag,
+// useful for semi-structured text like log lines or sms callbacks.
+type PatternScanner struct {
+	pattern *Pattern
+	input   string
+	config  *config
+}
+
+// Scans the captured placeholders onto v, it fails with `scanner.ErrPatternMismatch` when the input does not match
+func (s *PatternScanner) Scan(v any) error {
+	values, err := s.pattern.Match(s.input)
+	if err != nil {
+		return err
+	}
+
+	return s.config.decoder(templateValues(values), "pattern").Decode(v)
+}
+
+func NewPattern(pattern, input string, opts ...Option) (*PatternScanner, error) {
+	p, err := ParsePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Scanner(input, opts...), nil
+}
@@ -0,0 +1,205 @@
+package scanner
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/canpacis/scanner/structd"
+)
+
+// placeholders lists the placeholders the `blurhash=` and `color=` options of image tags compute
+var placeholders = map[string]func(image.Image) string{
+	"blurhash": Blurhash,
+	"color":    DominantColor,
+}
+
+// placeholderSamples is the number of pixels sampled on each axis to compute placeholders
+const placeholderSamples = 64
+
+// samples returns the pixels of img on a grid of at most placeholderSamples on each axis, in 8 bit rgb
+func samples(img image.Image) (pixels [][3]uint8, width, height int) {
+	bounds := img.Bounds()
+	width, height = min(bounds.Dx(), placeholderSamples), min(bounds.Dy(), placeholderSamples)
+	pixels = make([][3]uint8, 0, width*height)
+
+	for y := range height {
+		for x := range width {
+			r, g, b, _ := img.At(bounds.Min.X+x*bounds.Dx()/width, bounds.Min.Y+y*bounds.Dy()/height).RGBA()
+			pixels = append(pixels, [3]uint8{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8)})
+		}
+	}
+	return pixels, width, height
+}
+
+// DominantColor returns the most common color of img as `#rrggbb`, colors are grouped by their 4 high bits
+// and the average of the largest group is returned
+func DominantColor(img image.Image) string {
+	pixels, _, _ := samples(img)
+	if len(pixels) == 0 {
+		return ""
+	}
+
+	type bucket struct {
+		count   int
+		r, g, b int
+	}
+	buckets := map[int]*bucket{}
+	var dominant *bucket
+	for _, p := range pixels {
+		key := int(p[0]>>4)<<8 | int(p[1]>>4)<<4 | int(p[2]>>4)
+		b, ok := buckets[key]
+		if !ok {
+			b = &bucket{}
+			buckets[key] = b
+		}
+		b.count++
+		b.r += int(p[0])
+		b.g += int(p[1])
+		b.b += int(p[2])
+		if dominant == nil || b.count > dominant.count {
+			dominant = b
+		}
+	}
+
+	return fmt.Sprintf("#%02x%02x%02x", dominant.r/dominant.count, dominant.g/dominant.count, dominant.b/dominant.count)
+}
+
+const base83 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+func encode83(sb *strings.Builder, value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		sb.WriteByte(base83[value/int(math.Pow(83, float64(i)))%83])
+	}
+}
+
+func srgbToLinear(v uint8) float64 {
+	x := float64(v) / 255
+	if x <= 0.04045 {
+		return x / 12.92
+	}
+	return math.Pow((x+0.055)/1.055, 2.4)
+}
+
+func linearToSrgb(v float64) int {
+	x := max(0, min(1, v))
+	if x <= 0.0031308 {
+		return int(x*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(x, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(v, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(v), exp), v)
+}
+
+// Blurhash returns the https://blurha.sh placeholder of img with 4x3 components
+func Blurhash(img image.Image) string {
+	const cx, cy = 4, 3
+
+	pixels, width, height := samples(img)
+	if len(pixels) == 0 {
+		return ""
+	}
+
+	linear := make([][3]float64, len(pixels))
+	for i, p := range pixels {
+		linear[i] = [3]float64{srgbToLinear(p[0]), srgbToLinear(p[1]), srgbToLinear(p[2])}
+	}
+
+	factors := make([][3]float64, 0, cx*cy)
+	for j := range cy {
+		for i := range cx {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1
+			}
+
+			var f [3]float64
+			for y := range height {
+				for x := range width {
+					basis := math.Cos(math.Pi*float64(i*x)/float64(width)) * math.Cos(math.Pi*float64(j*y)/float64(height))
+					p := linear[y*width+x]
+					f[0] += basis * p[0]
+					f[1] += basis * p[1]
+					f[2] += basis * p[2]
+				}
+			}
+
+			scale := normalisation / float64(width*height)
+			factors = append(factors, [3]float64{f[0] * scale, f[1] * scale, f[2] * scale})
+		}
+	}
+
+	sb := &strings.Builder{}
+	encode83(sb, (cx-1)+(cy-1)*9, 1)
+
+	dc, ac := factors[0], factors[1:]
+	maximum := 0.0
+	for _, f := range ac {
+		maximum = max(maximum, math.Abs(f[0]), math.Abs(f[1]), math.Abs(f[2]))
+	}
+	quantised := max(0, min(82, int(math.Floor(maximum*166-0.5))))
+	maximum = float64(quantised+1) / 166
+	encode83(sb, quantised, 1)
+
+	encode83(sb, linearToSrgb(dc[0])<<16|linearToSrgb(dc[1])<<8|linearToSrgb(dc[2]), 4)
+	for _, f := range ac {
+		quantise := func(v float64) int {
+			return max(0, min(18, int(math.Floor(signPow(v/maximum, 0.5)*9+9.5))))
+		}
+		encode83(sb, quantise(f[0])*19*19+quantise(f[1])*19+quantise(f[2]), 2)
+	}
+
+	return sb.String()
+}
+
+// placeholder sets the placeholders requested by the image tags of v, like `image:"avatar,blurhash=AvatarHash"`,
+// on the string fields they name. Images decoded for other fields are reused.
+func (s *Image) placeholder(v any, key string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		tag, ok := field.Tag.Lookup(key)
+		if !ok || !strings.Contains(tag, "=") {
+			continue
+		}
+
+		spec := structd.ParseTag(tag)
+		for _, name := range spec.Keys() {
+			target := spec.Options[name]
+			compute, ok := placeholders[name]
+			if !ok {
+				continue
+			}
+
+			dst := rv.FieldByName(target)
+			if !dst.IsValid() || !dst.CanSet() {
+				return fmt.Errorf("scanner: %s of %s.%s names an unknown field %q", name, rt.Name(), field.Name, target)
+			}
+			if dst.Kind() != reflect.String {
+				return fmt.Errorf("scanner: %s field %s.%s must be a string", name, rt.Name(), target)
+			}
+
+			source, ok := s.Get(spec.Name).(*imageSource)
+			if !ok {
+				continue
+			}
+			img, err := source.image()
+			if err != nil {
+				return err
+			}
+			dst.SetString(compute(img))
+		}
+	}
+
+	return nil
+}
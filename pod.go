@@ -0,0 +1,117 @@
+package scanner
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/canpacis/scanner/structd"
+)
+
+// PodLabels are the `key="value"` lines of the labels and annotations files of the Kubernetes Downward API
+type PodLabels map[string]string
+
+func (l *PodLabels) UnmarshalString(s string) error {
+	labels := PodLabels{}
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return errors.New("scanner: pod label line has no '='")
+		}
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return err
+		}
+		labels[key] = unquoted
+	}
+
+	*l = labels
+	return nil
+}
+
+// environment is a getter of environment variables
+type environment func(key string) (string, bool)
+
+func (e environment) Get(key string) any {
+	v, ok := e(key)
+	if !ok {
+		return nil
+	}
+	return v
+}
+
+func (e environment) Cast(from any, to reflect.Type) (any, error) {
+	return structd.DefaultCast(from, to)
+}
+
+// podCast casts the files of a pod to types other than strings, trimming the newline of the file
+func podCast(from any, to reflect.Type) (any, error) {
+	b, ok := from.([]byte)
+	if !ok || to.Kind() == reflect.String || (to.Kind() == reflect.Slice && to.Elem().Kind() == reflect.Uint8) {
+		return nil, errors.ErrUnsupported
+	}
+	return structd.DefaultCast(strings.TrimSpace(string(b)), to)
+}
+
+// A scanner to scan the configuration of a Kubernetes pod without client-go. The files of mounted ConfigMap
+// and Downward API volumes are bound with the `file` tag and the environment variables of the pod with
+// the `env` tag:
+//
+//	type Config struct {
+//		Namespace string           `file:"namespace"`
+//		Labels    scanner.PodLabels `file:"labels"`
+//		Replicas  int              `file:"replicas"`
+//		PodIP     string           `env:"POD_IP"`
+//	}
+//
+// File values are trimmed before they are cast to types other than strings.
+type Pod struct {
+	dir    *Directory
+	env    environment
+	opts   []Option
+	config *config
+}
+
+// Scans the files and environment variables of the pod
+func (s *Pod) Scan(v any) error {
+	if err := s.dir.Scan(v); err != nil {
+		return err
+	}
+	return s.config.decoder(s.env, "env").Decode(v)
+}
+
+// Close closes the directory of the pod
+func (s *Pod) Close() error {
+	return s.dir.Close()
+}
+
+// Watch binds the files of the pod onto v, which must be a pointer to a struct, and returns a watcher that
+// re-binds them when Kubernetes updates the mounted ConfigMap
+func (s *Pod) Watch(v any) (*Watcher, error) {
+	return NewWatcher(s.dir.fsys, v, s.opts...)
+}
+
+// NewPod creates a pod scanner of the files in fsys, the mount path of a ConfigMap or Downward API volume,
+// and of the environment variables of the process. The hidden `..data` entries of the volume are never bound
+// and like other scanners that bind many tags, it ignores `scanner.WithTag`.
+func NewPod(fsys fs.FS, opts ...Option) (*Pod, error) {
+	opts = append(withoutTag(opts), WithCaster(podCast))
+	dir, err := NewDirectory(fsys, append(opts[:len(opts):len(opts)], WithExclude("..*"))...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Pod{
+		dir:    dir,
+		env:    os.LookupEnv,
+		opts:   opts,
+		config: newConfig(opts),
+	}, nil
+}
@@ -0,0 +1,131 @@
+package scanner
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ErrNoProtoMessage is returned by `Protobuf.Scan` when neither the destination nor the scanner has a proto message
+var ErrNoProtoMessage = errors.New("scanner: no proto message to unmarshal into")
+
+// A scanner to scan `application/x-protobuf` bodies. Proto messages are unmarshaled directly, plain structs
+// receive the fields of the message the scanner was created with through `scanner.ProtoMessage`.
+type Protobuf struct {
+	r      io.Reader
+	m      proto.Message
+	config *config
+}
+
+// Scans the body onto v
+func (s *Protobuf) Scan(v any) error {
+	b, err := io.ReadAll(s.r)
+	if err != nil {
+		return err
+	}
+
+	if m, ok := v.(proto.Message); ok {
+		return proto.Unmarshal(b, m)
+	}
+	if s.m == nil {
+		return ErrNoProtoMessage
+	}
+	if err := proto.Unmarshal(b, s.m); err != nil {
+		return err
+	}
+	return (&ProtoMessage{m: s.m, config: s.config}).Scan(v)
+}
+
+// NewProtobuf creates a protobuf body scanner, m is the message the body is unmarshaled into when the
+// destination is a plain struct and can be nil when it is always a proto message
+func NewProtobuf(r io.Reader, m proto.Message, opts ...Option) *Protobuf {
+	c := newConfig(opts)
+
+	return &Protobuf{
+		r:      c.reader(r),
+		m:      m,
+		config: c,
+	}
+}
+
+// A scanner to copy the fields of a proto message onto plain structs with the `proto` tag, fields are named by
+// their proto or json names. Enums bind their value names, nested messages nested structs, and the well-known
+// timestamp and duration types `time.Time` and `time.Duration`.
+type ProtoMessage struct {
+	m      proto.Message
+	config *config
+}
+
+// Scans the message onto v
+func (s *ProtoMessage) Scan(v any) error {
+	d := &document{values: protoValues(s.m.ProtoReflect()), key: "proto", config: s.config}
+	return s.config.decoder(d, "proto").Decode(v)
+}
+
+func NewProtoMessage(m proto.Message, opts ...Option) *ProtoMessage {
+	return &ProtoMessage{
+		m:      m,
+		config: newConfig(opts),
+	}
+}
+
+// protoValues converts the populated fields of a message to document values
+func protoValues(m protoreflect.Message) map[string]any {
+	values := map[string]any{}
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		var value any
+		switch {
+		case fd.IsList():
+			list := v.List()
+			entries := make([]any, list.Len())
+			for i := range list.Len() {
+				entries[i] = protoValue(fd, list.Get(i))
+			}
+			value = entries
+		case fd.IsMap():
+			entries := map[string]any{}
+			v.Map().Range(func(key protoreflect.MapKey, v protoreflect.Value) bool {
+				entries[key.String()] = protoValue(fd.MapValue(), v)
+				return true
+			})
+			value = entries
+		default:
+			value = protoValue(fd, v)
+		}
+
+		values[string(fd.Name())] = value
+		values[fd.JSONName()] = value
+		return true
+	})
+
+	return values
+}
+
+// protoValue converts a single value of a field to a document value
+func protoValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) any {
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return int64(v.Enum())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		m := v.Message()
+		switch m.Descriptor().FullName() {
+		case "google.protobuf.Timestamp":
+			fields := m.Descriptor().Fields()
+			return time.Unix(m.Get(fields.ByName("seconds")).Int(), m.Get(fields.ByName("nanos")).Int()).UTC()
+		case "google.protobuf.Duration":
+			fields := m.Descriptor().Fields()
+			return time.Duration(m.Get(fields.ByName("seconds")).Int())*time.Second + time.Duration(m.Get(fields.ByName("nanos")).Int())
+		}
+		return protoValues(m)
+	case protoreflect.BytesKind:
+		return append([]byte(nil), v.Bytes()...)
+	default:
+		return v.Interface()
+	}
+}
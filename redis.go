@@ -0,0 +1,60 @@
+package scanner
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+
+	"github.com/canpacis/scanner/structd"
+)
+
+// A HashResult is the result of a command that returns a hash, like the `*redis.MapStringStringCmd` HGETALL returns in go-redis
+type HashResult interface {
+	Result() (map[string]string, error)
+}
+
+// A scanner to scan the fields of a redis hash onto a struct with the `redis` tag
+type RedisHash struct {
+	values map[string]string
+	config *config
+}
+
+func (h *RedisHash) Get(key string) any {
+	value, ok := h.values[key]
+	if !ok {
+		return nil
+	}
+
+	return value
+}
+
+// Keys returns the fields of the hash
+func (h *RedisHash) Keys() []string {
+	return slices.Sorted(maps.Keys(h.values))
+}
+
+func (h *RedisHash) Cast(from any, to reflect.Type) (any, error) {
+	return structd.DefaultCast(from, to)
+}
+
+// Scans the hash fields onto v
+func (h *RedisHash) Scan(v any) error {
+	return h.config.decoder(h, "redis").Decode(v)
+}
+
+func NewRedisHash(values map[string]string, opts ...Option) *RedisHash {
+	return &RedisHash{
+		values: values,
+		config: newConfig(opts),
+	}
+}
+
+// NewRedisHashResult creates a scanner from the result of a hash command, failing with the error of the command
+func NewRedisHashResult(cmd HashResult, opts ...Option) (*RedisHash, error) {
+	values, err := cmd.Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRedisHash(values, opts...), nil
+}
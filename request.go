@@ -0,0 +1,88 @@
+package scanner
+
+import (
+	"io"
+	"mime"
+	"net/http"
+)
+
+// A scanner to scan an `*http.Request` onto a struct in one call. It binds the `header`, `query`, `path`,
+// `cookie` and `meta` tags, embedded `scanner.RequestMeta` structs, and the body with the `json` or `form` tags
+// depending on its content type. The `form` tag binds the non-file values of multipart bodies.
+type Request struct {
+	*http.Request
+	opts []Option
+}
+
+// defaultMaxMemory is the memory multipart forms are parsed with, like `(*http.Request).FormValue`
+const defaultMaxMemory = 32 << 20
+
+// Scans the request onto v, bodies are decoded with the content codings of their `Content-Encoding` header
+// and transcoded from the charset of their `Content-Type` header
+func (s *Request) Scan(v any) error {
+	query := s.URL.Query()
+	pipe := NewPipe(
+		NewHeader(&s.Header, s.opts...),
+		NewQuery(&query, s.opts...),
+		NewPath(s.Request, s.opts...),
+		NewCookie(s.Cookies(), s.opts...),
+		NewMeta(s.Request, s.opts...),
+	)
+
+	if s.Body != nil && s.Body != http.NoBody {
+		opts := s.opts
+		for _, opt := range []Option{encodingOption(s.Request), charsetOption(s.Request)} {
+			if opt != nil {
+				opts = append(opts[:len(opts):len(opts)], opt)
+			}
+		}
+
+		media, _, _ := mime.ParseMediaType(s.Header.Get("Content-Type"))
+		switch {
+		case isJSON(media):
+			*pipe = append(*pipe, NewJSON(s.Body, opts...))
+		case media == "application/x-www-form-urlencoded":
+			s.decodeBody(opts)
+			if err := s.ParseForm(); err != nil {
+				return err
+			}
+			form, err := newConfig(opts).transcodeValues(s.PostForm)
+			if err != nil {
+				return err
+			}
+			*pipe = append(*pipe, NewForm(form, s.opts...))
+		case media == "multipart/form-data":
+			s.decodeBody(opts)
+			if err := s.ParseMultipartForm(defaultMaxMemory); err != nil {
+				return err
+			}
+			form, err := newConfig(opts).transcodeValues(s.MultipartForm.Value)
+			if err != nil {
+				return err
+			}
+			*pipe = append(*pipe, NewForm(form, s.opts...))
+		}
+	}
+
+	return pipe.Scan(v)
+}
+
+// decodeBody replaces the body of the request with its decoded content, for the form parsers of `http.Request`
+func (s *Request) decodeBody(opts []Option) {
+	c := newConfig(opts)
+	if len(c.encodings) == 0 {
+		return
+	}
+
+	s.Body = struct {
+		io.Reader
+		io.Closer
+	}{c.decompress(c.limit(s.Body)), s.Body}
+}
+
+func NewRequest(req *http.Request, opts ...Option) *Request {
+	return &Request{
+		Request: req,
+		opts:    withoutTag(opts),
+	}
+}
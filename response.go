@@ -0,0 +1,55 @@
+package scanner
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// A scanner to scan an `*http.Response` onto a struct in one call, mirroring the request side scanners.
+// It binds the `header`, `cookie` (from Set-Cookie) and `link` tags, and decodes json bodies.
+type Response struct {
+	*http.Response
+	opts []Option
+}
+
+// isJSON reports whether a content type describes a json document, like `application/json` or `application/problem+json`
+func isJSON(contentType string) bool {
+	media, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return media == "application/json" || strings.HasSuffix(media, "+json")
+}
+
+// Scans the response onto v
+func (s *Response) Scan(v any) error {
+	pipe := NewPipe(
+		NewHeader(&s.Header, s.opts...),
+		NewCookie(s.Cookies(), s.opts...),
+		NewLink(s.Header, s.opts...),
+	)
+	if err := pipe.Scan(v); err != nil {
+		return err
+	}
+
+	if s.Body == nil || s.Body == http.NoBody || !isJSON(s.Header.Get("Content-Type")) {
+		return nil
+	}
+
+	err := NewJSON(s.Body, s.opts...).Scan(v)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
+
+func NewResponse(res *http.Response, opts ...Option) *Response {
+	return &Response{
+		Response: res,
+		opts:     opts,
+	}
+}
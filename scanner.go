@@ -4,14 +4,17 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
-	"image"
 	"io"
 	"io/fs"
+	"maps"
 	"mime/multipart"
 	"net/http"
 	"net/url"
-	"path/filepath"
+	"os"
 	"reflect"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/canpacis/scanner/structd"
 )
@@ -23,38 +26,131 @@ type Scanner interface {
 
 // A scanner to scan json value from an `io.Reader` to a struct
 type JSON struct {
-	r io.Reader
+	r      io.Reader
+	body   []byte
+	config *config
 }
 
-// Scans the json onto v
+// Scans the json onto v, with `scanner.WithRawBody` the raw bytes are bound to `raw:"body"` fields. The keys of
+// the object of a field tagged with the `flatten` option, like `Meta struct{} json:"meta,flatten"`, are also
+// matched against the fields of v, and a map field tagged `json:",remain"` gathers the keys no other field binds.
+// With `scanner.WithSchema` the body is validated against the schema first.
 func (s *JSON) Scan(v any) error {
-	return json.NewDecoder(s.r).Decode(v)
+	return s.config.observed("json", v, func(c *config) error {
+		return s.scan(c, v)
+	})
 }
 
-func NewJSON(r io.Reader) *JSON {
-	return &JSON{
-		r: r,
+func (s *JSON) scan(c *config, v any) error {
+	if !s.config.rawBody && !s.config.replay && s.config.schema == nil {
+		return s.decode(s.r, v)
+	}
+
+	b, err := s.read()
+	if err != nil {
+		return err
+	}
+	if s.config.schema != nil {
+		if err := s.validate(b, v); err != nil {
+			return err
+		}
+	}
+	if err := s.decode(bytes.NewReader(b), v); err != nil {
+		return err
+	}
+	if !s.config.rawBody {
+		return nil
+	}
+	return c.decoder(rawBody(b), "raw").Decode(v)
+}
+
+// read reads the body, it is kept to be replayed on later scans with `scanner.WithReplay`
+func (s *JSON) read() ([]byte, error) {
+	if s.body != nil {
+		return s.body, nil
+	}
+
+	b, err := io.ReadAll(s.r)
+	if err != nil {
+		return nil, err
+	}
+	if s.config.replay {
+		s.body = b
+	}
+	return b, nil
+}
+
+func (s *JSON) decode(r io.Reader, v any) error {
+	if l := jsonLayoutOf(v); l != nil {
+		return s.decodeFlat(r, v, l)
+	}
+
+	d := json.NewDecoder(r)
+	if s.config.strict {
+		d.DisallowUnknownFields()
+	}
+	return d.Decode(v)
+}
+
+// rawBody binds the raw bytes of a body to the `body` key
+type rawBody []byte
+
+func (b rawBody) Get(key string) any {
+	if key != "body" {
+		return nil
+	}
+	return []byte(b)
+}
+
+func (b rawBody) Cast(from any, to reflect.Type) (any, error) {
+	if raw, ok := from.([]byte); ok && to.Kind() == reflect.String {
+		return string(raw), nil
 	}
+	return nil, errors.ErrUnsupported
 }
 
-func NewJSONBytes(b []byte) *JSON {
+func NewJSON(r io.Reader, opts ...Option) *JSON {
+	c := newConfig(opts)
+
 	return &JSON{
-		r: bytes.NewBuffer(b),
+		r:      c.reader(r),
+		config: c,
 	}
 }
 
+func NewJSONBytes(b []byte, opts ...Option) *JSON {
+	return NewJSON(bytes.NewBuffer(b), opts...)
+}
+
 // A scanner to scan os file's content to a struct
 type Directory struct {
-	files map[string]io.Reader
+	fsys fs.FS
+	// files are the members of archives, which are read up front
+	files  map[string]io.Reader
+	read   map[string][]byte
+	loaded map[string][]byte
+	config *config
 }
 
 func (s *Directory) Get(key string) any {
+	if b, ok := s.loaded[key]; ok {
+		return b
+	}
+	if b, ok := s.read[key]; ok {
+		return b
+	}
 	file, ok := s.files[key]
 	if !ok {
-		return []byte{}
+		return nil
 	}
 
 	b, _ := io.ReadAll(file)
+	if s.config.replay {
+		if s.read == nil {
+			s.read = map[string][]byte{}
+		}
+		s.read[key] = b
+	}
 	return b
 }
 
@@ -70,75 +166,209 @@ func (s *Directory) Cast(from any, to reflect.Type) (any, error) {
 	return nil, errors.ErrUnsupported
 }
 
+// Scans the files the tags of v name, files of the file system are opened during the scan and closed
+// once they are read, so every scan sees their current content
 func (s *Directory) Scan(v any) error {
-	return structd.New(s, "file").Decode(v)
+	key := "file"
+	if s.config.tag != "" {
+		key = s.config.tag
+	}
+	if s.fsys != nil {
+		defer clear(s.loaded)
+		if err := s.load(v, key); err != nil {
+			return err
+		}
+	}
+	return s.config.decoder(s, "file").Decode(v)
 }
 
-func NewDirectory(fsys fs.FS) (*Directory, error) {
-	entries, err := fs.ReadDir(fsys, ".")
-	if err != nil {
-		return nil, err
+// Close closes the files of the directory, it is safe to call more than once
+func (s *Directory) Close() error {
+	errs := []error{}
+	for _, key := range slices.Sorted(maps.Keys(s.files)) {
+		if closer, ok := s.files[key].(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+		delete(s.files, key)
 	}
 
-	files := map[string]io.Reader{}
-	for _, entry := range entries {
-		file, err := fsys.Open(filepath.Join(".", entry.Name()))
+	return errors.Join(errs...)
+}
+
+// NewDirectory creates a directory scanner of the files in fsys, files in subdirectories are keyed by their
+// slash separated path like `file:"assets/logo.png"`. Files are only opened when a field binds them,
+// `scanner.WithRoot`, `scanner.WithInclude`, `scanner.WithExclude`, `scanner.WithMaxFileSize` and
+// `scanner.WithMaxFiles` restrict the files it binds.
+func NewDirectory(fsys fs.FS, opts ...Option) (*Directory, error) {
+	c := newConfig(opts)
+	if err := c.directory.validate(); err != nil {
+		return nil, err
+	}
+	if c.directory.root != "" {
+		sub, err := fs.Sub(fsys, c.directory.root)
 		if err != nil {
 			return nil, err
 		}
-		files[entry.Name()] = file
+		fsys = sub
 	}
 
-	return &Directory{files: files}, nil
+	return &Directory{fsys: fsys, loaded: map[string][]byte{}, config: c}, nil
 }
 
 // A scanner to scan header values from an `http.Header` to a struct
 type Header struct {
 	*http.Header
+	config *config
 }
 
 func (h *Header) Get(key string) any {
-	return h.Header.Get(key)
+	values := h.values(key)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+// GetAll returns every value of a header, splitting comma separated list headers into their elements as in RFC 9110
+func (h *Header) GetAll(key string) []string {
+	values := h.values(key)
+	if http.CanonicalHeaderKey(key) == "Set-Cookie" {
+		return values
+	}
+
+	result := []string{}
+	for _, value := range values {
+		result = append(result, splitList(value)...)
+	}
+
+	return result
+}
+
+// Keys returns the names of the headers
+func (h *Header) Keys() []string {
+	return slices.Sorted(maps.Keys(*h.Header))
+}
+
+// MatchKey matches header names case-insensitively
+func (h *Header) MatchKey(name, key string) bool {
+	return strings.EqualFold(name, key)
+}
+
+// values looks up the values of a header by its canonical key, falling back to a case-insensitive
+// search for headers that were set without canonicalization
+func (h *Header) values(key string) []string {
+	if values := h.Header.Values(key); len(values) > 0 {
+		return values
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(*h.Header)) {
+		if strings.EqualFold(k, key) {
+			return (*h.Header)[k]
+		}
+	}
+
+	return nil
+}
+
+// Cast trims the whitespace around header values and casts them with the default cast, the structured
+// field booleans `?1` and `?0` are read as true and false
+func (h *Header) Cast(from any, to reflect.Type) (any, error) {
+	s, ok := from.(string)
+	if !ok {
+		return nil, errors.ErrUnsupported
+	}
+
+	s = strings.TrimSpace(s)
+	if to.Kind() == reflect.Bool && (s == "?1" || s == "?0") {
+		return reflect.ValueOf(s == "?1").Convert(to).Interface(), nil
+	}
+	return structd.DefaultCast(s, to)
 }
 
-// Scans the headers onto v
+// Scans the headers onto v, time fields also accept the date formats of http
 func (s *Header) Scan(v any) error {
-	return structd.New(s, "header").Decode(v)
+	config := *s.config
+	config.decoderOptions = append(config.decoderOptions[:len(config.decoderOptions):len(config.decoderOptions)], structd.WithTimeLayouts(http.TimeFormat, time.RFC850, time.ANSIC))
+	return config.decoder(s, "header").Decode(v)
 }
 
-func NewHeader(h *http.Header) *Header {
+func NewHeader(h *http.Header, opts ...Option) *Header {
 	return &Header{
 		Header: h,
+		config: newConfig(opts),
 	}
 }
 
 // A scanner to scan url query values from a `*url.Values` to a struct
 type Query struct {
 	*url.Values
+	config *config
 }
 
 func (v Query) Get(key string) any {
 	return v.Values.Get(key)
 }
 
+// Keys returns the names of the query values
+func (v Query) Keys() []string {
+	return slices.Sorted(maps.Keys(*v.Values))
+}
+
 func (v Query) Cast(from any, to reflect.Type) (any, error) {
+	if v, err := ImageCast(from, to); !errors.Is(err, errors.ErrUnsupported) {
+		return v, err
+	}
 	return structd.DefaultCast(from, to)
 }
 
 // Scans the query values onto v
 func (s *Query) Scan(v any) error {
-	return structd.New(s, "query").Decode(v)
+	return s.config.decoder(s, "query").Decode(v)
+}
+
+// Values are the sources of url values the query and form scanners accept
+type Values interface {
+	*url.Values | url.Values | map[string][]string
+}
+
+// toValues converts any Values to a *url.Values without copying
+func toValues[V Values](v V) *url.Values {
+	switch v := any(v).(type) {
+	case *url.Values:
+		return v
+	case url.Values:
+		return &v
+	case map[string][]string:
+		values := url.Values(v)
+		return &values
+	default:
+		return &url.Values{}
+	}
 }
 
-func NewQuery(v *url.Values) *Query {
+func NewQuery[V Values](v V, opts ...Option) *Query {
 	return &Query{
-		Values: v,
+		Values: toValues(v),
+		config: newConfig(opts),
 	}
 }
 
+// NewQueryString creates a query scanner of a raw query string like `a=1&b=2`, `scanner.WithLiteralPlus`
+// and `scanner.WithLenientEscapes` change how it is decoded
+func NewQueryString(query string, opts ...Option) (*Query, error) {
+	values, err := newConfig(opts).parseQuery(strings.TrimPrefix(query, "?"))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewQuery(values, opts...), nil
+}
+
 // A scanner to scan http cookies for a url from a `http.CookieJar` to a struct
 type Cookie struct {
 	cookies []*http.Cookie
+	config  *config
 }
 
 func (v Cookie) Get(key string) any {
@@ -151,44 +381,110 @@ func (v Cookie) Get(key string) any {
 	return nil
 }
 
-// Scans the cookie values onto v
+// Keys returns the names of the cookies, in the order they are given
+func (v Cookie) Keys() []string {
+	keys := []string{}
+	for _, cookie := range v.cookies {
+		if !slices.Contains(keys, cookie.Name) {
+			keys = append(keys, cookie.Name)
+		}
+	}
+
+	return keys
+}
+
+func (v Cookie) Cast(from any, to reflect.Type) (any, error) {
+	if cookie, ok := from.(*http.Cookie); ok && to == cookieType {
+		return *cookie, nil
+	}
+	return structd.DefaultCast(from, to)
+}
+
+// Scans the cookie values onto v. A `*http.Cookie` or `http.Cookie` field receives the whole cookie with its
+// attributes, like the expiry of the cookies of a response.
 func (s *Cookie) Scan(v any) error {
-	return structd.New(s, "cookie").Decode(v)
+	config := *s.config
+	key := "cookie"
+	if config.tag != "" {
+		key = config.tag
+	}
+	config.decoderOptions = append(config.decoderOptions[:len(config.decoderOptions):len(config.decoderOptions)], structd.WithBeforeField(s.withCookies(key)))
+
+	if config.cookieCodec == nil {
+		return config.decoder(s, "cookie").Decode(v)
+	}
+
+	g := &codedCookies{Cookie: s, codec: config.cookieCodec}
+	err := config.decoder(g, "cookie").Decode(v)
+	if g.err != nil {
+		return g.err
+	}
+	return err
 }
 
-func NewCookie(cookies []*http.Cookie) *Cookie {
+func NewCookie(cookies []*http.Cookie, opts ...Option) *Cookie {
 	return &Cookie{
 		cookies: cookies,
+		config:  newConfig(opts),
 	}
 }
 
 // A scanner to scan form values from a `*url.Values` to a struct
 type Form struct {
 	*url.Values
+	config *config
 }
 
 func (v Form) Get(key string) any {
 	return v.Values.Get(key)
 }
 
+// Keys returns the names of the form values
+func (v Form) Keys() []string {
+	return slices.Sorted(maps.Keys(*v.Values))
+}
+
+// MatchKey matches the indexed keys of a name, like `items[0].name` of `items`, to it
+func (v Form) MatchKey(name, key string) bool {
+	if name == key {
+		return true
+	}
+	_, _, ok := indexed(name, key)
+	return ok
+}
+
 func (v Form) Cast(from any, to reflect.Type) (any, error) {
+	if v, err := ImageCast(from, to); !errors.Is(err, errors.ErrUnsupported) {
+		return v, err
+	}
 	return structd.DefaultCast(from, to)
 }
 
-// Scans the form data onto v
+// Scans the form data onto v. Slices of structs bind indexed keys, like `items[0].name=a&items[1].name=b`
+// or `items[0][name]=a`, to their elements.
 func (s *Form) Scan(v any) error {
-	return structd.New(s, "form").Decode(v)
+	config := *s.config
+	key := "form"
+	if config.tag != "" {
+		key = config.tag
+	}
+	if hasIndexes(v, key) {
+		config.decoderOptions = append(config.decoderOptions[:len(config.decoderOptions):len(config.decoderOptions)], structd.WithBeforeField(s.withIndexes(key)))
+	}
+	return config.decoder(s, "form").Decode(v)
 }
 
-func NewForm(v *url.Values) *Form {
+func NewForm[V Values](v V, opts ...Option) *Form {
 	return &Form{
-		Values: v,
+		Values: toValues(v),
+		config: newConfig(opts),
 	}
 }
 
 // A scanner to scan path parameters from a `*http.Request` to a struct
 type Path struct {
 	*http.Request
+	config *config
 }
 
 func (v Path) Get(key string) any {
@@ -196,28 +492,62 @@ func (v Path) Get(key string) any {
 }
 
 func (v Path) Cast(from any, to reflect.Type) (any, error) {
+	if v, err := ImageCast(from, to); !errors.Is(err, errors.ErrUnsupported) {
+		return v, err
+	}
 	return structd.DefaultCast(from, to)
 }
 
 // Scans the path parameters onto v
 func (s *Path) Scan(v any) error {
-	return structd.New(s, "path").Decode(v)
+	return s.config.decoder(s, "path").Decode(v)
 }
 
-func NewPath(req *http.Request) *Path {
+func NewPath(req *http.Request, opts ...Option) *Path {
 	return &Path{
 		Request: req,
+		config:  newConfig(opts),
 	}
 }
 
 type MultipartValues struct {
 	Files map[string]multipart.File
+	// Headers are the headers of the files, when they are known
+	Headers map[string]*multipart.FileHeader
+	// Values are the non-file values of the form
+	Values url.Values
+	form   *multipart.Form
+	temp   []string
+}
+
+// Close closes the files and removes the temporary files of the parsed form, it is safe to call more than once
+func (v *MultipartValues) Close() error {
+	errs := []error{}
+	for _, key := range slices.Sorted(maps.Keys(v.Files)) {
+		errs = append(errs, v.Files[key].Close())
+		delete(v.Files, key)
+	}
+	if v.form != nil {
+		errs = append(errs, v.form.RemoveAll())
+		v.form = nil
+	}
+	for _, name := range v.temp {
+		errs = append(errs, os.Remove(name))
+	}
+	v.temp = nil
+
+	return errors.Join(errs...)
 }
 
 func (v MultipartValues) Get(key string) any {
 	return v.Files[key]
 }
 
+// Keys returns the names of the files
+func (v MultipartValues) Keys() []string {
+	return slices.Sorted(maps.Keys(v.Files))
+}
+
 type MultipartParser interface {
 	ParseMultipartForm(int64) error
 	FormFile(string) (multipart.File, *multipart.FileHeader, error)
@@ -231,37 +561,250 @@ func MultipartValuesFromParser(p MultipartParser, size int64, names ...string) (
 	}
 
 	files := map[string]multipart.File{}
+	headers := map[string]*multipart.FileHeader{}
 
 	for _, name := range names {
-		file, _, err := p.FormFile(name)
+		file, header, err := p.FormFile(name)
+		if err != nil {
+			return nil, err
+		}
+		files[name] = file
+		headers[name] = header
+	}
+
+	values := &MultipartValues{Files: files, Headers: headers, Values: url.Values{}}
+	if req, ok := p.(*http.Request); ok && req.MultipartForm != nil {
+		values.form = req.MultipartForm
+		values.Values = req.MultipartForm.Value
+	}
+	return values, nil
+}
+
+// MultipartValuesFromForm opens the first file of every field of a parsed multipart form, such as the
+// form of `(*fasthttp.RequestCtx).MultipartForm`, and returns them as `*scanner.MultipartValues`
+func MultipartValuesFromForm(form *multipart.Form) (*MultipartValues, error) {
+	files := map[string]multipart.File{}
+	headers := map[string]*multipart.FileHeader{}
+
+	for name, fileHeaders := range form.File {
+		if len(fileHeaders) == 0 {
+			continue
+		}
+
+		file, err := fileHeaders[0].Open()
 		if err != nil {
 			return nil, err
 		}
 		files[name] = file
+		headers[name] = fileHeaders[0]
+	}
+
+	return &MultipartValues{Files: files, Headers: headers, Values: form.Value, form: form}, nil
+}
+
+// memoryFile is a multipart.File of a part kept in memory
+type memoryFile struct {
+	*bytes.Reader
+}
+
+func (memoryFile) Close() error {
+	return nil
+}
+
+// MultipartValuesFromReader reads the files and values of a streamed multipart body, such as the reader of
+// `(*http.Request).MultipartReader`. Parts up to threshold bytes are kept in memory and larger ones are
+// spilled to temporary files, which are removed when the values are closed. Values are always kept in memory,
+// so like `(*http.Request).ParseMultipartForm` they are limited to threshold plus 10 MB in total.
+// `scanner.WithMaxBytes` limits the total size of the parts.
+func MultipartValuesFromReader(r *multipart.Reader, threshold int64, opts ...Option) (*MultipartValues, error) {
+	c := newConfig(opts)
+	values := &MultipartValues{Files: map[string]multipart.File{}, Headers: map[string]*multipart.FileHeader{}, Values: url.Values{}}
+	limited := &limitedReader{n: c.maxBytes, limit: c.maxBytes}
+	maxValueBytes := threshold + 10<<20
+	valueBytes := &limitedReader{n: maxValueBytes, limit: maxValueBytes}
+
+	for {
+		part, err := r.NextPart()
+		if err == io.EOF {
+			return values, nil
+		}
+		if err != nil {
+			values.Close()
+			return nil, err
+		}
+
+		var src io.Reader = part
+		if c.maxBytes > 0 {
+			limited.r = part
+			src = limited
+		}
+
+		name := part.FormName()
+		switch _, ok := values.Files[name]; {
+		case part.FileName() == "":
+			valueBytes.r = src
+			b, readErr := io.ReadAll(valueBytes)
+			values.Values.Add(name, string(b))
+			err = readErr
+		case ok:
+			_, err = io.Copy(io.Discard, src)
+		default:
+			err = values.read(name, src, threshold)
+			if err == nil {
+				values.Headers[name] = &multipart.FileHeader{Filename: part.FileName(), Header: part.Header, Size: values.size(name)}
+			}
+		}
+		part.Close()
+		if err != nil {
+			values.Close()
+			return nil, err
+		}
 	}
+}
+
+// read reads a part into memory, or into a temporary file when it is larger than threshold
+func (v *MultipartValues) read(name string, r io.Reader, threshold int64) error {
+	buf := &bytes.Buffer{}
+	n, err := io.CopyN(buf, r, threshold+1)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if n <= threshold {
+		v.Files[name] = memoryFile{bytes.NewReader(buf.Bytes())}
+		return nil
+	}
+
+	file, err := os.CreateTemp("", "scanner-multipart-")
+	if err != nil {
+		return err
+	}
+	v.temp = append(v.temp, file.Name())
+	v.Files[name] = file
 
-	return &MultipartValues{Files: files}, nil
+	if _, err := io.Copy(file, io.MultiReader(buf, r)); err != nil {
+		return err
+	}
+	_, err = file.Seek(0, io.SeekStart)
+	return err
+}
+
+// size returns the size of a file read by read
+func (v *MultipartValues) size(name string) int64 {
+	switch file := v.Files[name].(type) {
+	case memoryFile:
+		return file.Size()
+	case *os.File:
+		info, err := file.Stat()
+		if err == nil {
+			return info.Size()
+		}
+	}
+	return 0
 }
 
-// A scanner to scan multipart form values, files, from a `*scanner.MultipartValues` to a struct
+// A scanner to scan multipart form values, files, from a `*scanner.MultipartValues` to a struct.
+// Files are bound with the `multipart` tag and the other values of the form with the `form` tag. `[]byte` and
+// `string` fields receive the content of a file, limited by `scanner.WithMaxBytes`.
+// The `md5=` and `sha256=` options set the digest of a file on another field, like `multipart:"doc,sha256=DocHash"`.
 // You can create a `*scanner.MultipartValues` instance with the `scanner.MultipartValuesFromParser` function.
 type Multipart struct {
-	v *MultipartValues
+	v         *MultipartValues
+	inspected bool
+	observed  bool
+	config    *config
 }
 
 // Scans the multipart form data onto v
 func (s *Multipart) Scan(v any) error {
-	return structd.New(s.v, "multipart").Decode(v)
+	return s.config.observed("multipart", v, func(c *config) error {
+		return s.scan(c, v)
+	})
+}
+
+func (s *Multipart) scan(c *config, v any) error {
+	if s.config.replay {
+		if err := rewind(s.v.Files); err != nil {
+			return err
+		}
+	}
+	key := "multipart"
+	if s.config.tag != "" {
+		key = s.config.tag
+	}
+	if err := s.inspect(); err != nil {
+		return err
+	}
+	if !s.observed {
+		s.config.observeUploads("multipart", s.v)
+		s.observed = true
+	}
+	if err := s.checksum(v, key); err != nil {
+		return err
+	}
+	if err := c.decoder(multipartFiles{MultipartValues: s.v, config: c}, "multipart").Decode(v); err != nil {
+		return err
+	}
+	if len(s.v.Values) == 0 {
+		return nil
+	}
+
+	// the tag override applies to the files only, values bind like the values of a form including their indexes
+	form := *c
+	form.tag = ""
+	return (&Form{Values: &s.v.Values, config: &form}).Scan(v)
+}
+
+// multipartFiles casts the files of multipart values to the contents of their `[]byte` and `string` fields
+type multipartFiles struct {
+	*MultipartValues
+	config *config
+}
+
+func (f multipartFiles) Cast(from any, to reflect.Type) (any, error) {
+	file, ok := from.(multipart.File)
+	if !ok || (to.Kind() != reflect.String && (to.Kind() != reflect.Slice || to.Elem().Kind() != reflect.Uint8)) {
+		return nil, errors.ErrUnsupported
+	}
+
+	b, err := io.ReadAll(f.config.limit(file))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return reflect.ValueOf(b).Convert(to).Interface(), nil
 }
 
-func NewMultipart(v *MultipartValues) *Multipart {
+// rewind seeks the files back to their start so they can be read again
+func rewind(files map[string]multipart.File) error {
+	for _, file := range files {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the multipart values of the scanner
+func (s *Multipart) Close() error {
+	return s.v.Close()
+}
+
+func NewMultipart(v *MultipartValues, opts ...Option) *Multipart {
 	return &Multipart{
-		v: v,
+		v:      v,
+		config: newConfig(opts),
 	}
 }
 
 type Image struct {
-	Files map[string]multipart.File
+	Files     map[string]multipart.File
+	values    *MultipartValues
+	sources   map[string]*imageSource
+	inspected bool
+	observed  bool
+	config    *config
 }
 
 func (v Image) Get(key string) any {
@@ -270,18 +813,60 @@ func (v Image) Get(key string) any {
 		return nil
 	}
 
-	img, _, _ := image.Decode(file)
-	return img
+	if v.sources[key] == nil {
+		v.sources[key] = &imageSource{file: file}
+	}
+	return v.sources[key]
 }
 
-// Scans the multipart form data and turns them into image.Image and sets v
+// Scans the multipart form data and turns them into image.Image and sets v. A `*gif.GIF` field receives
+// every frame of an animation, a `scanner.ImageInfo` field its format, dimensions and frames and a
+// `scanner.ImageOutput` field the image encoded again in the format its tag requests. The `blurhash=` and
+// `color=` tag options set the blurhash and dominant color of an image on the string fields they name.
 func (s *Image) Scan(v any) error {
-	return structd.New(s, "image").Decode(v)
+	return s.config.observed("image", v, func(c *config) error {
+		return s.scan(c, v)
+	})
 }
 
-func NewImage(v *MultipartValues) *Image {
+func (s *Image) scan(c *config, v any) error {
+	if s.config.replay {
+		if err := rewind(s.Files); err != nil {
+			return err
+		}
+	}
+	if err := s.inspect(); err != nil {
+		return err
+	}
+	if !s.observed {
+		s.config.observeUploads("image", s.values)
+		s.observed = true
+	}
+	clear(s.sources)
+
+	config := *c
+	key := "image"
+	if config.tag != "" {
+		key = config.tag
+	}
+	config.decoderOptions = append(config.decoderOptions[:len(config.decoderOptions):len(config.decoderOptions)], structd.WithBeforeField(withImageOutput(key)))
+	if err := config.decoder(s, key).Decode(v); err != nil {
+		return err
+	}
+	return s.placeholder(v, key)
+}
+
+// Close closes the multipart values of the scanner
+func (s *Image) Close() error {
+	return s.values.Close()
+}
+
+func NewImage(v *MultipartValues, opts ...Option) *Image {
 	return &Image{
-		Files: v.Files,
+		Files:   v.Files,
+		values:  v,
+		sources: map[string]*imageSource{},
+		config:  newConfig(opts),
 	}
 }
 
@@ -300,7 +885,83 @@ func (s *Pipe) Scan(v any) error {
 	return nil
 }
 
+// Degraded returns the errors of the optional stages that failed during the last scan
+func (s *Pipe) Degraded() []error {
+	errs := []error{}
+
+	for _, scanner := range *s {
+		o, ok := scanner.(*OptionalScanner)
+		if ok && o.err != nil {
+			errs = append(errs, o.err)
+		}
+	}
+
+	return errs
+}
+
+// Close closes every scanner of the pipe that is an `io.Closer`
+func (s *Pipe) Close() error {
+	errs := []error{}
+	for _, scanner := range *s {
+		if closer, ok := scanner.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
 func NewPipe(scanners ...Scanner) *Pipe {
 	s := Pipe(scanners)
 	return &s
 }
+
+// A scanner that tolerates the failures of the scanner it wraps, usually used as a `scanner.Pipe` stage
+// for sources that are allowed to be unavailable.
+type OptionalScanner struct {
+	Scanner
+	err    error
+	config *config
+}
+
+// Scans onto a copy of v and applies it only when the wrapped scanner succeeds.
+// A failure is logged as a warning and leaves v untouched.
+func (s *OptionalScanner) Scan(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return s.Scanner.Scan(v)
+	}
+
+	cp := reflect.New(rv.Elem().Type())
+	cp.Elem().Set(rv.Elem())
+
+	if err := s.Scanner.Scan(cp.Interface()); err != nil {
+		s.err = err
+		s.config.log().Warn("scanner: optional source failed", "scanner", reflect.TypeOf(s.Scanner).String(), "error", err)
+		return nil
+	}
+
+	s.err = nil
+	rv.Elem().Set(cp.Elem())
+	return nil
+}
+
+// Err returns the error of the last failed scan, or nil if it succeeded
+func (s *OptionalScanner) Err() error {
+	return s.err
+}
+
+// Close closes the wrapped scanner if it is an `io.Closer`
+func (s *OptionalScanner) Close() error {
+	if closer, ok := s.Scanner.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func NewOptional(s Scanner, opts ...Option) *OptionalScanner {
+	return &OptionalScanner{
+		Scanner: s,
+		config:  newConfig(opts),
+	}
+}
@@ -1,21 +1,60 @@
 package scanner_test
 
 import (
+	"archive/tar"
+	"archive/zip"
 	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/hmac"
 	"crypto/md5"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"image"
+	"image/color"
 	"image/draw"
+	"image/gif"
+	_ "image/jpeg"
 	"image/png"
 	"io"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"math"
 	"mime/multipart"
 	"net/http"
 	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/netip"
 	"net/url"
+	"os"
+	"path"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/canpacis/scanner"
+	"github.com/canpacis/scanner/structd"
+	"github.com/canpacis/scanner/uritemplate"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/apipb"
+	"google.golang.org/protobuf/types/known/sourcecontextpb"
+	"google.golang.org/protobuf/types/known/typepb"
 )
 
 type Role struct {
@@ -275,3 +314,4161 @@ func TestDirectoryScanner(t *testing.T) {
 	}
 	c.Run(t)
 }
+
+func TestOptionalScanner(t *testing.T) {
+	assert := assert.New(t)
+
+	header := &http.Header{}
+	header.Set("Accept-Language", "en")
+
+	p := &Params{Email: "default@example.com"}
+	s := scanner.NewPipe(
+		scanner.NewOptional(scanner.NewJSONBytes([]byte(`{ "email": "partial@example.com", `))),
+		scanner.NewHeader(header),
+	)
+
+	assert.NoError(s.Scan(p))
+	assert.Equal("default@example.com", p.Email)
+	assert.Equal("en", p.Language)
+	assert.Len(s.Degraded(), 1)
+}
+
+func TestConstraints(t *testing.T) {
+	assert := assert.New(t)
+
+	type Constrained struct {
+		Page  int      `query:"page,min=1,max=10"`
+		Name  string   `query:"name,minlen=2,maxlen=4"`
+		Roles []string `query:"roles,maxlen=2"`
+	}
+
+	values := &url.Values{}
+	values.Set("page", "4")
+	values.Set("name", "John")
+	values.Set("roles", "admin,user")
+	p := &Constrained{}
+	assert.NoError(scanner.NewQuery(values).Scan(p))
+	assert.Equal(4, p.Page)
+
+	var ferr *structd.FieldError
+	var cerr *structd.ConstraintError
+
+	values.Set("page", "11")
+	err := scanner.NewQuery(values).Scan(&Constrained{})
+	assert.ErrorAs(err, &ferr)
+	assert.ErrorAs(err, &cerr)
+	assert.Equal("Page", ferr.Field)
+	assert.Equal("max", cerr.Constraint)
+
+	values.Set("page", "1")
+	values.Set("name", "J")
+	err = scanner.NewQuery(values).Scan(&Constrained{})
+	assert.ErrorAs(err, &cerr)
+	assert.Equal("minlen", cerr.Constraint)
+
+	values.Set("name", "Jo")
+	values.Set("roles", "admin,user,guest")
+	err = scanner.NewQuery(values).Scan(&Constrained{})
+	assert.ErrorAs(err, &cerr)
+	assert.Equal("maxlen", cerr.Constraint)
+}
+
+func TestWatcher(t *testing.T) {
+	assert := assert.New(t)
+
+	type Config struct {
+		Name  string `file:"name.txt"`
+		Token string `file:"token.txt"`
+	}
+
+	fsys := FS{
+		Files: map[string]*File{
+			"name.txt":  NewFile("name.txt", []byte("service")),
+			"token.txt": NewFile("token.txt", []byte("secret")),
+		},
+	}
+
+	c := &Config{}
+	w, err := scanner.NewWatcher(fsys, c)
+	assert.NoError(err)
+	assert.Equal("service", c.Name)
+	assert.Equal("secret", c.Token)
+
+	notified := [][]string{}
+	w.Subscribe(func(changed []string) {
+		notified = append(notified, changed)
+	})
+
+	fsys.Files["name.txt"] = NewFile("name.txt", []byte("service"))
+	fsys.Files["token.txt"] = NewFile("token.txt", []byte("rotated"))
+	changed, err := w.Poll()
+	assert.NoError(err)
+	assert.Equal([]string{"Token"}, changed)
+	assert.Equal("rotated", c.Token)
+	assert.Equal("service", c.Name)
+
+	delete(fsys.Files, "name.txt")
+	changed, err = w.Poll()
+	assert.NoError(err)
+	assert.Equal([]string{"Name"}, changed)
+	assert.Equal("", c.Name)
+	assert.Equal([][]string{{"Token"}, {"Name"}}, notified)
+
+	replayed := &Config{}
+	_, err = scanner.NewWatcher(fsys, replayed, scanner.WithReplay())
+	assert.NoError(err)
+	assert.Equal("rotated", replayed.Token)
+	type Tagged struct {
+		Token string `cfg:"token.txt"`
+	}
+	tagged := &Tagged{}
+	_, err = scanner.NewWatcher(fsys, tagged, scanner.WithTag("cfg"))
+	assert.NoError(err)
+	assert.Equal("rotated", tagged.Token)
+	// files that fail to bind are retried until they do
+	type Server struct {
+		Port int `file:"port"`
+	}
+	fsys.Files["port"] = NewFile("port", []byte("8080"))
+	server := &Server{}
+	w, err = scanner.NewWatcher(fsys, server)
+	assert.NoError(err)
+	fsys.Files["port"] = NewFile("port", []byte("80a"))
+	_, err = w.Poll()
+	assert.Error(err)
+	_, err = w.Poll()
+	assert.Error(err)
+	fsys.Files["port"] = NewFile("port", []byte("80"))
+	changed, err = w.Poll()
+	assert.NoError(err)
+	assert.Equal([]string{"Port"}, changed)
+	assert.Equal(80, server.Port)
+	assert.Equal(&Server{Port: 80}, w.Load())
+
+	// polls swap a copy of the struct, so it can be loaded while they run
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 50 {
+			fsys.Files["port"] = NewFile("port", []byte(strconv.Itoa(1000+i)))
+			w.Poll()
+		}
+	}()
+	for range 50 {
+		assert.GreaterOrEqual(w.Load().(*Server).Port, 80)
+	}
+	<-done
+	assert.Equal(1049, w.Load().(*Server).Port)
+}
+
+func TestSanitizers(t *testing.T) {
+	assert := assert.New(t)
+
+	structd.RegisterSanitizer("slug", func(s string) string {
+		return strings.ReplaceAll(s, " ", "-")
+	})
+
+	type Sanitized struct {
+		Email string `form:"email,trim,lower"`
+		Code  string `form:"code,upper"`
+		Name  string `form:"name,nfkc"`
+		Slug  string `form:"slug,trim,lower,slug"`
+		Blank string `form:"blank,trim"`
+	}
+
+	form := &url.Values{}
+	form.Set("email", "  John@Example.COM ")
+	form.Set("code", "tr")
+	form.Set("name", "ﬁle")
+	form.Set("slug", " Hello World ")
+	form.Set("blank", "   ")
+
+	p := &Sanitized{Blank: "default"}
+	assert.NoError(scanner.NewForm(form).Scan(p))
+	assert.Equal("john@example.com", p.Email)
+	assert.Equal("TR", p.Code)
+	assert.Equal("file", p.Name)
+	assert.Equal("hello-world", p.Slug)
+	assert.Equal("default", p.Blank)
+}
+
+type mapGetter map[string]any
+
+func (m mapGetter) Get(key string) any {
+	return m[key]
+}
+
+func TestDecodeHooks(t *testing.T) {
+	assert := assert.New(t)
+
+	type Hooked struct {
+		Token string `src:"token"`
+		Name  string `src:"name"`
+	}
+
+	seen := []string{}
+	decoder := structd.New(mapGetter{"token": "abc", "name": "john"}, "src",
+		structd.WithBeforeField(func(field reflect.StructField, raw any) (any, error) {
+			seen = append(seen, field.Name)
+			if field.Name == "Token" {
+				return nil, nil
+			}
+			return strings.ToUpper(raw.(string)), nil
+		}),
+		structd.WithAfterDecode(func(v any) error {
+			if v.(*Hooked).Name == "" {
+				return errors.New("name is required")
+			}
+			return nil
+		}),
+	)
+
+	p := &Hooked{}
+	assert.NoError(decoder.Decode(p))
+	assert.Equal("", p.Token)
+	assert.Equal("JOHN", p.Name)
+	assert.Equal([]string{"Token", "Name"}, seen)
+
+	failing := structd.New(mapGetter{"name": "john"}, "src",
+		structd.WithBeforeField(func(field reflect.StructField, raw any) (any, error) {
+			return nil, errors.New("rejected")
+		}),
+	)
+	var ferr *structd.FieldError
+	assert.ErrorAs(failing.Decode(&Hooked{}), &ferr)
+	assert.Equal("Name", ferr.Field)
+}
+
+func TestOptional(t *testing.T) {
+	assert := assert.New(t)
+
+	type Patch struct {
+		Page    scanner.Optional[uint32]       `query:"page" json:"page"`
+		Done    scanner.Optional[bool]         `query:"done" json:"done"`
+		Role    scanner.Optional[Role]         `query:"role" json:"-"`
+		Name    scanner.Optional[string]       `query:"name" json:"name"`
+		Count   sql.NullInt64                  `query:"count" json:"-"`
+		Missing scanner.Optional[sql.NullBool] `query:"missing" json:"-"`
+	}
+
+	values := &url.Values{}
+	values.Set("page", "0")
+	values.Set("done", "true")
+	values.Set("role", "admin")
+	values.Set("count", "42")
+
+	p := &Patch{}
+	assert.NoError(scanner.NewQuery(values).Scan(p))
+	assert.Equal(scanner.Some(uint32(0)), p.Page)
+	assert.Equal(scanner.Some(true), p.Done)
+	assert.Equal("admin", p.Role.Value.Name)
+	assert.False(p.Name.Present)
+	assert.Equal("fallback", p.Name.Or("fallback"))
+	assert.Equal(sql.NullInt64{Int64: 42, Valid: true}, p.Count)
+	assert.False(p.Missing.Present)
+
+	p = &Patch{}
+	assert.NoError(scanner.NewJSONBytes([]byte(`{ "page": 0 }`)).Scan(p))
+	assert.Equal(scanner.Some(uint32(0)), p.Page)
+	assert.False(p.Done.Present)
+}
+
+func TestPatchScanner(t *testing.T) {
+	assert := assert.New(t)
+
+	type Address struct {
+		City    string `json:"city"`
+		Country string `json:"country"`
+	}
+
+	type User struct {
+		Name    string                   `json:"name"`
+		Email   string                   `json:"email"`
+		Age     scanner.Optional[int]    `json:"age"`
+		Address *Address                 `json:"address"`
+		Labels  map[string]string        `json:"labels"`
+		Nick    scanner.Optional[string] `json:"nick"`
+	}
+
+	u := &User{
+		Name:    "John",
+		Email:   "john@example.com",
+		Address: &Address{City: "Istanbul", Country: "TR"},
+		Labels:  map[string]string{"team": "core", "role": "dev"},
+	}
+
+	s := scanner.NewPatch(bytes.NewBufferString(`{ "email": null, "age": 0, "address": { "city": "Ankara", "country": null }, "labels": { "role": null } }`))
+	assert.NoError(s.Scan(u))
+
+	assert.Equal("John", u.Name)
+	assert.Equal("", u.Email)
+	assert.Equal(scanner.Some(0), u.Age)
+	assert.False(u.Nick.Present)
+	assert.Equal(&Address{City: "Ankara"}, u.Address)
+	assert.Equal(map[string]string{"team": "core"}, u.Labels)
+
+	assert.True(s.Changed("email"))
+	assert.True(s.Changed("address.city"))
+	assert.False(s.Changed("name"))
+
+	assert.Error(scanner.NewPatch(bytes.NewBufferString(`[1, 2]`)).Scan(u))
+
+	type Lang string
+	type Translated struct {
+		Names map[Lang]string `json:"names"`
+		Ranks map[int]string  `json:"ranks"`
+	}
+	tr := &Translated{Names: map[Lang]string{"en": "Hello", "de": "Hallo"}, Ranks: map[int]string{1: "gold", 2: "silver"}}
+	assert.NoError(scanner.NewPatch(bytes.NewBufferString(`{ "names": { "de": null }, "ranks": { "2": null } }`)).Scan(tr))
+	assert.Equal(&Translated{Names: map[Lang]string{"en": "Hello"}, Ranks: map[int]string{1: "gold"}}, tr)
+}
+
+type Shape interface {
+	Area() float64
+}
+
+type Circle struct {
+	Radius float64 `query:"radius"`
+}
+
+func (c Circle) Area() float64 {
+	return 3 * c.Radius * c.Radius
+}
+
+type Square struct {
+	Side float64 `query:"side"`
+}
+
+func (s *Square) Area() float64 {
+	return s.Side * s.Side
+}
+
+func TestImplementations(t *testing.T) {
+	assert := assert.New(t)
+
+	structd.RegisterImplementation[Shape]("circle", Circle{})
+	structd.RegisterImplementation[Shape]("square", &Square{})
+
+	type Params struct {
+		Shape Shape `query:"shape"`
+		Other Shape `query:"other,discriminator=kind"`
+	}
+
+	values := &url.Values{}
+	values.Set("shape", "circle")
+	values.Set("kind", "square")
+	values.Set("radius", "2")
+	values.Set("side", "3")
+
+	p := &Params{}
+	assert.NoError(scanner.NewQuery(values).Scan(p))
+	assert.Equal(Circle{Radius: 2}, p.Shape)
+	assert.Equal(&Square{Side: 3}, p.Other)
+
+	values.Set("shape", "triangle")
+	var ierr *structd.ImplementationError
+	assert.ErrorAs(scanner.NewQuery(values).Scan(&Params{}), &ierr)
+	assert.Equal("triangle", ierr.Name)
+}
+
+func TestJsonMaxBytes(t *testing.T) {
+	assert := assert.New(t)
+	body := []byte(`{ "email": "test@example.com", "name": "John Doe" }`)
+
+	p := &Params{}
+	assert.NoError(scanner.NewJSONBytes(body, scanner.WithMaxBytes(int64(len(body)))).Scan(p))
+	assert.Equal("John Doe", p.Name)
+
+	var berr *scanner.BodyTooLargeError
+	err := scanner.NewJSONBytes(body, scanner.WithMaxBytes(16)).Scan(&Params{})
+	assert.ErrorAs(err, &berr)
+	assert.Equal(int64(16), berr.Limit)
+}
+
+type APIKey string
+
+func (k *APIKey) UnmarshalString(s string) error {
+	return fmt.Errorf("malformed key %s", s)
+}
+
+func TestSecretRedaction(t *testing.T) {
+	assert := assert.New(t)
+
+	type Secrets struct {
+		Pin int    `query:"pin,secret"`
+		Key APIKey `query:"key,secret"`
+	}
+
+	values := &url.Values{}
+	values.Set("pin", "s3cr3t-pin")
+	err := scanner.NewQuery(values).Scan(&Secrets{})
+	assert.Error(err)
+	assert.NotContains(err.Error(), "s3cr3t-pin")
+	assert.Contains(err.Error(), structd.Redacted)
+
+	values = &url.Values{}
+	values.Set("key", "sk_live_123")
+	err = scanner.NewQuery(values).Scan(&Secrets{})
+	var uerr *structd.UnmarshalerError
+	assert.ErrorAs(err, &uerr)
+	assert.Equal(structd.Redacted, uerr.Value)
+	assert.NotContains(err.Error(), "sk_live_123")
+}
+
+func TestLocaleCasting(t *testing.T) {
+	assert := assert.New(t)
+
+	type Localized struct {
+		Price    float64   `form:"price,locale=de"`
+		Quantity int       `form:"quantity,locale=fr"`
+		Amount   float64   `form:"amount"`
+		Date     time.Time `form:"date,locale=de"`
+		Due      time.Time `form:"due,layout=2006/01/02"`
+	}
+
+	form := &url.Values{}
+	form.Set("price", "1.234,56")
+	form.Set("quantity", "12 345")
+	form.Set("amount", "1234.5")
+	form.Set("date", "31.12.2024")
+	form.Set("due", "2025/01/15")
+
+	p := &Localized{}
+	assert.NoError(scanner.NewForm(form).Scan(p))
+	assert.Equal(1234.56, p.Price)
+	assert.Equal(12345, p.Quantity)
+	assert.Equal(1234.5, p.Amount)
+	assert.Equal(time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC), p.Date)
+	assert.Equal(time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC), p.Due)
+
+	p = &Localized{}
+	decoder := structd.New(scanner.NewForm(&url.Values{"amount": {"1,5"}}), "form", structd.WithLocale(language.German))
+	assert.NoError(decoder.Decode(p))
+	assert.Equal(1.5, p.Amount)
+}
+
+func TestTimezoneParsing(t *testing.T) {
+	assert := assert.New(t)
+
+	istanbul, err := time.LoadLocation("Europe/Istanbul")
+	assert.NoError(err)
+
+	type Schedule struct {
+		Start  time.Time `query:"start"`
+		End    time.Time `query:"end,tz=America/New_York"`
+		Offset time.Time `query:"offset"`
+	}
+
+	values := &url.Values{}
+	values.Set("start", "2024-06-01 09:30:00")
+	values.Set("end", "2024-06-01")
+	values.Set("offset", "2024-06-01T09:30:00Z")
+
+	p := &Schedule{}
+	assert.NoError(scanner.NewQuery(values, scanner.WithLocation(istanbul)).Scan(p))
+	assert.Equal(time.Date(2024, 6, 1, 9, 30, 0, 0, istanbul), p.Start)
+	assert.Equal("America/New_York", p.End.Location().String())
+	assert.True(time.Date(2024, 6, 1, 9, 30, 0, 0, time.UTC).Equal(p.Offset))
+}
+
+func TestHeaderMultiValue(t *testing.T) {
+	assert := assert.New(t)
+
+	type Headers struct {
+		Encodings []string `header:"accept-encoding"`
+		Via       []string `header:"Via"`
+		Custom    string   `header:"X-Custom"`
+		Prefer    []string `header:"prefer"`
+	}
+
+	header := &http.Header{}
+	header.Add("Accept-Encoding", "gzip, deflate")
+	header.Add("Accept-Encoding", "br")
+	header.Add("Via", "1.0 fred, 1.1 p.example.net")
+	header.Add("Prefer", `respond-async, wait="1,5"`)
+	(*header)["x-custom"] = []string{"value"}
+
+	p := &Headers{}
+	assert.NoError(scanner.NewHeader(header).Scan(p))
+	assert.Equal([]string{"gzip", "deflate", "br"}, p.Encodings)
+	assert.Equal([]string{"1.0 fred", "1.1 p.example.net"}, p.Via)
+	assert.Equal([]string{"respond-async", `wait="1,5"`}, p.Prefer)
+	assert.Equal("value", p.Custom)
+}
+
+func TestNegotiation(t *testing.T) {
+	assert := assert.New(t)
+
+	type Negotiation struct {
+		Languages scanner.AcceptLanguage `header:"accept-language"`
+		Accept    scanner.Accept         `header:"accept"`
+	}
+
+	header := &http.Header{}
+	header.Set("Accept-Language", "da, en-GB;q=0.8, en;q=0.7")
+	header.Set("Accept", "text/*;q=0.3, text/html;q=0.7, text/html;level=1, */*;q=0.5")
+
+	p := &Negotiation{}
+	assert.NoError(scanner.NewHeader(header).Scan(p))
+	assert.Equal(scanner.AcceptLanguage{language.Danish, language.BritishEnglish, language.English}, p.Languages)
+	assert.Equal(language.BritishEnglish, p.Languages.Match(language.AmericanEnglish, language.BritishEnglish))
+
+	assert.Equal("text/html", p.Accept.Negotiate("text/plain", "text/html"))
+	assert.Equal("application/json", p.Accept.Negotiate("text/plain", "application/json"))
+	assert.Equal("text/plain", scanner.Accept{}.Negotiate("text/plain"))
+}
+
+func TestForwardedScanner(t *testing.T) {
+	assert := assert.New(t)
+
+	type Client struct {
+		IP     netip.Addr `forwarded:"for"`
+		Scheme string     `forwarded:"proto"`
+		Host   string     `forwarded:"host"`
+	}
+
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	req := httptest.NewRequest(http.MethodGet, "http://internal/", nil)
+	req.RemoteAddr = "10.0.0.1:4000"
+	req.Header.Set("Forwarded", `for=198.51.100.17;proto=https;host=example.com, for="[2001:db8::1]:4711", for=10.0.0.2`)
+
+	p := &Client{}
+	assert.NoError(scanner.NewForwarded(req, trusted).Scan(p))
+	assert.Equal(netip.MustParseAddr("2001:db8::1"), p.IP)
+	assert.Equal("http", p.Scheme)
+	assert.Equal("internal", p.Host)
+
+	req.Header.Del("Forwarded")
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.3")
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "example.com")
+
+	p = &Client{}
+	assert.NoError(scanner.NewForwarded(req, trusted).Scan(p))
+	assert.Equal(netip.MustParseAddr("203.0.113.7"), p.IP)
+	assert.Equal("https", p.Scheme)
+	assert.Equal("example.com", p.Host)
+
+	// a client cannot inject the host of the hops trusted proxies appended
+	req.Header.Set("X-Forwarded-For", "198.51.100.1, 203.0.113.7, 10.0.0.3")
+	req.Header.Set("X-Forwarded-Proto", "http, https, https")
+	req.Header.Set("X-Forwarded-Host", "evil.example, example.com, internal")
+
+	p = &Client{}
+	assert.NoError(scanner.NewForwarded(req, trusted).Scan(p))
+	assert.Equal(netip.MustParseAddr("203.0.113.7"), p.IP)
+	assert.Equal("https", p.Scheme)
+	assert.Equal("example.com", p.Host)
+
+	req.RemoteAddr = "192.0.2.1:4000"
+	p = &Client{}
+	assert.NoError(scanner.NewForwarded(req, trusted).Scan(p))
+	assert.Equal(netip.MustParseAddr("192.0.2.1"), p.IP)
+	assert.Equal("http", p.Scheme)
+}
+
+func TestLinkScanner(t *testing.T) {
+	assert := assert.New(t)
+
+	type Pagination struct {
+		Next  *url.URL `link:"next"`
+		Prev  string   `link:"prev"`
+		First url.URL  `link:"first"`
+		Last  string   `link:"last"`
+	}
+
+	header := http.Header{}
+	header.Add("Link", `<https://api.github.com/repositories/1/issues?page=2&per_page=1,2>; rel="next", <https://api.github.com/repositories/1/issues?page=1>; rel="prev first"`)
+	header.Add("Link", `<https://api.github.com/repositories/1/issues?page=5>; title="a; b, c"; rel=last`)
+
+	p := &Pagination{}
+	assert.NoError(scanner.NewLink(header).Scan(p))
+	assert.Equal("https://api.github.com/repositories/1/issues?page=2&per_page=1,2", p.Next.String())
+	assert.Equal("https://api.github.com/repositories/1/issues?page=1", p.Prev)
+	assert.Equal("page=1", p.First.RawQuery)
+	assert.Equal("https://api.github.com/repositories/1/issues?page=5", p.Last)
+}
+
+func TestResponseScanner(t *testing.T) {
+	assert := assert.New(t)
+
+	type Result struct {
+		ID        string `json:"id"`
+		Name      string `json:"name"`
+		RequestID string `header:"x-request-id"`
+		Session   string `cookie:"session"`
+		Next      string `link:"next"`
+	}
+
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Content-Type", "application/json; charset=utf-8")
+	rec.Header().Set("X-Request-Id", "req-1")
+	rec.Header().Set("Link", `<https://example.com/items?page=2>; rel="next"`)
+	http.SetCookie(rec, &http.Cookie{Name: "session", Value: "s-1"})
+	rec.WriteString(`{ "id": "item-1", "name": "Item" }`)
+
+	p := &Result{}
+	assert.NoError(scanner.NewResponse(rec.Result()).Scan(p))
+	assert.Equal(Result{
+		ID:        "item-1",
+		Name:      "Item",
+		RequestID: "req-1",
+		Session:   "s-1",
+		Next:      "https://example.com/items?page=2",
+	}, *p)
+}
+
+func TestPathTemplateScanner(t *testing.T) {
+	assert := assert.New(t)
+
+	type Route struct {
+		ID   int    `path:"id"`
+		Slug string `path:"slug"`
+	}
+
+	s, err := scanner.NewPathTemplate("/users/{id}/posts/{slug}", "/users/42/posts/hello-world")
+	assert.NoError(err)
+
+	p := &Route{}
+	assert.NoError(s.Scan(p))
+	assert.Equal(Route{ID: 42, Slug: "hello-world"}, *p)
+
+	s, err = scanner.NewPathTemplate("/users/{id}", "/posts/42")
+	assert.NoError(err)
+	assert.ErrorIs(s.Scan(p), uritemplate.ErrMismatch)
+}
+
+func TestGraphQLScanner(t *testing.T) {
+	assert := assert.New(t)
+
+	type Filter struct {
+		Status string   `gql:"status"`
+		Tags   []string `gql:"tags"`
+	}
+
+	type Variables struct {
+		First  int               `gql:"first"`
+		After  string            `gql:"after"`
+		Filter Filter            `gql:"filter"`
+		IDs    []uint64          `gql:"ids"`
+		Meta   map[string]string `gql:"meta"`
+		Ratio  float32           `gql:"ratio"`
+	}
+
+	req := &scanner.GraphQLRequest{}
+	err := json.Unmarshal([]byte(`{
+		"query": "query Items($first: Int) { items(first: $first) { id } }",
+		"variables": {
+			"first": 10,
+			"after": "cursor",
+			"filter": { "status": "open", "tags": ["a", "b"] },
+			"ids": [1, 2, 3],
+			"meta": { "source": "web" },
+			"ratio": 0.5
+		}
+	}`), req)
+	assert.NoError(err)
+
+	p := &Variables{}
+	assert.NoError(scanner.NewGraphQL(req).Scan(p))
+	assert.Equal(Variables{
+		First:  10,
+		After:  "cursor",
+		Filter: Filter{Status: "open", Tags: []string{"a", "b"}},
+		IDs:    []uint64{1, 2, 3},
+		Meta:   map[string]string{"source": "web"},
+		Ratio:  0.5,
+	}, *p)
+
+	req.Variables["first"] = 1.5
+	assert.Error(scanner.NewGraphQL(req).Scan(&Variables{}))
+}
+
+func TestJSONRPCScanner(t *testing.T) {
+	assert := assert.New(t)
+
+	type Position struct {
+		Line      int `rpc:"line"`
+		Character int `rpc:"character"`
+	}
+
+	type Named struct {
+		URI      string   `rpc:"uri"`
+		Position Position `rpc:"position"`
+	}
+
+	s := scanner.NewJSONRPC(bytes.NewBufferString(`{ "jsonrpc": "2.0", "id": 1, "method": "textDocument/hover", "params": { "uri": "file:///main.go", "position": { "line": 3, "character": 12 } } }`))
+	p := &Named{}
+	assert.NoError(s.Scan(p))
+	assert.Equal(Named{URI: "file:///main.go", Position: Position{Line: 3, Character: 12}}, *p)
+	assert.Equal("textDocument/hover", s.Method())
+	assert.Equal(float64(1), s.ID())
+	assert.False(s.IsNotification())
+
+	type Positional struct {
+		A int `rpc:"0"`
+		B int `rpc:"1"`
+	}
+
+	s = scanner.NewJSONRPC(bytes.NewBufferString(`{ "jsonrpc": "2.0", "method": "subtract", "params": [42, 23] }`))
+	pp := &Positional{}
+	assert.NoError(s.Scan(pp))
+	assert.Equal(Positional{A: 42, B: 23}, *pp)
+	assert.True(s.IsNotification())
+
+	var rerr *scanner.JSONRPCError
+	assert.ErrorAs(scanner.NewJSONRPC(bytes.NewBufferString(`{ "jsonrpc": "1.0", "method": "x" }`)).Scan(pp), &rerr)
+	assert.Equal(scanner.JSONRPCInvalidRequest, rerr.Code)
+	assert.ErrorAs(scanner.NewJSONRPC(bytes.NewBufferString(`{`)).Scan(pp), &rerr)
+	assert.Equal(scanner.JSONRPCParseError, rerr.Code)
+}
+
+func TestRequestScanner(t *testing.T) {
+	assert := assert.New(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/items?page=2", bytes.NewBufferString(`{ "email": "test@example.com" }`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "en")
+	req.AddCookie(&http.Cookie{Name: "token", Value: "cookie-token"})
+	req.SetPathValue("id", "this_is_id")
+
+	p := &Params{}
+	assert.NoError(scanner.NewRequest(req).Scan(p))
+	assert.Equal("test@example.com", p.Email)
+	assert.Equal("en", p.Language)
+	assert.Equal(uint32(2), p.Page)
+	assert.Equal("cookie-token", p.Token)
+	assert.Equal("this_is_id", p.ID)
+}
+
+func TestAPIGatewayScanner(t *testing.T) {
+	assert := assert.New(t)
+
+	ev := &scanner.APIGatewayProxyRequest{}
+	assert.NoError(json.Unmarshal([]byte(`{
+		"path": "/items/this_is_id",
+		"httpMethod": "POST",
+		"headers": { "accept-language": "en", "content-type": "application/json", "cookie": "token=cookie-token" },
+		"multiValueQueryStringParameters": { "roles": ["admin,user"] },
+		"queryStringParameters": { "page": "2" },
+		"pathParameters": { "id": "this_is_id" },
+		"body": "eyAibmFtZSI6ICJKb2huIERvZSIgfQ==",
+		"isBase64Encoded": true
+	}`), ev))
+
+	s, err := scanner.NewAPIGateway(ev)
+	assert.NoError(err)
+
+	p := &Params{}
+	assert.NoError(s.Scan(p))
+	assert.Equal("John Doe", p.Name)
+	assert.Equal("en", p.Language)
+	assert.Equal(uint32(2), p.Page)
+	assert.Len(p.Roles, 2)
+	assert.Equal("cookie-token", p.Token)
+	assert.Equal("this_is_id", p.ID)
+
+	v2 := &scanner.APIGatewayV2HTTPRequest{}
+	assert.NoError(json.Unmarshal([]byte(`{
+		"rawPath": "/items/this_is_id",
+		"rawQueryString": "page=3&done=true",
+		"cookies": ["token=v2-token"],
+		"headers": { "accept-language": "tr" },
+		"pathParameters": { "slug": "slug" },
+		"requestContext": { "http": { "method": "GET" } }
+	}`), v2))
+
+	s, err = scanner.NewAPIGatewayV2(v2)
+	assert.NoError(err)
+
+	p = &Params{}
+	assert.NoError(s.Scan(p))
+	assert.Equal(uint32(3), p.Page)
+	assert.True(p.Done)
+	assert.Equal("tr", p.Language)
+	assert.Equal("v2-token", p.Token)
+	assert.Equal("slug", p.Slug)
+}
+
+type args map[string][]string
+
+func (a args) Peek(key string) []byte {
+	if values := a[key]; len(values) > 0 {
+		return []byte(values[0])
+	}
+	return nil
+}
+
+func (a args) PeekMulti(key string) [][]byte {
+	values := [][]byte{}
+	for _, value := range a[key] {
+		values = append(values, []byte(value))
+	}
+	return values
+}
+
+type userValues map[string]any
+
+func (u userValues) UserValue(key any) any {
+	return u[key.(string)]
+}
+
+func TestArgsScanner(t *testing.T) {
+	assert := assert.New(t)
+
+	type Params struct {
+		Page  uint32   `query:"page"`
+		Raw   []byte   `query:"raw"`
+		IDs   []int    `query:"id"`
+		Name  string   `query:"name"`
+		Token string   `cookie:"token"`
+		Slug  string   `path:"slug"`
+		Tags  []string `query:"tag"`
+	}
+
+	query := args{"page": {"2"}, "raw": {"bytes"}, "id": {"1", "2"}, "name": {"John"}, "tag": {"a,b"}}
+	cookies := map[string]string{"token": "cookie-token"}
+
+	p := &Params{}
+	s := scanner.NewPipe(
+		scanner.NewArgs(query, "query"),
+		scanner.NewArgs(scanner.PeekFunc(func(key string) []byte { return []byte(cookies[key]) }), "cookie"),
+		scanner.NewUserValues(userValues{"slug": "hello"}),
+	)
+	assert.NoError(s.Scan(p))
+	assert.Equal(Params{
+		Page:  2,
+		Raw:   []byte("bytes"),
+		IDs:   []int{1, 2},
+		Name:  "John",
+		Token: "cookie-token",
+		Slug:  "hello",
+		Tags:  []string{"a,b"},
+	}, *p)
+}
+
+type kafkaHeader struct {
+	Key   string
+	Value []byte
+}
+
+func TestMessageScanner(t *testing.T) {
+	assert := assert.New(t)
+
+	type Event struct {
+		Key      string `meta:"key"`
+		Type     string `meta:"event-type"`
+		Version  int    `header:"x-version"`
+		Email    string `json:"email"`
+		Name     string `json:"name"`
+		Language string `header:"accept-language"`
+	}
+
+	kafka := scanner.KafkaMessage([]byte("user-1"), []byte(`{ "email": "test@example.com", "name": "John Doe" }`), []kafkaHeader{
+		{Key: "event-type", Value: []byte("user.created")},
+		{Key: "accept-language", Value: []byte("en")},
+	})
+
+	p := &Event{}
+	assert.NoError(scanner.NewMessage(kafka).Scan(p))
+	assert.Equal(Event{Key: "user-1", Type: "user.created", Email: "test@example.com", Name: "John Doe", Language: "en"}, *p)
+
+	amqp := scanner.AMQPMessage("users.created", "application/json", map[string]any{"event-type": "user.created"}, []byte(`{ "name": "Jane" }`))
+	p = &Event{}
+	assert.NoError(scanner.NewMessage(amqp).Scan(p))
+	assert.Equal("users.created", p.Key)
+	assert.Equal("Jane", p.Name)
+
+	nats := scanner.NATSMessage("users", map[string][]string{"Content-Type": {"text/plain"}}, []byte("plain"))
+	p = &Event{}
+	assert.NoError(scanner.NewMessage(nats).Scan(p))
+	assert.Equal("users", p.Key)
+	assert.Equal("", p.Name)
+}
+
+type objectID [12]byte
+
+func (id objectID) Hex() string {
+	return hex.EncodeToString(id[:])
+}
+
+type dateTime int64
+
+func (d dateTime) Time() time.Time {
+	return time.UnixMilli(int64(d)).UTC()
+}
+
+type bsonM map[string]any
+
+type bsonA []any
+
+type bsonE struct {
+	Key   string
+	Value any
+}
+
+func TestBSONScanner(t *testing.T) {
+	assert := assert.New(t)
+
+	type Address struct {
+		City string `bson:"city"`
+	}
+
+	type User struct {
+		ID        string    `bson:"_id"`
+		Name      string    `bson:"name"`
+		Age       int       `bson:"age"`
+		Score     float64   `bson:"score"`
+		Tags      []string  `bson:"tags"`
+		CreatedAt time.Time `bson:"created_at"`
+		Address   Address   `bson:"address"`
+		Previous  []Address `bson:"previous,omitempty"`
+	}
+
+	id := objectID{0x65, 0x0f, 0x1a}
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	doc := bsonM{
+		"_id":        id,
+		"name":       "John",
+		"age":        int32(30),
+		"score":      int64(99),
+		"tags":       bsonA{"a", "b"},
+		"created_at": dateTime(created.UnixMilli()),
+		"address":    bsonM{"city": "Istanbul"},
+		"previous":   bsonA{[]bsonE{{Key: "city", Value: "Ankara"}}},
+	}
+
+	p := &User{}
+	assert.NoError(scanner.NewBSON(doc).Scan(p))
+	assert.Equal(User{
+		ID:        id.Hex(),
+		Name:      "John",
+		Age:       30,
+		Score:     99,
+		Tags:      []string{"a", "b"},
+		CreatedAt: created,
+		Address:   Address{City: "Istanbul"},
+		Previous:  []Address{{City: "Ankara"}},
+	}, *p)
+
+	p = &User{}
+	assert.NoError(scanner.NewBSOND([]bsonE{{Key: "name", Value: "Jane"}, {Key: "age", Value: int64(25)}}).Scan(p))
+	assert.Equal("Jane", p.Name)
+	assert.Equal(25, p.Age)
+
+	raw := bsonDocument(
+		bsonElement(0x07, "_id", id[:]),
+		bsonElement(0x02, "name", bsonString("John")),
+		bsonElement(0x10, "age", binary.LittleEndian.AppendUint32(nil, 30)),
+		bsonElement(0x01, "score", binary.LittleEndian.AppendUint64(nil, math.Float64bits(99))),
+		bsonElement(0x04, "tags", bsonDocument(bsonElement(0x02, "0", bsonString("a")), bsonElement(0x02, "1", bsonString("b")))),
+		bsonElement(0x09, "created_at", binary.LittleEndian.AppendUint64(nil, uint64(created.UnixMilli()))),
+		bsonElement(0x03, "address", bsonDocument(bsonElement(0x02, "city", bsonString("Istanbul")))),
+		bsonElement(0x04, "previous", bsonDocument(bsonElement(0x03, "0", bsonDocument(bsonElement(0x02, "city", bsonString("Ankara")))))),
+		bsonElement(0x0a, "deleted_at", nil),
+	)
+	s, err := scanner.NewBSONRaw(raw)
+	assert.NoError(err)
+	p = &User{}
+	assert.NoError(s.Scan(p))
+	assert.Equal(User{
+		ID:        id.Hex(),
+		Name:      "John",
+		Age:       30,
+		Score:     99,
+		Tags:      []string{"a", "b"},
+		CreatedAt: created,
+		Address:   Address{City: "Istanbul"},
+		Previous:  []Address{{City: "Ankara"}},
+	}, *p)
+
+	_, err = scanner.NewBSONRaw(raw[:len(raw)-3])
+	assert.ErrorIs(err, io.ErrUnexpectedEOF)
+	_, err = scanner.NewBSONRaw(bsonDocument(bsonElement(0x13, "price", make([]byte, 16))))
+	assert.ErrorContains(err, `unsupported bson type 0x13 of key "price"`)
+}
+
+func bsonDocument(elements ...[]byte) []byte {
+	body := bytes.Join(elements, nil)
+	b := binary.LittleEndian.AppendUint32(nil, uint32(len(body)+5))
+	return append(append(b, body...), 0)
+}
+
+func bsonElement(t byte, key string, value []byte) []byte {
+	return append(append(append([]byte{t}, key...), 0), value...)
+}
+
+func bsonString(s string) []byte {
+	b := binary.LittleEndian.AppendUint32(nil, uint32(len(s)+1))
+	return append(append(b, s...), 0)
+}
+
+type hashResult struct {
+	values map[string]string
+	err    error
+}
+
+func (r hashResult) Result() (map[string]string, error) {
+	return r.values, r.err
+}
+
+func TestRedisHashScanner(t *testing.T) {
+	assert := assert.New(t)
+
+	type Session struct {
+		UserID    uint64    `redis:"user_id"`
+		Roles     []string  `redis:"roles"`
+		Admin     bool      `redis:"admin"`
+		ExpiresAt time.Time `redis:"expires_at"`
+	}
+
+	s, err := scanner.NewRedisHashResult(hashResult{values: map[string]string{
+		"user_id":    "42",
+		"roles":      "admin,user",
+		"admin":      "1",
+		"expires_at": "2024-06-01T12:00:00Z",
+	}})
+	assert.NoError(err)
+
+	p := &Session{}
+	assert.NoError(s.Scan(p))
+	assert.Equal(Session{
+		UserID:    42,
+		Roles:     []string{"admin", "user"},
+		Admin:     true,
+		ExpiresAt: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+	}, *p)
+
+	_, err = scanner.NewRedisHashResult(hashResult{err: errors.New("redis: nil")})
+	assert.Error(err)
+}
+
+func TestVCardScanner(t *testing.T) {
+	assert := assert.New(t)
+
+	type Contact struct {
+		Name   string   `vcard:"FN"`
+		Emails []string `vcard:"email"`
+		Phone  string   `vcard:"TEL"`
+		Note   string   `vcard:"NOTE"`
+	}
+
+	card := "BEGIN:VCARD\r\nVERSION:4.0\r\nFN:John\r\n  Doe\r\nEMAIL;TYPE=work:john@example.com\r\nitem1.EMAIL:john@home.example\r\nTEL;VALUE=uri:tel:+1-555-555-5555\r\nNOTE:first\\, second\\nline\r\nEND:VCARD\r\n"
+
+	p := &Contact{}
+	assert.NoError(scanner.NewVCard(bytes.NewBufferString(card)).Scan(p))
+	assert.Equal(Contact{
+		Name:   "John Doe",
+		Emails: []string{"john@example.com", "john@home.example"},
+		Phone:  "tel:+1-555-555-5555",
+		Note:   "first, second\nline",
+	}, *p)
+}
+
+func TestICalendarScanner(t *testing.T) {
+	assert := assert.New(t)
+
+	type Event struct {
+		UID     string    `ical:"UID"`
+		Summary string    `ical:"SUMMARY"`
+		Start   time.Time `ical:"DTSTART"`
+		End     time.Time `ical:"DTEND"`
+	}
+
+	calendar := `BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VEVENT
+UID:event-1
+SUMMARY:Standup
+DTSTART;TZID=Europe/Istanbul:20240101T100000
+DTEND:20240101T073000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:event-2
+SUMMARY:Holiday
+DTSTART;VALUE=DATE:20240102
+END:VEVENT
+END:VCALENDAR
+`
+
+	s, err := scanner.NewICalendar(bytes.NewBufferString(calendar))
+	assert.NoError(err)
+
+	istanbul, _ := time.LoadLocation("Europe/Istanbul")
+	p := &Event{}
+	assert.NoError(s.Scan(p))
+	assert.Equal("Standup", p.Summary)
+	assert.Equal(time.Date(2024, 1, 1, 10, 0, 0, 0, istanbul), p.Start)
+	assert.Equal(time.Date(2024, 1, 1, 7, 30, 0, 0, time.UTC), p.End)
+
+	events := s.Events()
+	assert.Len(events, 2)
+	p = &Event{}
+	assert.NoError(events[1].Scan(p))
+	assert.Equal("event-2", p.UID)
+	assert.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), p.Start)
+
+	_, err = scanner.NewICalendar(bytes.NewBufferString("BEGIN:VCALENDAR\nBEGIN:VEVENT\nEND:VCALENDAR\n"))
+	assert.Error(err)
+}
+
+func TestFixedWidthScanner(t *testing.T) {
+	assert := assert.New(t)
+
+	type Transaction struct {
+		Account string    `fixed:"0,10"`
+		Amount  float64   `fixed:"10,20"`
+		Date    time.Time `fixed:"20,28,layout=20060102"`
+		Code    string    `fixed:"28,31,lower"`
+	}
+
+	file := "ACC0000001    120.50202401153XA\r\nACC0000002      7.25\n\nACC0000003  abc\n"
+	s := scanner.NewFixedWidth(bytes.NewBufferString(file))
+
+	records := []Transaction{}
+	var err error
+	for s.Next() {
+		r := Transaction{}
+		if err = s.Scan(&r); err != nil {
+			break
+		}
+		records = append(records, r)
+	}
+	assert.NoError(s.Err())
+	assert.Error(err)
+	assert.Equal([]Transaction{
+		{Account: "ACC0000001", Amount: 120.5, Date: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), Code: "3xa"},
+		{Account: "ACC0000002", Amount: 7.25},
+	}, records)
+}
+
+func TestBinaryScanner(t *testing.T) {
+	assert := assert.New(t)
+
+	type Packet struct {
+		Version uint8   `bin:"offset=0"`
+		Flags   bool    `bin:"offset=1"`
+		Length  uint16  `bin:"offset=2,be"`
+		Temp    int16   `bin:"offset=4,le"`
+		Level   float32 `bin:"offset=6"`
+		ID      [2]byte `bin:"offset=10"`
+		Serial  uint32  `bin:"offset=12,len=3,le"`
+		Name    string  `bin:"offset=15,len=6"`
+		Rest    []byte  `bin:"offset=21"`
+	}
+
+	payload := []byte{
+		0x02, 0x01, 0x01, 0x02, 0xf6, 0xff, 0x3f, 0xc0, 0x00, 0x00,
+		0xab, 0xcd, 0x01, 0x02, 0x03, 'n', 'o', 'd', 'e', 0x00, 0x00, 0x09, 0x08,
+	}
+
+	p := Packet{}
+	assert.NoError(scanner.NewBinaryBytes(payload).Scan(&p))
+	assert.Equal(Packet{
+		Version: 2,
+		Flags:   true,
+		Length:  258,
+		Temp:    -10,
+		Level:   1.5,
+		ID:      [2]byte{0xab, 0xcd},
+		Serial:  0x030201,
+		Name:    "node",
+		Rest:    []byte{0x09, 0x08},
+	}, p)
+
+	type Sequential struct {
+		Kind  uint8  `bin:"kind"`
+		Count uint16 `bin:"count,le"`
+	}
+	s := Sequential{}
+	assert.NoError(scanner.NewBinaryBytes([]byte{0x07, 0x10, 0x00}).Scan(&s))
+	assert.Equal(Sequential{Kind: 7, Count: 16}, s)
+
+	err := scanner.NewBinaryBytes([]byte{0x07}).Scan(&s)
+	assert.ErrorIs(err, io.ErrUnexpectedEOF)
+}
+
+func TestArchiveScanner(t *testing.T) {
+	assert := assert.New(t)
+
+	type Theme struct {
+		Manifest string `file:"manifest.json"`
+		Style    string `file:"assets/css/style.css"`
+		Missing  string `file:"assets/missing.css"`
+	}
+	expected := Theme{Manifest: `{"name":"dark"}`, Style: "body{}"}
+	members := [][2]string{{"manifest.json", expected.Manifest}, {"assets/css/style.css", expected.Style}}
+
+	zipped := &bytes.Buffer{}
+	zw := zip.NewWriter(zipped)
+	for _, member := range members {
+		w, err := zw.Create(member[0])
+		assert.NoError(err)
+		w.Write([]byte(member[1]))
+	}
+	assert.NoError(zw.Close())
+
+	tarred := &bytes.Buffer{}
+	gw := gzip.NewWriter(tarred)
+	tw := tar.NewWriter(gw)
+	assert.NoError(tw.WriteHeader(&tar.Header{Name: "./assets/", Typeflag: tar.TypeDir, Mode: 0755}))
+	for _, member := range members {
+		assert.NoError(tw.WriteHeader(&tar.Header{Name: "./" + member[0], Mode: 0644, Size: int64(len(member[1]))}))
+		tw.Write([]byte(member[1]))
+	}
+	assert.NoError(tw.Close())
+	assert.NoError(gw.Close())
+
+	for _, archive := range []*bytes.Buffer{zipped, tarred} {
+		s, err := scanner.NewArchive(archive)
+		assert.NoError(err)
+
+		theme := Theme{}
+		assert.NoError(s.Scan(&theme))
+		assert.Equal(expected, theme)
+	}
+
+	_, err := scanner.NewArchive(bytes.NewBufferString("plain text"))
+	assert.ErrorIs(err, scanner.ErrUnknownArchive)
+
+	// members of tar archives follow the directory options
+	tarred = &bytes.Buffer{}
+	tw = tar.NewWriter(tarred)
+	big := strings.Repeat("a", 1000)
+	assert.NoError(tw.WriteHeader(&tar.Header{Name: "big.txt", Mode: 0644, Size: int64(len(big))}))
+	tw.Write([]byte(big))
+	assert.NoError(tw.Close())
+
+	type Big struct {
+		Content string `file:"big.txt"`
+	}
+	s, err := scanner.NewArchive(bytes.NewReader(tarred.Bytes()), scanner.WithMaxFileSize(10))
+	assert.NoError(err)
+	var tooLarge *scanner.FileTooLargeError
+	assert.ErrorAs(s.Scan(&Big{}), &tooLarge)
+
+	s, err = scanner.NewArchive(bytes.NewReader(tarred.Bytes()), scanner.WithExclude("*.txt"))
+	assert.NoError(err)
+	b := Big{}
+	assert.NoError(s.Scan(&b))
+	assert.Empty(b.Content)
+}
+
+func TestFrontMatterScanner(t *testing.T) {
+	assert := assert.New(t)
+
+	type Author struct {
+		Name string `front:"name"`
+	}
+	type Post struct {
+		Title  string    `front:"title"`
+		Date   time.Time `front:"date"`
+		Tags   []string  `front:"tags"`
+		Draft  bool      `front:"draft"`
+		Weight int       `front:"weight"`
+		Author Author    `front:"author"`
+		Body   string    `body:""`
+	}
+	expected := Post{
+		Title:  "Hello",
+		Date:   time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+		Tags:   []string{"go", "web"},
+		Draft:  true,
+		Weight: 3,
+		Author: Author{Name: "Ada"},
+		Body:   "# Hello\n\nWorld\n",
+	}
+
+	yamlDoc := "---\ntitle: Hello\ndate: 2024-03-01T10:00:00Z\ntags: [go, web]\ndraft: true\nweight: 3\nauthor:\n  name: Ada\n---\n# Hello\n\nWorld\n"
+	post := Post{}
+	assert.NoError(scanner.NewFrontMatter(strings.NewReader(yamlDoc)).Scan(&post))
+	assert.Equal(expected, post)
+
+	tomlDoc := "+++\r\ntitle = \"Hello\"\r\ndate = 2024-03-01T10:00:00Z\r\ntags = [\"go\", \"web\"]\r\ndraft = true\r\nweight = 3\r\n[author]\r\nname = \"Ada\"\r\n+++\r\n# Hello\n\nWorld\n"
+	post = Post{}
+	assert.NoError(scanner.NewFrontMatter(strings.NewReader(tomlDoc)).Scan(&post))
+	assert.Equal(expected, post)
+
+	post = Post{}
+	assert.NoError(scanner.NewFrontMatter(strings.NewReader("# Plain\n")).Scan(&post))
+	assert.Equal(Post{Body: "# Plain\n"}, post)
+
+	err := scanner.NewFrontMatter(strings.NewReader("---\ntitle: Hello\n")).Scan(&post)
+	assert.ErrorIs(err, scanner.ErrUnterminatedFrontMatter)
+}
+
+func TestHTMLScanner(t *testing.T) {
+	assert := assert.New(t)
+
+	doc := `<!doctype html>
+<html><body>
+	<h1 class="title main">  Sign
+		in </h1>
+	<form id="login" action="/session">
+		<input type="hidden" name="csrf" value="token-123">
+		<input type="email" name="email" value="user@example.com">
+		<input type="checkbox" name="remember" checked>
+		<input type="checkbox" name="newsletter">
+		<select name="age"><option value="18">18</option><option value="21" selected>21</option></select>
+		<textarea name="bio">Hi
+there</textarea>
+	</form>
+	<ul class="links"><li><a href="/a">A</a></li><li><a href="/b">B</a></li></ul>
+	<a class="next" href="/page/2">Next</a>
+</body></html>`
+
+	type Page struct {
+		Title      string   `html:"h1.title"`
+		Action     string   `html:"form#login@action"`
+		CSRF       string   `html:"input[name=csrf]"`
+		Email      string   `html:"form > input[name=\"email\"]"`
+		Remember   bool     `html:"input[name=remember]"`
+		Newsletter bool     `html:"input[name=newsletter]"`
+		Age        int      `html:"select[name=age]"`
+		Bio        string   `html:"textarea"`
+		Links      []string `html:"ul.links a@href"`
+		Labels     []string `html:"ul.links li"`
+		Next       string   `html:"a.next@href"`
+		Missing    string   `html:"table td"`
+	}
+
+	p := Page{}
+	assert.NoError(scanner.NewHTML(strings.NewReader(doc)).Scan(&p))
+	assert.Equal(Page{
+		Title:    "Sign in",
+		Action:   "/session",
+		CSRF:     "token-123",
+		Email:    "user@example.com",
+		Remember: true,
+		Age:      21,
+		Bio:      "Hi\nthere",
+		Links:    []string{"/a", "/b"},
+		Labels:   []string{"A", "B"},
+		Next:     "/page/2",
+	}, p)
+}
+
+func TestUserAgent(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		ua       string
+		expected scanner.UserAgent
+	}{
+		{
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91",
+			scanner.UserAgent{Browser: "Edge", Version: "120.0.2210.91", OS: "Windows", OSVersion: "10", Device: scanner.DeviceDesktop},
+		},
+		{
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1",
+			scanner.UserAgent{Browser: "Safari", Version: "17.1", OS: "iOS", OSVersion: "17.1.2", Device: scanner.DeviceMobile},
+		},
+		{
+			"Mozilla/5.0 (Linux; Android 14; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Safari/537.36",
+			scanner.UserAgent{Browser: "Chrome", Version: "119.0.0.0", OS: "Android", OSVersion: "14", Device: scanner.DeviceTablet},
+		},
+		{
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 10.15; rv:121.0) Gecko/20100101 Firefox/121.0",
+			scanner.UserAgent{Browser: "Firefox", Version: "121.0", OS: "macOS", OSVersion: "10.15", Device: scanner.DeviceDesktop},
+		},
+		{
+			"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			scanner.UserAgent{Browser: "Googlebot", Version: "2.1", Device: scanner.DeviceBot, Bot: true},
+		},
+		{
+			"curl/8.4.0",
+			scanner.UserAgent{Browser: "curl", Version: "8.4.0", Device: scanner.DeviceBot, Bot: true},
+		},
+	}
+
+	for _, test := range tests {
+		test.expected.Raw = test.ua
+		assert.Equal(test.expected, scanner.ParseUserAgent(test.ua), test.ua)
+	}
+
+	header := &http.Header{}
+	header.Set("User-Agent", tests[1].ua)
+
+	type Request struct {
+		UserAgent scanner.UserAgent `header:"user-agent"`
+	}
+	r := Request{}
+	assert.NoError(scanner.NewHeader(header).Scan(&r))
+	assert.True(r.UserAgent.IsMobile())
+	assert.Equal("Safari", r.UserAgent.Browser)
+}
+
+func TestEventStream(t *testing.T) {
+	assert := assert.New(t)
+
+	stream := ": keep-alive\n\n" +
+		"retry: 3000\nid: 1\nevent: price\ndata: {\"symbol\": \"ACME\",\ndata: \"price\": 12.5}\n\n" +
+		"data: plain\r\n\r\n" +
+		"id: 2\nevent: price\ndata: {\"symbol\": \"INIT\", \"price\": 1}\n\n" +
+		"data: incomplete"
+
+	type Price struct {
+		ID     string        `sse:"id"`
+		Event  string        `sse:"event"`
+		Retry  time.Duration `sse:"retry"`
+		Data   string        `sse:"data"`
+		Symbol string        `json:"symbol"`
+		Price  float64       `json:"price"`
+	}
+
+	s := scanner.NewEventStream(context.Background(), strings.NewReader(stream))
+	prices := []Price{}
+	for s.Next() {
+		p := Price{}
+		assert.NoError(s.Scan(&p))
+		prices = append(prices, p)
+	}
+	assert.NoError(s.Err())
+	assert.Equal("2", s.LastEventID())
+	assert.Equal([]Price{
+		{ID: "1", Event: "price", Retry: 3 * time.Second, Data: "{\"symbol\": \"ACME\",\n\"price\": 12.5}", Symbol: "ACME", Price: 12.5},
+		{ID: "1", Event: "message", Retry: 3 * time.Second, Data: "plain"},
+		{ID: "2", Event: "price", Retry: 3 * time.Second, Data: "{\"symbol\": \"INIT\", \"price\": 1}", Symbol: "INIT", Price: 1},
+	}, prices)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r, w := io.Pipe()
+	s = scanner.NewEventStream(ctx, r)
+	defer s.Close()
+
+	go w.Write([]byte("data: first\n\n"))
+	assert.True(s.Next())
+	assert.Equal("first", string(s.Event().Data))
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	assert.False(s.Next())
+	assert.ErrorIs(s.Err(), context.Canceled)
+}
+
+type frames [][2]any
+
+func (f *frames) ReadMessage() (int, []byte, error) {
+	if len(*f) == 0 {
+		return 0, nil, io.EOF
+	}
+	frame := (*f)[0]
+	*f = (*f)[1:]
+	return frame[0].(int), frame[1].([]byte), nil
+}
+
+func TestWebSocket(t *testing.T) {
+	assert := assert.New(t)
+
+	type Join struct {
+		Room string    `json:"room" msgpack:"room"`
+		User string    `json:"user" msgpack:"user"`
+		At   time.Time `msgpack:"at"`
+	}
+	type Chat struct {
+		Room string `json:"room"`
+		Text string `json:"text"`
+	}
+
+	// {"type": "join", "room": "go", "user": "ada", "at": timestamp 1700000000}
+	packed := []byte{0x85,
+		0xa4, 't', 'y', 'p', 'e', 0xa4, 'j', 'o', 'i', 'n',
+		0xa4, 'r', 'o', 'o', 'm', 0xa2, 'g', 'o',
+		0xa4, 'u', 's', 'e', 'r', 0xa3, 'a', 'd', 'a',
+		0xa2, 'a', 't', 0xd6, 0xff, 0x65, 0x53, 0xf1, 0x00,
+		0xa3, 'a', 'g', 'e', 0xcd, 0x01, 0x00,
+	}
+	conn := &frames{
+		{scanner.TextFrame, []byte(`{"type": "join", "room": "go", "user": "grace"}`)},
+		{9, []byte("ping")},
+		{scanner.BinaryFrame, packed},
+		{scanner.TextFrame, []byte(`{"type": "chat", "room": "go", "text": "hi"}`)},
+	}
+
+	joins := []Join{}
+	chats := []Chat{}
+	err := scanner.NewWebSocket(conn).Dispatch(context.Background(), "type", map[string]scanner.FrameHandler{
+		"join": scanner.HandleFrame(func(j *Join) error {
+			joins = append(joins, *j)
+			return nil
+		}),
+		"chat": scanner.HandleFrame(func(c *Chat) error {
+			chats = append(chats, *c)
+			return nil
+		}),
+	})
+	assert.NoError(err)
+	assert.Equal([]Join{
+		{Room: "go", User: "grace"},
+		{Room: "go", User: "ada", At: time.Unix(1700000000, 0).UTC()},
+	}, joins)
+	assert.Equal([]Chat{{Room: "go", Text: "hi"}}, chats)
+
+	conn = &frames{{scanner.TextFrame, []byte(`{"type": "leave"}`)}}
+	err = scanner.NewWebSocket(conn).Dispatch(context.Background(), "type", map[string]scanner.FrameHandler{})
+	var unknown *scanner.UnknownFrameError
+	assert.ErrorAs(err, &unknown)
+	assert.Equal("leave", unknown.Kind)
+
+	conn = &frames{{scanner.TextFrame, []byte(`{"room": "go", "text": "bye"}`)}}
+	ws := scanner.NewWebSocket(conn)
+	assert.True(ws.Next())
+	c := Chat{}
+	assert.NoError(ws.Scan(&c))
+	assert.Equal(Chat{Room: "go", Text: "bye"}, c)
+	assert.False(ws.Next())
+	assert.NoError(ws.Err())
+}
+
+func TestProtobufScanner(t *testing.T) {
+	assert := assert.New(t)
+
+	api := &apipb.Api{
+		Name:          "library.Books",
+		Version:       "v1",
+		Methods:       []*apipb.Method{{Name: "GetBook"}, {Name: "WatchBooks", ResponseStreaming: true}},
+		SourceContext: &sourcecontextpb.SourceContext{FileName: "library.proto"},
+		Syntax:        typepb.Syntax_SYNTAX_PROTO3,
+	}
+	body, err := proto.Marshal(api)
+	assert.NoError(err)
+
+	decoded := &apipb.Api{}
+	assert.NoError(scanner.NewProtobuf(bytes.NewReader(body), nil).Scan(decoded))
+	assert.True(proto.Equal(api, decoded))
+
+	type Method struct {
+		Name      string `proto:"name"`
+		Streaming bool   `proto:"responseStreaming"`
+	}
+	type Source struct {
+		File string `proto:"file_name"`
+	}
+	type API struct {
+		Name    string   `proto:"name"`
+		Version string   `proto:"version"`
+		Methods []Method `proto:"methods"`
+		Source  *Source  `proto:"source_context"`
+		Syntax  string   `proto:"syntax"`
+	}
+
+	a := API{}
+	assert.NoError(scanner.NewProtobuf(bytes.NewReader(body), &apipb.Api{}).Scan(&a))
+	assert.Equal(API{
+		Name:    "library.Books",
+		Version: "v1",
+		Methods: []Method{{Name: "GetBook"}, {Name: "WatchBooks", Streaming: true}},
+		Source:  &Source{File: "library.proto"},
+		Syntax:  "SYNTAX_PROTO3",
+	}, a)
+
+	assert.ErrorIs(scanner.NewProtobuf(bytes.NewReader(body), nil).Scan(&a), scanner.ErrNoProtoMessage)
+}
+
+func TestGobScanner(t *testing.T) {
+	assert := assert.New(t)
+
+	type Entry struct {
+		Key     string
+		Value   []byte
+		Expires time.Time
+	}
+	entries := []Entry{
+		{Key: "a", Value: []byte("1"), Expires: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Key: "b", Value: []byte("2")},
+	}
+
+	buf := &bytes.Buffer{}
+	e := gob.NewEncoder(buf)
+	for _, entry := range entries {
+		assert.NoError(e.Encode(entry))
+	}
+
+	s := scanner.NewGob(buf)
+	for _, expected := range entries {
+		entry := Entry{}
+		assert.NoError(s.Scan(&entry))
+		assert.Equal(expected, entry)
+	}
+	assert.ErrorIs(s.Scan(&Entry{}), io.EOF)
+}
+
+func TestHARScanner(t *testing.T) {
+	assert := assert.New(t)
+
+	har := `{"log": {"version": "1.2", "entries": [
+		{
+			"startedDateTime": "2024-05-01T12:00:00.000Z",
+			"request": {
+				"method": "POST",
+				"url": "https://shop.example.com/orders/42/items?notify=true",
+				"httpVersion": "HTTP/2",
+				"headers": [{"name": ":authority", "value": "shop.example.com"}, {"name": "X-Request-Id", "value": "abc"}],
+				"cookies": [{"name": "session", "value": "s3cr3t"}],
+				"postData": {"mimeType": "application/json", "text": "{\"sku\": \"KB-1\", \"quantity\": 2}"}
+			},
+			"response": {"status": 201, "statusText": "Created", "headers": []}
+		},
+		{
+			"startedDateTime": "2024-05-01T12:00:01.000Z",
+			"request": {
+				"method": "POST",
+				"url": "https://shop.example.com/login",
+				"headers": [],
+				"postData": {"mimeType": "application/x-www-form-urlencoded", "params": [{"name": "email", "value": "a@example.com"}]}
+			},
+			"response": {"status": 302, "statusText": "Found", "headers": []}
+		}
+	]}}`
+
+	h, err := scanner.NewHAR(strings.NewReader(har))
+	assert.NoError(err)
+	assert.Len(h.Entries, 2)
+	assert.Equal(201, h.Entries[0].Response.Status)
+
+	type AddItem struct {
+		Order     int    `path:"id"`
+		Notify    bool   `query:"notify"`
+		RequestID string `header:"x-request-id"`
+		Session   string `cookie:"session"`
+		SKU       string `json:"sku"`
+		Quantity  int    `json:"quantity"`
+	}
+
+	requests, err := h.Route("POST /orders/{id}/items")
+	assert.NoError(err)
+	assert.Len(requests, 1)
+
+	item := AddItem{}
+	assert.NoError(requests[0].Scan(&item))
+	assert.Equal(AddItem{Order: 42, Notify: true, RequestID: "abc", Session: "s3cr3t", SKU: "KB-1", Quantity: 2}, item)
+
+	type Login struct {
+		Email string `form:"email"`
+	}
+	login := Login{}
+	assert.NoError(h.Entries[1].Scan(&login))
+	assert.Equal("a@example.com", login.Email)
+}
+
+func TestPatternScanner(t *testing.T) {
+	assert := assert.New(t)
+
+	type Shipment struct {
+		ID      int       `pattern:"id"`
+		At      time.Time `pattern:"time,layout=2006-01-02 15:04"`
+		Carrier string    `pattern:"carrier,upper"`
+	}
+
+	s, err := scanner.NewPattern("order #{id:\\d+} shipped at {time} via {carrier}.", "order #1042 shipped at 2024-06-01 14:30 via ups.")
+	assert.NoError(err)
+	shipment := Shipment{}
+	assert.NoError(s.Scan(&shipment))
+	assert.Equal(Shipment{ID: 1042, At: time.Date(2024, 6, 1, 14, 30, 0, 0, time.UTC), Carrier: "UPS"}, shipment)
+
+	p := scanner.MustParsePattern("{{{level}}} {year:\\d{4}}: {message}")
+	type Log struct {
+		Level   string `pattern:"level"`
+		Year    int    `pattern:"year"`
+		Message string `pattern:"message"`
+	}
+	l := Log{}
+	assert.NoError(p.Scanner("{warn} 2024: disk almost full").Scan(&l))
+	assert.Equal(Log{Level: "warn", Year: 2024, Message: "disk almost full"}, l)
+
+	assert.ErrorIs(p.Scanner("warn 2024: disk").Scan(&l), scanner.ErrPatternMismatch)
+
+	_, err = scanner.ParsePattern("order {id")
+	assert.Error(err)
+	_, err = scanner.ParsePattern("{id} and {id}")
+	assert.Error(err)
+}
+
+type Quantity int
+
+func (q *Quantity) UnmarshalParam(key, value, source string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return fmt.Errorf("%s %q must be a positive number", source, key)
+	}
+	*q = Quantity(n)
+	return nil
+}
+
+func TestParamUnmarshaler(t *testing.T) {
+	assert := assert.New(t)
+
+	type Order struct {
+		Quantity Quantity   `query:"qty" header:"x-qty"`
+		Extra    []Quantity `header:"x-extra"`
+	}
+
+	query := url.Values{"qty": {"3"}}
+	header := http.Header{"X-Extra": {"1, 2"}}
+	o := Order{}
+	assert.NoError(scanner.NewPipe(scanner.NewQuery(&query), scanner.NewHeader(&header)).Scan(&o))
+	assert.Equal(Order{Quantity: 3, Extra: []Quantity{1, 2}}, o)
+
+	query = url.Values{"qty": {"-1"}}
+	err := scanner.NewQuery(&query).Scan(&o)
+	assert.ErrorContains(err, `query "qty" must be a positive number`)
+
+	header = http.Header{"X-Extra": {"1, x"}}
+	err = scanner.NewHeader(&header).Scan(&o)
+	assert.ErrorContains(err, `header "x-extra" must be a positive number`)
+}
+
+func TestWithTag(t *testing.T) {
+	assert := assert.New(t)
+
+	type Search struct {
+		Term  string `url:"q"`
+		Page  int    `url:"page"`
+		Agent string `hdr:"user-agent"`
+		Query string `query:"q"`
+	}
+
+	query := url.Values{"q": {"gophers"}, "page": {"2"}}
+	header := http.Header{"User-Agent": {"curl/8.4.0"}}
+
+	s := Search{}
+	assert.NoError(scanner.NewPipe(
+		scanner.NewQuery(&query, scanner.WithTag("url")),
+		scanner.NewHeader(&header, scanner.WithTag("hdr")),
+	).Scan(&s))
+	assert.Equal(Search{Term: "gophers", Page: 2, Agent: "curl/8.4.0"}, s)
+
+	type Document struct {
+		Title  string `yaml:"title"`
+		Author struct {
+			Name string `yaml:"name"`
+		} `yaml:"author"`
+	}
+	d := Document{}
+	assert.NoError(scanner.NewBSON(map[string]any{"title": "Tags", "author": map[string]any{"name": "Ada"}}, scanner.WithTag("yaml")).Scan(&d))
+	assert.Equal("Tags", d.Title)
+	assert.Equal("Ada", d.Author.Name)
+
+	req := httptest.NewRequest(http.MethodGet, "/?q=gophers", nil)
+	s = Search{}
+	assert.NoError(scanner.NewRequest(req, scanner.WithTag("url")).Scan(&s))
+	assert.Equal(Search{Query: "gophers"}, s)
+}
+
+func TestInto(t *testing.T) {
+	assert := assert.New(t)
+
+	type Page struct {
+		Page int `query:"page"`
+	}
+
+	query := url.Values{"page": {"3"}}
+	p, err := scanner.Into[Page](scanner.NewQuery(&query))
+	assert.NoError(err)
+	assert.Equal(&Page{Page: 3}, p)
+
+	v, err := scanner.ScanValue[Page](scanner.NewQuery(&query))
+	assert.NoError(err)
+	assert.Equal(Page{Page: 3}, v)
+
+	assert.Equal(&Page{Page: 3}, scanner.MustInto[Page](scanner.NewQuery(&query)))
+
+	query = url.Values{"page": {"x"}}
+	p, err = scanner.Into[Page](scanner.NewQuery(&query))
+	assert.Error(err)
+	assert.Nil(p)
+	assert.Panics(func() { scanner.MustInto[Page](scanner.NewQuery(&query)) })
+}
+
+func TestOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	type User struct {
+		Name string `json:"name"`
+	}
+	u := User{}
+	assert.NoError(scanner.NewJSONBytes([]byte(`{"name": "ada", "admin": true}`)).Scan(&u))
+	err := scanner.NewJSONBytes([]byte(`{"name": "ada", "admin": true}`), scanner.WithStrict()).Scan(&u)
+	assert.ErrorContains(err, "unknown field")
+
+	logs := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(logs, nil))
+	assert.NoError(scanner.NewOptional(scanner.NewJSONBytes([]byte(`{`)), scanner.WithLogger(logger)).Scan(&u))
+	assert.Contains(logs.String(), "optional source failed")
+
+	type Config struct {
+		Port  int      `file:"port"`
+		Hosts []string `header:"x-hosts"`
+	}
+	fsys := FS{Files: map[string]*File{"port": NewFile("port", []byte("8080\n"))}}
+	hexInt := func(from any, to reflect.Type) (any, error) {
+		b, ok := from.([]byte)
+		if !ok || to.Kind() != reflect.Int {
+			return nil, errors.ErrUnsupported
+		}
+		return strconv.Atoi(strings.TrimSpace(string(b)))
+	}
+
+	s, err := scanner.NewDirectory(fsys, scanner.WithCaster(hexInt))
+	assert.NoError(err)
+	c := Config{}
+	assert.NoError(s.Scan(&c))
+	assert.Equal(8080, c.Port)
+
+	header := http.Header{"X-Hosts": {"a, b"}}
+	after := false
+	h := scanner.NewHeader(&header, scanner.WithCaster(hexInt), scanner.WithDecoderOptions(structd.WithAfterDecode(func(v any) error {
+		after = true
+		return nil
+	})))
+	assert.NoError(h.Scan(&c))
+	assert.Equal([]string{"a", "b"}, c.Hosts)
+	assert.True(after)
+}
+
+type Listing struct {
+	Page int    `query:"page"`
+	Sort string `query:"sort,lower"`
+}
+
+// DecodeGenerated is the output of scannergen for Listing
+func (v *Listing) DecodeGenerated(d *structd.Decoder) (bool, error) {
+	switch d.Key() {
+	case "query":
+		if raw, ok := d.Get("page").(string); !ok {
+			if err := d.DecodeField(v, "Page"); err != nil {
+				return true, err
+			}
+		} else if raw != "" {
+			if parsed, err := strconv.ParseInt(raw, 10, 0); err == nil {
+				v.Page = int(parsed)
+			} else if err := d.DecodeField(v, "Page"); err != nil {
+				return true, err
+			}
+		}
+		if err := d.DecodeField(v, "Sort"); err != nil {
+			return true, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+func TestGeneratedDecoder(t *testing.T) {
+	assert := assert.New(t)
+
+	query := url.Values{"page": {"4"}, "sort": {"NAME"}}
+	l := Listing{}
+	assert.NoError(scanner.NewQuery(&query).Scan(&l))
+	assert.Equal(Listing{Page: 4, Sort: "name"}, l)
+
+	type Reflective struct {
+		Page int `query:"page"`
+	}
+	query = url.Values{"page": {"four"}}
+	generatedErr := scanner.NewQuery(&query).Scan(&Listing{})
+	reflectiveErr := scanner.NewQuery(&query).Scan(&Reflective{})
+	assert.Error(generatedErr)
+	assert.Equal(reflectiveErr.Error(), generatedErr.Error())
+
+	header := http.Header{}
+	assert.NoError(scanner.NewHeader(&header).Scan(&l))
+	assert.Equal(Listing{Page: 4, Sort: "name"}, l)
+}
+
+func BenchmarkScanners(b *testing.B) {
+	type Params struct {
+		ID      int      `path:"id" bson:"id" bin:"offset=0,len=4"`
+		Page    int      `query:"page,min=1" form:"page" bson:"page"`
+		Name    string   `query:"name,trim" form:"name" bson:"name" cookie:"name"`
+		Tags    []string `query:"tags" header:"x-tags"`
+		Agent   string   `header:"user-agent"`
+		Session string   `cookie:"session"`
+		Active  bool     `form:"active"`
+	}
+
+	query := url.Values{"page": {"2"}, "name": {" ada "}, "tags": {"a,b,c"}}
+	form := url.Values{"page": {"2"}, "name": {"ada"}, "active": {"true"}}
+	header := http.Header{"User-Agent": {"bench"}, "X-Tags": {"a, b, c"}}
+	cookies := []*http.Cookie{{Name: "session", Value: "abc"}, {Name: "name", Value: "ada"}}
+	req := httptest.NewRequest(http.MethodGet, "/users/42?page=2&name=ada", nil)
+	req.SetPathValue("id", "42")
+	doc := map[string]any{"id": int32(42), "page": int64(2), "name": "ada"}
+	body := []byte(`{"ID": 42, "Page": 2, "Name": "ada", "Tags": ["a", "b"]}`)
+	packet := []byte{0, 0, 0, 42}
+
+	scanners := map[string]func() scanner.Scanner{
+		"JSON":    func() scanner.Scanner { return scanner.NewJSONBytes(body) },
+		"Query":   func() scanner.Scanner { return scanner.NewQuery(&query) },
+		"Form":    func() scanner.Scanner { return scanner.NewForm(&form) },
+		"Header":  func() scanner.Scanner { return scanner.NewHeader(&header) },
+		"Cookie":  func() scanner.Scanner { return scanner.NewCookie(cookies) },
+		"Path":    func() scanner.Scanner { return scanner.NewPath(req) },
+		"Request": func() scanner.Scanner { return scanner.NewRequest(req) },
+		"BSON":    func() scanner.Scanner { return scanner.NewBSON(doc) },
+		"Binary":  func() scanner.Scanner { return scanner.NewBinaryBytes(packet) },
+	}
+
+	for name, create := range scanners {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				p := Params{}
+				if err := create().Scan(&p); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+type Level int
+
+type Levels []Level
+
+func FuzzDefaultCast(f *testing.F) {
+	for _, seed := range []string{"", "0", "-1", "1e309", "18446744073709551616", "a,b,,c", "1,2,x", "true", "\xff\xfe", strings.Repeat("1,", 1000)} {
+		f.Add(seed)
+	}
+
+	types := []reflect.Type{
+		reflect.TypeFor[int](), reflect.TypeFor[int8](), reflect.TypeFor[uint16](), reflect.TypeFor[float32](),
+		reflect.TypeFor[bool](), reflect.TypeFor[string](), reflect.TypeFor[[]int](), reflect.TypeFor[[]string](),
+		reflect.TypeFor[Level](), reflect.TypeFor[Levels](), reflect.TypeFor[[]Level](), reflect.TypeFor[[][]int](),
+		reflect.TypeFor[[]byte](), reflect.TypeFor[sql.NullInt64](), reflect.TypeFor[map[string]int](),
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		for _, to := range types {
+			v, err := structd.DefaultCast(s, to)
+			if err == nil && v != nil && !reflect.TypeOf(v).ConvertibleTo(to) {
+				t.Fatalf("cast of %q to %s returned %T", s, to, v)
+			}
+		}
+	})
+}
+
+func FuzzQueryScanner(f *testing.F) {
+	for _, seed := range []string{"page=1&tags=a,b", "page=-0&level=3&levels=1,2", "page=99999999999999999999", "tags=%ff%fe", "name=" + strings.Repeat("x,", 500)} {
+		f.Add(seed)
+	}
+
+	type Params struct {
+		Page   int       `query:"page,min=0" form:"page" header:"x-page"`
+		Ratio  float32   `query:"ratio" form:"ratio"`
+		Name   string    `query:"name,trim,maxlen=64" form:"name" header:"x-name"`
+		Tags   []string  `query:"tags" form:"tags" header:"x-tags"`
+		IDs    []uint8   `query:"ids" form:"ids" header:"x-ids"`
+		Level  Level     `query:"level" form:"level" header:"x-level"`
+		Levels Levels    `query:"levels" form:"levels" header:"x-levels"`
+		At     time.Time `query:"at" form:"at" header:"x-at"`
+		Flag   bool      `query:"flag" form:"flag"`
+	}
+
+	f.Fuzz(func(t *testing.T, raw string) {
+		values, err := url.ParseQuery(raw)
+		if err != nil {
+			return
+		}
+
+		scanner.NewQuery(&values).Scan(&Params{})
+		scanner.NewForm(&values).Scan(&Params{})
+
+		header := http.Header{}
+		for key, entries := range values {
+			for _, entry := range entries {
+				header.Add("X-"+key, entry)
+			}
+		}
+		scanner.NewHeader(&header).Scan(&Params{})
+	})
+}
+
+func TestScanAll(t *testing.T) {
+	assert := assert.New(t)
+
+	type Event struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	items := []scanner.Scanner{}
+	for i := range 50 {
+		body := fmt.Sprintf(`{"id": %d, "name": "event-%d"}`, i, i)
+		if i == 7 {
+			body = `{"id": "seven"}`
+		}
+		items = append(items, scanner.NewJSONBytes([]byte(body)))
+	}
+
+	results, errs := scanner.ScanAll[Event](items, 4)
+	assert.Len(results, 50)
+	assert.Len(errs, 50)
+	for i := range 50 {
+		if i == 7 {
+			assert.Error(errs[i])
+			assert.Nil(results[i])
+			continue
+		}
+		assert.NoError(errs[i])
+		assert.Equal(&Event{ID: i, Name: fmt.Sprintf("event-%d", i)}, results[i])
+	}
+
+	results, errs = scanner.ScanAll[Event](nil, 0)
+	assert.Empty(results)
+	assert.Empty(errs)
+}
+
+func TestDecodePanicError(t *testing.T) {
+	assert := assert.New(t)
+
+	type Params struct {
+		Page int    `query:"page"`
+		Name string `query:"name"`
+	}
+
+	query := url.Values{"page": {"1"}, "name": {"ada"}}
+	s := scanner.NewQuery(&query, scanner.WithDecoderOptions(structd.WithBeforeField(func(field reflect.StructField, raw any) (any, error) {
+		if field.Name == "Name" {
+			var m map[string]string
+			m["boom"] = raw.(string)
+		}
+		return raw, nil
+	})))
+
+	p := Params{}
+	err := s.Scan(&p)
+	var perr *structd.DecodePanicError
+	assert.ErrorAs(err, &perr)
+	assert.Equal("Params", perr.Struct)
+	assert.Equal("Name", perr.Field)
+	assert.NotEmpty(perr.Stack)
+	assert.ErrorContains(err, "structd: panic while decoding Params.Name: assignment to entry in nil map")
+
+	var rerr runtime.Error
+	assert.ErrorAs(err, &rerr)
+}
+
+func TestQueryValueSources(t *testing.T) {
+	assert := assert.New(t)
+
+	type Params struct {
+		Page int    `query:"page" form:"page"`
+		Name string `query:"name" form:"name"`
+	}
+	expected := Params{Page: 2, Name: "ada"}
+
+	values := url.Values{"page": {"2"}, "name": {"ada"}}
+	raw := map[string][]string{"page": {"2"}, "name": {"ada"}}
+
+	scanners := []scanner.Scanner{
+		scanner.NewQuery(&values),
+		scanner.NewQuery(values),
+		scanner.NewQuery(raw),
+		scanner.NewForm(values),
+		scanner.NewForm(raw),
+	}
+	s, err := scanner.NewQueryString("?page=2&name=ada")
+	assert.NoError(err)
+	scanners = append(scanners, s)
+
+	for _, s := range scanners {
+		p := Params{}
+		assert.NoError(s.Scan(&p))
+		assert.Equal(expected, p)
+	}
+
+	_, err = scanner.NewQueryString("page=%zz")
+	assert.Error(err)
+}
+
+func TestCookieCodec(t *testing.T) {
+	assert := assert.New(t)
+
+	type Session struct {
+		User string `cookie:"user"`
+		Role string `cookie:"role"`
+	}
+
+	old := []byte("0123456789abcdef0123456789abcdef")
+	current := []byte("fedcba9876543210fedcba9876543210")
+
+	signed, err := scanner.NewSignedCookies(current, old)
+	assert.NoError(err)
+	encrypted, err := scanner.NewEncryptedCookies(current, old)
+	assert.NoError(err)
+	rotated, err := scanner.NewEncryptedCookies(old)
+	assert.NoError(err)
+
+	for _, codec := range []scanner.CookieCodec{signed, encrypted} {
+		user, err := codec.Encode("user", "ada")
+		assert.NoError(err)
+		role, err := codec.Encode("role", "admin")
+		assert.NoError(err)
+
+		cookies := []*http.Cookie{{Name: "user", Value: user}, {Name: "role", Value: role}}
+		s := Session{}
+		assert.NoError(scanner.NewCookie(cookies, scanner.WithCookieCodec(codec)).Scan(&s))
+		assert.Equal(Session{User: "ada", Role: "admin"}, s)
+
+		// a value moved to another cookie does not verify
+		cookies = []*http.Cookie{{Name: "role", Value: user}}
+		err = scanner.NewCookie(cookies, scanner.WithCookieCodec(codec)).Scan(&Session{})
+		assert.ErrorIs(err, scanner.ErrTamperedCookie)
+		var cookieErr *scanner.CookieError
+		assert.ErrorAs(err, &cookieErr)
+		assert.Equal("role", cookieErr.Name)
+	}
+
+	// values encrypted with a rotated key still decode
+	user, err := rotated.Encode("user", "grace")
+	assert.NoError(err)
+	s := Session{}
+	assert.NoError(scanner.NewCookie([]*http.Cookie{{Name: "user", Value: user}}, scanner.WithCookieCodec(encrypted)).Scan(&s))
+	assert.Equal("grace", s.User)
+
+	err = scanner.NewCookie([]*http.Cookie{{Name: "user", Value: "ada"}}, scanner.WithCookieCodec(signed)).Scan(&Session{})
+	assert.ErrorIs(err, scanner.ErrTamperedCookie)
+
+	_, err = scanner.NewEncryptedCookies([]byte("short"))
+	assert.Error(err)
+}
+
+func TestCSRF(t *testing.T) {
+	assert := assert.New(t)
+
+	type Params struct {
+		Token string `csrf:"token"`
+		Title string `form:"title"`
+	}
+
+	validate := scanner.CSRFDoubleSubmit("csrf")
+	newRequest := func(method, body string) *http.Request {
+		req := httptest.NewRequest(method, "/posts", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: "csrf", Value: "s3cret"})
+		return req
+	}
+
+	req := newRequest(http.MethodPost, "title=hello&csrf_token=s3cret")
+	p := Params{}
+	s := scanner.NewPipe(
+		scanner.NewCSRF(req, scanner.DefaultCSRFSource, validate),
+		scanner.NewRequest(req),
+	)
+	assert.NoError(s.Scan(&p))
+	assert.Equal(Params{Token: "s3cret", Title: "hello"}, p)
+
+	req = newRequest(http.MethodPost, "title=hello")
+	req.Header.Set("X-CSRF-Token", "s3cret")
+	assert.NoError(scanner.NewCSRF(req, scanner.DefaultCSRFSource, validate).Scan(&Params{}))
+
+	req = newRequest(http.MethodPost, "csrf_token=forged")
+	err := scanner.NewCSRF(req, scanner.DefaultCSRFSource, validate).Scan(&Params{})
+	var csrfErr *scanner.CSRFError
+	assert.ErrorAs(err, &csrfErr)
+	assert.ErrorIs(err, scanner.ErrInvalidCSRFToken)
+
+	req = newRequest(http.MethodDelete, "")
+	err = scanner.NewCSRF(req, scanner.DefaultCSRFSource, validate).Scan(&Params{})
+	assert.ErrorIs(err, scanner.ErrMissingCSRFToken)
+
+	req = newRequest(http.MethodGet, "")
+	assert.NoError(scanner.NewCSRF(req, scanner.DefaultCSRFSource, validate).Scan(&Params{}))
+
+	// a token read from the cookie it is checked against is not verified
+	req = newRequest(http.MethodPost, "")
+	err = scanner.NewCSRF(req, scanner.CSRFSource{Cookie: "csrf"}, validate).Scan(&Params{})
+	assert.ErrorIs(err, scanner.ErrCSRFTokenFromCookie)
+
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	w.WriteField("title", "hello")
+	w.WriteField("csrf_token", "s3cret")
+	w.Close()
+	req = newRequest(http.MethodPost, body.String())
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	p = Params{}
+	assert.NoError(scanner.NewPipe(scanner.NewCSRF(req, scanner.DefaultCSRFSource, validate), scanner.NewRequest(req)).Scan(&p))
+	assert.Equal(Params{Token: "s3cret", Title: "hello"}, p)
+}
+
+func TestList(t *testing.T) {
+	assert := assert.New(t)
+
+	type Params struct {
+		Page   scanner.Page   `list:"page"`
+		Sort   scanner.Sort   `list:"sort"`
+		Filter scanner.Filter `list:"filter"`
+		Search string         `query:"q"`
+	}
+
+	options := scanner.ListOptions{
+		SortColumns:  []string{"created_at", "name"},
+		FilterFields: []string{"status"},
+		MaxSize:      50,
+	}
+	values, _ := url.ParseQuery("page=2&per_page=50&sort=-created_at,name&filter[status]=open&q=go")
+
+	p := Params{}
+	s := scanner.NewPipe(scanner.NewList(values, options), scanner.NewQuery(values))
+	assert.NoError(s.Scan(&p))
+	assert.Equal(scanner.Page{Number: 2, Size: 50}, p.Page)
+	assert.Equal(50, p.Page.Offset())
+	assert.Equal(scanner.Sort{
+		{Column: "created_at", Direction: scanner.Descending},
+		{Column: "name", Direction: scanner.Ascending},
+	}, p.Sort)
+	assert.Equal(scanner.Filter{"status": "open"}, p.Filter)
+	assert.Equal("go", p.Search)
+
+	p = Params{}
+	assert.NoError(scanner.NewList(url.Values{}, options).Scan(&p))
+	assert.Equal(scanner.Page{Number: 1, Size: 20}, p.Page)
+	assert.Empty(p.Sort)
+
+	for _, query := range []string{"page=0", "per_page=51", "sort=password", "filter[owner]=me"} {
+		values, _ := url.ParseQuery(query)
+		err := scanner.NewList(values, options).Scan(&Params{})
+		var listErr *scanner.ListError
+		assert.ErrorAs(err, &listErr, query)
+	}
+
+	type Unchecked struct {
+		Sort scanner.Sort `query:"sort"`
+	}
+	u := Unchecked{}
+	assert.NoError(scanner.NewQuery(url.Values{"sort": {"+name,-id"}}).Scan(&u))
+	assert.Equal(scanner.Descending, u.Sort[1].Direction)
+}
+
+func TestFieldMask(t *testing.T) {
+	assert := assert.New(t)
+
+	type Params struct {
+		Fields scanner.FieldMask `query:"fields"`
+		Paths  scanner.FieldMask `query:"paths"`
+	}
+
+	p := Params{}
+	values := url.Values{"fields": {"id,users(name,address(city)),title"}, "paths": {"users.id,users.email"}}
+	assert.NoError(scanner.NewQuery(values).Scan(&p))
+	assert.Equal(scanner.FieldMask{"id", "users.name", "users.address.city", "title"}, p.Fields)
+	assert.Equal(scanner.FieldMask{"users.id", "users.email"}, p.Paths)
+
+	assert.True(p.Fields.Has("users"))
+	assert.True(p.Fields.Has("users.address.city"))
+	assert.False(p.Fields.Has("users.email"))
+	assert.False(p.Fields.Has("body"))
+	assert.Equal(scanner.FieldMask{"name", "address.city"}, p.Fields.Sub("users"))
+	assert.True(scanner.FieldMask{}.Has("anything"))
+
+	for _, malformed := range []string{"users(id", "users)id", "(id)", "users(id)name"} {
+		err := scanner.NewQuery(url.Values{"fields": {malformed}}).Scan(&Params{})
+		assert.Error(err, malformed)
+	}
+
+	type List struct {
+		Fields scanner.FieldMask `list:"fields"`
+	}
+	l := List{}
+	assert.NoError(scanner.NewList(url.Values{"fields": {"id,name"}}, scanner.ListOptions{}).Scan(&l))
+	assert.Equal(scanner.FieldMask{"id", "name"}, l.Fields)
+}
+
+func TestGeo(t *testing.T) {
+	assert := assert.New(t)
+
+	type Params struct {
+		Near   scanner.LatLng  `query:"near"`
+		Within scanner.BBox    `query:"bbox"`
+		Cell   scanner.Geohash `query:"cell"`
+	}
+
+	p := Params{}
+	values := url.Values{"near": {"41.0082,28.9784"}, "bbox": {"28.5,40.8,29.5,41.3"}, "cell": {"SXK9"}}
+	assert.NoError(scanner.NewQuery(values).Scan(&p))
+	assert.Equal(scanner.LatLng{Lat: 41.0082, Lng: 28.9784}, p.Near)
+	assert.True(p.Within.Contains(p.Near))
+	assert.False(p.Within.Contains(scanner.LatLng{Lat: 39.9, Lng: 32.8}))
+	assert.Equal(scanner.Geohash("sxk9"), p.Cell)
+	assert.True(p.Cell.Bounds().Contains(p.Near))
+	assert.InDelta(41.0, p.Cell.Center().Lat, 0.2)
+
+	for _, values := range []url.Values{
+		{"near": {"91,0"}},
+		{"near": {"0,181"}},
+		{"near": {"41.0"}},
+		{"bbox": {"0,10,1,5"}},
+		{"cell": {"sxka"}},
+	} {
+		assert.Error(scanner.NewQuery(values).Scan(&Params{}), values.Encode())
+	}
+
+	antimeridian := scanner.BBox{Min: scanner.LatLng{Lat: -10, Lng: 170}, Max: scanner.LatLng{Lat: 10, Lng: -170}}
+	assert.True(antimeridian.Contains(scanner.LatLng{Lat: 0, Lng: 179}))
+	assert.False(antimeridian.Contains(scanner.LatLng{Lat: 0, Lng: 0}))
+
+	type Place struct {
+		Location scanner.LatLng `json:"location"`
+	}
+	place := Place{}
+	assert.NoError(scanner.NewJSONBytes([]byte(`{ "location": { "type": "Point", "coordinates": [28.9784, 41.0082] } }`)).Scan(&place))
+	assert.Equal(scanner.LatLng{Lat: 41.0082, Lng: 28.9784}, place.Location)
+	b, _ := json.Marshal(place.Location)
+	assert.JSONEq(`{ "type": "Point", "coordinates": [28.9784, 41.0082] }`, string(b))
+	assert.Error(scanner.NewJSONBytes([]byte(`{ "location": { "type": "LineString", "coordinates": [] } }`)).Scan(&place))
+}
+
+func TestDecimal(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := map[string]string{
+		"19.99":   "19.99",
+		"-0.5":    "-0.5",
+		"+7":      "7",
+		".25":     "0.25",
+		"1.5e3":   "1500",
+		"12e-3":   "0.012",
+		"0.10":    "0.10",
+		"-0.0001": "-0.0001",
+	}
+	for input, expected := range cases {
+		d, err := scanner.ParseDecimal(input)
+		if assert.NoError(err, input) {
+			assert.Equal(expected, d.String(), input)
+		}
+	}
+	for _, input := range []string{"", "-", "1.2.3", "--1", "1e", "0x10", "1,5", "NaN"} {
+		_, err := scanner.ParseDecimal(input)
+		assert.Error(err, input)
+	}
+
+	assert.Equal(0, scanner.MustParseDecimal("0.1").Cmp(scanner.MustParseDecimal("0.10")))
+	assert.Equal(-1, scanner.MustParseDecimal("0.1").Cmp(scanner.MustParseDecimal("0.2")))
+	assert.Equal("0", scanner.Decimal{}.String())
+
+	type Params struct {
+		Price scanner.Decimal `query:"price"`
+		Total scanner.Money   `query:"total"`
+	}
+	p := Params{}
+	assert.NoError(scanner.NewQuery(url.Values{"price": {"0.30"}, "total": {"USD 19.99"}}).Scan(&p))
+	assert.Equal("0.30", p.Price.String())
+	assert.Equal("19.99 USD", p.Total.String())
+	assert.Equal(2, p.Total.Amount.Scale())
+
+	for _, total := range []string{"19.999 USD", "100.5 JPY", "19.99 XYZ", "19.99", "ten USD"} {
+		assert.Error(scanner.NewQuery(url.Values{"total": {total}}).Scan(&Params{}), total)
+	}
+
+	type Order struct {
+		Price    scanner.Decimal `json:"price"`
+		Discount scanner.Decimal `json:"discount"`
+		Total    scanner.Money   `json:"total"`
+		Shipping scanner.Money   `json:"shipping"`
+	}
+	o := Order{}
+	body := `{ "price": 0.1000000000000000055511151231257827, "discount": "0.05", "total": "42 EUR", "shipping": { "amount": 4.5, "currency": "eur" } }`
+	assert.NoError(scanner.NewJSONBytes([]byte(body)).Scan(&o))
+	assert.Equal("0.1000000000000000055511151231257827", o.Price.String())
+	assert.Equal("0.05", o.Discount.String())
+	assert.Equal(scanner.Money{Amount: scanner.MustParseDecimal("42"), Currency: "EUR"}, o.Total)
+	assert.Equal("4.5 EUR", o.Shipping.String())
+
+	b, _ := json.Marshal(o.Discount)
+	assert.Equal(`"0.05"`, string(b))
+}
+
+func TestContact(t *testing.T) {
+	assert := assert.New(t)
+
+	type Params struct {
+		Email scanner.Email `form:"email"`
+		Phone scanner.Phone `form:"phone"`
+	}
+
+	p := Params{}
+	values := url.Values{"email": {" Ada.Lovelace@Example.COM "}, "phone": {"+90 (555) 123-45.67"}}
+	assert.NoError(scanner.NewForm(values).Scan(&p))
+	assert.Equal(scanner.Email("Ada.Lovelace@example.com"), p.Email)
+	assert.Equal("Ada.Lovelace", p.Email.Local())
+	assert.Equal("example.com", p.Email.Domain())
+	assert.Equal(scanner.Phone("+905551234567"), p.Phone)
+
+	p = Params{}
+	assert.NoError(scanner.NewForm(url.Values{"email": {"ada@bücher.de"}, "phone": {"0044 20 7946 0958"}}).Scan(&p))
+	assert.Equal(scanner.Email("ada@xn--bcher-kva.de"), p.Email)
+	assert.Equal(scanner.Phone("+442079460958"), p.Phone)
+
+	for _, email := range []string{"ada", "ada@", "Ada <ada@example.com>", "ada@localhost", "a b@example.com"} {
+		assert.Error(scanner.NewForm(url.Values{"email": {email}}).Scan(&Params{}), email)
+	}
+	for _, phone := range []string{"5551234567", "+0123456789", "+90 555 ABC", "+1234", "+1234567890123456"} {
+		assert.Error(scanner.NewForm(url.Values{"phone": {phone}}).Scan(&Params{}), phone)
+	}
+}
+
+func TestImageParams(t *testing.T) {
+	assert := assert.New(t)
+
+	type Params struct {
+		Background color.Color     `query:"bg"`
+		Tint       color.NRGBA     `query:"tint"`
+		Border     color.RGBA      `query:"border"`
+		Size       image.Point     `query:"size"`
+		Crop       image.Rectangle `query:"crop"`
+	}
+
+	p := Params{}
+	values := url.Values{
+		"bg":     {"#F80"},
+		"tint":   {"rgba(255, 136, 0, 0.5)"},
+		"border": {"ff000080"},
+		"size":   {"200x300"},
+		"crop":   {"100x50+10-20"},
+	}
+	assert.NoError(scanner.NewQuery(values).Scan(&p))
+	assert.Equal(color.NRGBA{R: 255, G: 136, B: 0, A: 255}, p.Background)
+	assert.Equal(color.NRGBA{R: 255, G: 136, B: 0, A: 128}, p.Tint)
+	assert.Equal(color.RGBA{R: 128, G: 0, B: 0, A: 128}, p.Border)
+	assert.Equal(image.Pt(200, 300), p.Size)
+	assert.Equal(image.Rect(10, -20, 110, 30), p.Crop)
+
+	p = Params{}
+	assert.NoError(scanner.NewQuery(url.Values{"bg": {"rgb(1,2,3)"}, "crop": {"64x64"}}).Scan(&p))
+	assert.Equal(color.NRGBA{R: 1, G: 2, B: 3, A: 255}, p.Background)
+	assert.Equal(image.Rect(0, 0, 64, 64), p.Crop)
+
+	for _, values := range []url.Values{
+		{"bg": {"#ff000"}},
+		{"bg": {"#gggggg"}},
+		{"tint": {"rgb(256,0,0)"}},
+		{"tint": {"rgba(1,2,3)"}},
+		{"size": {"200"}},
+		{"size": {"-1x5"}},
+		{"crop": {"10x10+5"}},
+	} {
+		assert.Error(scanner.NewQuery(values).Scan(&Params{}), values.Encode())
+	}
+}
+
+func TestByteRanges(t *testing.T) {
+	assert := assert.New(t)
+
+	type Params struct {
+		Range scanner.ByteRanges `header:"range"`
+	}
+
+	p := Params{}
+	h := http.Header{"Range": {"bytes=0-1023, 2048-,-500"}}
+	assert.NoError(scanner.NewHeader(&h).Scan(&p))
+	assert.Equal(scanner.ByteRanges{{Start: 0, End: 1023}, {Start: 2048, End: -1}, {Start: -1, End: 500}}, p.Range)
+
+	resolved, err := p.Range.Resolve(4096)
+	assert.NoError(err)
+	assert.Equal(scanner.ByteRanges{{Start: 0, End: 1023}, {Start: 2048, End: 4095}, {Start: 3596, End: 4095}}, resolved)
+	assert.Equal(int64(1024), resolved[0].Length())
+	assert.Equal("bytes 2048-4095/4096", resolved[1].ContentRange(4096))
+
+	resolved, err = p.Range.Resolve(100)
+	assert.NoError(err)
+	assert.Equal(scanner.ByteRanges{{Start: 0, End: 99}, {Start: 0, End: 99}}, resolved)
+
+	_, err = scanner.ByteRanges{{Start: 500, End: -1}}.Resolve(100)
+	assert.ErrorIs(err, scanner.ErrRangeNotSatisfiable)
+	assert.Equal("bytes */100", scanner.UnsatisfiedContentRange(100))
+
+	for _, value := range []string{"items=0-1", "bytes=5-1", "bytes=-", "bytes=a-b", "bytes=", "bytes=1-2-3"} {
+		h := http.Header{"Range": {value}}
+		assert.Error(scanner.NewHeader(&h).Scan(&Params{}), value)
+	}
+}
+
+func TestRequestMeta(t *testing.T) {
+	assert := assert.New(t)
+
+	type Params struct {
+		scanner.RequestMeta
+		Page    int    `query:"page"`
+		TraceID string `meta:"trace-id"`
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/orders?page=2", nil)
+	req.Header.Set("X-Request-Id", "req-123")
+	req.Header.Set("Idempotency-Key", `"8e03978e-40d5-43e8-bc93-6894a57f9324"`)
+	req.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	p := Params{}
+	assert.NoError(scanner.NewRequest(req).Scan(&p))
+	assert.Equal(scanner.RequestMeta{
+		RequestID:      "req-123",
+		IdempotencyKey: "8e03978e-40d5-43e8-bc93-6894a57f9324",
+		TraceID:        "4bf92f3577b34da6a3ce929d0e0e4736",
+		ParentID:       "00f067aa0ba902b7",
+		Sampled:        true,
+	}, p.RequestMeta)
+	assert.Equal(2, p.Page)
+	assert.Equal(p.RequestMeta.TraceID, p.TraceID)
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-Id", "bad id\n")
+	req.Header.Set("Traceparent", "00-00000000000000000000000000000000-00f067aa0ba902b7-01")
+
+	p = Params{}
+	assert.NoError(scanner.NewMeta(req).Scan(&p))
+	assert.Len(p.RequestID, 32)
+	assert.NotEqual("bad id\n", p.RequestID)
+	assert.Empty(p.IdempotencyKey)
+	assert.Len(p.RequestMeta.TraceID, 32)
+	assert.Empty(p.ParentID)
+	assert.False(p.Sampled)
+	assert.Equal(p.RequestMeta.TraceID, p.TraceID)
+
+	type Pointer struct {
+		*scanner.RequestMeta
+	}
+	ptr := Pointer{}
+	assert.NoError(scanner.NewMeta(req).Scan(&ptr))
+	assert.NotNil(ptr.RequestMeta)
+}
+
+func TestWebhook(t *testing.T) {
+	assert := assert.New(t)
+
+	type Event struct {
+		Raw    string `webhook:"body"`
+		Action string `json:"action"`
+	}
+
+	secret := []byte("whsec")
+	body := `{ "action": "opened" }`
+	hexHMAC := func(parts ...string) string {
+		h := hmac.New(sha256.New, secret)
+		h.Write([]byte(strings.Join(parts, "")))
+		return hex.EncodeToString(h.Sum(nil))
+	}
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	cases := []struct {
+		verifier scanner.WebhookVerifier
+		headers  http.Header
+		err      error
+	}{
+		{scanner.GitHubSignature(secret), http.Header{"X-Hub-Signature-256": {"sha256=" + hexHMAC(body)}}, nil},
+		{scanner.GitHubSignature(secret), http.Header{"X-Hub-Signature-256": {"sha256=" + hexHMAC(body, " ")}}, scanner.ErrInvalidSignature},
+		{scanner.StripeSignature(secret, 5*time.Minute), http.Header{"Stripe-Signature": {"t=" + now + ",v1=deadbeef,v1=" + hexHMAC(now, ".", body)}}, nil},
+		{scanner.StripeSignature(secret, 5*time.Minute), http.Header{"Stripe-Signature": {"t=" + stale + ",v1=" + hexHMAC(stale, ".", body)}}, scanner.ErrExpiredSignature},
+		{scanner.SlackSignature(secret, 5*time.Minute), http.Header{"X-Slack-Request-Timestamp": {now}, "X-Slack-Signature": {"v0=" + hexHMAC("v0:", now, ":", body)}}, nil},
+		{scanner.SlackSignature(secret, 5*time.Minute), http.Header{"X-Slack-Signature": {"v0=" + hexHMAC("v0:", now, ":", body)}}, scanner.ErrInvalidSignature},
+	}
+
+	for i, c := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/hooks", strings.NewReader(body))
+		req.Header = c.headers
+
+		e := Event{}
+		webhook := scanner.NewWebhook(req, c.verifier)
+		err := scanner.NewPipe(webhook, scanner.NewJSON(webhook.Body())).Scan(&e)
+		if c.err != nil {
+			var webhookErr *scanner.WebhookError
+			assert.ErrorAs(err, &webhookErr, i)
+			assert.ErrorIs(err, c.err, i)
+			continue
+		}
+		assert.NoError(err, i)
+		assert.Equal(Event{Raw: body, Action: "opened"}, e, i)
+
+		replayed, _ := io.ReadAll(req.Body)
+		assert.Equal(body, string(replayed), i)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/hooks", strings.NewReader(body))
+	_, err := io.ReadAll(scanner.NewWebhook(req, scanner.GitHubSignature(secret)).Body())
+	assert.ErrorIs(err, scanner.ErrUnverifiedBody)
+}
+
+func TestRawBody(t *testing.T) {
+	assert := assert.New(t)
+
+	type Payload struct {
+		Name    string          `json:"name"`
+		Raw     []byte          `json:"-" raw:"body"`
+		Text    string          `json:"-" raw:"body"`
+		Message json.RawMessage `json:"-" raw:"body"`
+	}
+
+	body := `{ "name":  "ada" }`
+	p := Payload{}
+	assert.NoError(scanner.NewJSONBytes([]byte(body), scanner.WithRawBody()).Scan(&p))
+	assert.Equal("ada", p.Name)
+	assert.Equal(body, string(p.Raw))
+	assert.Equal(body, p.Text)
+	assert.Equal(json.RawMessage(body), p.Message)
+
+	p = Payload{}
+	assert.NoError(scanner.NewJSONBytes([]byte(body)).Scan(&p))
+	assert.Nil(p.Raw)
+
+	var tooLarge *scanner.BodyTooLargeError
+	err := scanner.NewJSONBytes([]byte(body), scanner.WithRawBody(), scanner.WithMaxBytes(4)).Scan(&p)
+	assert.ErrorAs(err, &tooLarge)
+}
+
+func TestReplay(t *testing.T) {
+	assert := assert.New(t)
+
+	type Metrics struct {
+		Name string `json:"name"`
+	}
+	type Handler struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+
+	s := scanner.NewJSONBytes([]byte(`{ "name": "ada", "age": 36 }`), scanner.WithReplay())
+	m, h := Metrics{}, Handler{}
+	assert.NoError(s.Scan(&m))
+	assert.NoError(s.Scan(&h))
+	assert.Equal("ada", m.Name)
+	assert.Equal(Handler{Name: "ada", Age: 36}, h)
+
+	fsys := FS{Files: map[string]*File{"local.txt": NewFile("local.txt", []byte("mock file"))}}
+	d, err := scanner.NewDirectory(fsys, scanner.WithReplay())
+	assert.NoError(err)
+	type Files struct {
+		Local string `file:"local.txt"`
+	}
+	for range 2 {
+		f := Files{}
+		assert.NoError(d.Scan(&f))
+		assert.Equal("mock file", f.Local)
+	}
+
+	r := bytes.NewReader([]byte("text document"))
+	values := &scanner.MultipartValues{Files: map[string]multipart.File{"document": file{Reader: r, ReaderAt: r, Seeker: r}}}
+	type Upload struct {
+		Document multipart.File `multipart:"document"`
+	}
+	mp := scanner.NewMultipart(values, scanner.WithReplay())
+	for range 2 {
+		u := Upload{}
+		assert.NoError(mp.Scan(&u))
+		b, _ := io.ReadAll(u.Document)
+		assert.Equal("text document", string(b))
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+	png.Encode(buf, image.NewNRGBA(image.Rect(0, 0, 8, 8)))
+	r = bytes.NewReader(buf.Bytes())
+	values = &scanner.MultipartValues{Files: map[string]multipart.File{"avatar": file{Reader: r, ReaderAt: r, Seeker: r}}}
+	type Avatar struct {
+		Image image.Image `image:"avatar"`
+	}
+	img := scanner.NewImage(values, scanner.WithReplay())
+	for range 2 {
+		a := Avatar{}
+		assert.NoError(img.Scan(&a))
+		assert.NotNil(a.Image)
+	}
+}
+
+func TestClose(t *testing.T) {
+	assert := assert.New(t)
+
+	local := NewFile("local.txt", []byte("mock file"))
+	d, err := scanner.NewDirectory(FS{Files: map[string]*File{"local.txt": local}})
+	assert.NoError(err)
+	assert.False(local.closed)
+
+	type Files struct {
+		Local string `file:"local.txt"`
+	}
+	assert.NoError(d.Scan(&Files{}))
+	// directory files are closed once they are read
+	assert.True(local.closed)
+
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	part, _ := w.CreateFormFile("document", "document.txt")
+	part.Write(bytes.Repeat([]byte("a"), 1024))
+	w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/", body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	values, err := scanner.MultipartValuesFromParser(req, 1, "document")
+	assert.NoError(err)
+	document := values.Files["document"]
+
+	pipe := scanner.NewPipe(d, scanner.NewOptional(scanner.NewMultipart(values)), scanner.NewImage(values))
+	assert.NoError(pipe.Close())
+	_, err = document.Read(make([]byte, 1))
+	assert.Error(err)
+
+	// closing again is a no-op
+	assert.NoError(pipe.Close())
+}
+
+func TestMultipartValuesFromReader(t *testing.T) {
+	assert := assert.New(t)
+
+	large := bytes.Repeat([]byte("a"), 2048)
+	newBody := func() (*bytes.Buffer, string) {
+		body := &bytes.Buffer{}
+		w := multipart.NewWriter(body)
+		w.WriteField("title", "ignored")
+		part, _ := w.CreateFormFile("avatar", "avatar.txt")
+		part.Write([]byte("small"))
+		part, _ = w.CreateFormFile("video", "video.txt")
+		part.Write(large)
+		w.Close()
+		return body, w.Boundary()
+	}
+
+	body, boundary := newBody()
+	values, err := scanner.MultipartValuesFromReader(multipart.NewReader(body, boundary), 100)
+	assert.NoError(err)
+	assert.Len(values.Files, 2)
+
+	_, spilled := values.Files["avatar"].(*os.File)
+	assert.False(spilled)
+	video, spilled := values.Files["video"].(*os.File)
+	assert.True(spilled)
+
+	type Upload struct {
+		Avatar multipart.File `multipart:"avatar"`
+		Video  multipart.File `multipart:"video"`
+	}
+	u := Upload{}
+	assert.NoError(scanner.NewMultipart(values).Scan(&u))
+	b, _ := io.ReadAll(u.Avatar)
+	assert.Equal("small", string(b))
+	b, _ = io.ReadAll(u.Video)
+	assert.Equal(large, b)
+
+	assert.NoError(values.Close())
+	_, err = os.Stat(video.Name())
+	assert.ErrorIs(err, fs.ErrNotExist)
+
+	body, boundary = newBody()
+	_, err = scanner.MultipartValuesFromReader(multipart.NewReader(body, boundary), 100, scanner.WithMaxBytes(1024))
+	var tooLarge *scanner.BodyTooLargeError
+	assert.ErrorAs(err, &tooLarge)
+
+	// values are kept in memory, so they are limited without a byte limit too
+	body = &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	w.WriteField("note", strings.Repeat("a", 100+10<<20+1))
+	w.Close()
+	_, err = scanner.MultipartValuesFromReader(multipart.NewReader(body, w.Boundary()), 100)
+	assert.ErrorAs(err, &tooLarge)
+	assert.Equal(int64(100+10<<20), tooLarge.Limit)
+}
+
+func TestMultipartFormValues(t *testing.T) {
+	assert := assert.New(t)
+
+	type Upload struct {
+		Title    string         `form:"title"`
+		Priority int            `form:"priority"`
+		Document multipart.File `multipart:"document"`
+	}
+
+	newRequest := func() *http.Request {
+		body := &bytes.Buffer{}
+		w := multipart.NewWriter(body)
+		w.WriteField("title", "report")
+		w.WriteField("priority", "2")
+		part, _ := w.CreateFormFile("document", "report.txt")
+		part.Write([]byte("numbers"))
+		w.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/", body)
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		return req
+	}
+
+	req := newRequest()
+	values, err := scanner.MultipartValuesFromParser(req, 1<<20, "document")
+	assert.NoError(err)
+	u := Upload{}
+	assert.NoError(scanner.NewMultipart(values).Scan(&u))
+	assert.Equal("report", u.Title)
+	assert.Equal(2, u.Priority)
+	b, _ := io.ReadAll(u.Document)
+	assert.Equal("numbers", string(b))
+
+	req = newRequest()
+	reader, err := req.MultipartReader()
+	assert.NoError(err)
+	values, err = scanner.MultipartValuesFromReader(reader, 1<<20)
+	assert.NoError(err)
+	u = Upload{}
+	assert.NoError(scanner.NewMultipart(values).Scan(&u))
+	assert.Equal("report", u.Title)
+	assert.NotNil(u.Document)
+
+	req = newRequest()
+	u = Upload{}
+	assert.NoError(scanner.NewRequest(req).Scan(&u))
+	assert.Equal("report", u.Title)
+	assert.Equal(2, u.Priority)
+}
+
+func TestMultipartChecksum(t *testing.T) {
+	assert := assert.New(t)
+
+	type Upload struct {
+		Document     multipart.File `multipart:"document,sha256=DocumentHash,md5=DocumentMD5"`
+		DocumentHash string
+		DocumentMD5  []byte
+	}
+
+	content := []byte("text document")
+	r := bytes.NewReader(content)
+	values := &scanner.MultipartValues{Files: map[string]multipart.File{"document": file{Reader: r, ReaderAt: r, Seeker: r}}}
+
+	u := Upload{}
+	assert.NoError(scanner.NewMultipart(values).Scan(&u))
+	sum := sha256.Sum256(content)
+	assert.Equal(hex.EncodeToString(sum[:]), u.DocumentHash)
+	md5sum := md5.Sum(content)
+	assert.Equal(md5sum[:], u.DocumentMD5)
+
+	b, _ := io.ReadAll(u.Document)
+	assert.Equal(content, b)
+
+	type Unknown struct {
+		Document multipart.File `multipart:"document,sha256=Missing"`
+	}
+	r.Seek(0, io.SeekStart)
+	assert.Error(scanner.NewMultipart(values).Scan(&Unknown{}))
+}
+
+func TestContentInspector(t *testing.T) {
+	assert := assert.New(t)
+
+	errInfected := errors.New("infected")
+	clam := scanner.ContentInspectorFunc(func(field string, header *multipart.FileHeader, r io.Reader) error {
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		if bytes.Contains(b, []byte("EICAR")) {
+			return errInfected
+		}
+		return nil
+	})
+
+	newRequest := func(content string) *http.Request {
+		body := &bytes.Buffer{}
+		w := multipart.NewWriter(body)
+		part, _ := w.CreateFormFile("document", "report.txt")
+		part.Write([]byte(content))
+		w.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/", body)
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		return req
+	}
+
+	type Upload struct {
+		Document multipart.File `multipart:"document"`
+	}
+
+	values, err := scanner.MultipartValuesFromParser(newRequest("clean"), 1<<20, "document")
+	assert.NoError(err)
+	assert.Equal("report.txt", values.Headers["document"].Filename)
+	u := Upload{}
+	assert.NoError(scanner.NewMultipart(values, scanner.WithInspector(clam)).Scan(&u))
+	b, _ := io.ReadAll(u.Document)
+	assert.Equal("clean", string(b))
+
+	reader, _ := newRequest("X5O!P%@AP EICAR").MultipartReader()
+	values, err = scanner.MultipartValuesFromReader(reader, 1<<20)
+	assert.NoError(err)
+	assert.Equal(int64(15), values.Headers["document"].Size)
+
+	u = Upload{}
+	err = scanner.NewMultipart(values, scanner.WithInspector(clam)).Scan(&u)
+	var rejected *scanner.RejectedUploadError
+	assert.ErrorAs(err, &rejected)
+	assert.ErrorIs(err, errInfected)
+	assert.Equal("document", rejected.Field)
+	assert.Equal("report.txt", rejected.Filename)
+	assert.Nil(u.Document)
+}
+
+func TestImageInspector(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	png.Encode(buf, image.NewNRGBA(image.Rect(0, 0, 8, 8)))
+	r := bytes.NewReader(buf.Bytes())
+	values := &scanner.MultipartValues{Files: map[string]multipart.File{"avatar": file{Reader: r, ReaderAt: r, Seeker: r}}}
+
+	type Avatar struct {
+		Image image.Image `image:"avatar"`
+	}
+	reject := scanner.ContentInspectorFunc(func(field string, header *multipart.FileHeader, r io.Reader) error {
+		return errors.New("too large")
+	})
+
+	var rejected *scanner.RejectedUploadError
+	assert.ErrorAs(t, scanner.NewImage(values, scanner.WithInspector(reject)).Scan(&Avatar{}), &rejected)
+}
+
+func TestImageFrames(t *testing.T) {
+	assert := assert.New(t)
+
+	palette := color.Palette{color.Black, color.White}
+	animation := &gif.GIF{LoopCount: 0}
+	for i := range 3 {
+		frame := image.NewPaletted(image.Rect(0, 0, 4, 2), palette)
+		frame.SetColorIndex(i, 0, 1)
+		animation.Image = append(animation.Image, frame)
+		animation.Delay = append(animation.Delay, 50)
+	}
+	buf := &bytes.Buffer{}
+	assert.NoError(gif.EncodeAll(buf, animation))
+
+	r := bytes.NewReader(buf.Bytes())
+	values := &scanner.MultipartValues{Files: map[string]multipart.File{"avatar": file{Reader: r, ReaderAt: r, Seeker: r}}}
+
+	type Avatar struct {
+		Still     image.Image       `image:"avatar"`
+		Animation *gif.GIF          `image:"avatar"`
+		Info      scanner.ImageInfo `image:"avatar"`
+	}
+
+	a := Avatar{}
+	assert.NoError(scanner.NewImage(values).Scan(&a))
+	assert.Equal(image.Rect(0, 0, 4, 2), a.Still.Bounds())
+	assert.Len(a.Animation.Image, 3)
+	assert.Equal(scanner.ImageInfo{Format: "gif", Width: 4, Height: 2, Frames: 3, Duration: 1500 * time.Millisecond, LoopCount: 0}, a.Info)
+
+	buf.Reset()
+	png.Encode(buf, image.NewNRGBA(image.Rect(0, 0, 3, 5)))
+	r = bytes.NewReader(buf.Bytes())
+	values = &scanner.MultipartValues{Files: map[string]multipart.File{"avatar": file{Reader: r, ReaderAt: r, Seeker: r}}}
+
+	type Info struct {
+		Info scanner.ImageInfo `image:"avatar"`
+	}
+	i := Info{}
+	assert.NoError(scanner.NewImage(values).Scan(&i))
+	assert.Equal(scanner.ImageInfo{Format: "png", Width: 3, Height: 5, Frames: 1, LoopCount: -1}, i.Info)
+
+	r = bytes.NewReader([]byte("not an image"))
+	values = &scanner.MultipartValues{Files: map[string]multipart.File{"avatar": file{Reader: r, ReaderAt: r, Seeker: r}}}
+	assert.Error(scanner.NewImage(values).Scan(&Avatar{}))
+}
+
+func TestImageUnsupportedFormat(t *testing.T) {
+	webp := append([]byte("RIFF\x00\x00\x00\x00WEBPVP8L"), make([]byte, 16)...)
+	r := bytes.NewReader(webp)
+	values := &scanner.MultipartValues{Files: map[string]multipart.File{"avatar": file{Reader: r, ReaderAt: r, Seeker: r}}}
+
+	type Avatar struct {
+		Image image.Image `image:"avatar"`
+	}
+
+	var unsupported *scanner.UnsupportedImageError
+	err := scanner.NewImage(values).Scan(&Avatar{})
+	assert.ErrorAs(t, err, &unsupported)
+	assert.Equal(t, "webp", unsupported.Format)
+	assert.ErrorIs(t, err, image.ErrFormat)
+}
+
+func TestImageOutput(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	png.Encode(buf, image.NewNRGBA(image.Rect(0, 0, 6, 4)))
+	r := bytes.NewReader(buf.Bytes())
+	values := &scanner.MultipartValues{Files: map[string]multipart.File{"avatar": file{Reader: r, ReaderAt: r, Seeker: r}}}
+
+	type Avatar struct {
+		Original image.Image         `image:"avatar"`
+		JPEG     scanner.ImageOutput `image:"avatar,format=jpg,quality=80"`
+		Same     scanner.ImageOutput `image:"avatar"`
+	}
+
+	a := Avatar{}
+	assert.NoError(scanner.NewImage(values).Scan(&a))
+	assert.Equal("jpeg", a.JPEG.Format)
+	assert.Equal(image.Rect(0, 0, 6, 4), a.JPEG.Image.Bounds())
+	decoded, format, err := image.Decode(a.JPEG.Reader())
+	assert.NoError(err)
+	assert.Equal("jpeg", format)
+	assert.Equal(image.Rect(0, 0, 6, 4), decoded.Bounds())
+	assert.Equal("png", a.Same.Format)
+	assert.Equal(a.Original.Bounds(), a.Same.Image.Bounds())
+
+	type Invalid struct {
+		Output scanner.ImageOutput `image:"avatar,quality=0"`
+	}
+	assert.Error(scanner.NewImage(values).Scan(&Invalid{}))
+
+	type Unknown struct {
+		Output scanner.ImageOutput `image:"avatar,format=bmp"`
+	}
+	assert.Error(scanner.NewImage(values).Scan(&Unknown{}))
+}
+
+func TestImagePlaceholder(t *testing.T) {
+	assert := assert.New(t)
+
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 30))
+	for y := range 30 {
+		for x := range 40 {
+			img.Set(x, y, color.NRGBA{uint8(x * 6), uint8(y * 8), uint8((x + y) * 3), 255})
+		}
+	}
+	assert.Equal("LqG91z2kwzX5l@WYjtf7gKfkfQfj", scanner.Blurhash(img))
+
+	buf := &bytes.Buffer{}
+	red := image.NewNRGBA(image.Rect(0, 0, 100, 100))
+	draw.Draw(red, red.Bounds(), image.NewUniform(color.NRGBA{R: 250, G: 10, B: 20, A: 255}), image.Point{}, draw.Src)
+	draw.Draw(red, image.Rect(0, 0, 30, 30), image.NewUniform(color.NRGBA{B: 255, A: 255}), image.Point{}, draw.Src)
+	png.Encode(buf, red)
+	r := bytes.NewReader(buf.Bytes())
+	values := &scanner.MultipartValues{Files: map[string]multipart.File{"avatar": file{Reader: r, ReaderAt: r, Seeker: r}}}
+
+	type Avatar struct {
+		Image image.Image `image:"avatar,blurhash=Hash,color=Color"`
+		Hash  string
+		Color string
+	}
+
+	a := Avatar{}
+	assert.NoError(scanner.NewImage(values).Scan(&a))
+	assert.Equal("#fa0a14", a.Color)
+	assert.Equal(scanner.Blurhash(a.Image), a.Hash)
+	assert.Len(a.Hash, 28)
+
+	type Unknown struct {
+		Image image.Image `image:"avatar,color=Missing"`
+	}
+	assert.Error(scanner.NewImage(values).Scan(&Unknown{}))
+}
+
+func TestDirectoryOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	fsys := fstest.MapFS{
+		"config/app.yaml":        {Data: []byte("name: app")},
+		"config/secret.key":      {Data: []byte("key")},
+		"config/.git/HEAD":       {Data: []byte("ref")},
+		"config/nested/db.yaml":  {Data: []byte("host: db")},
+		"config/nested/big.yaml": {Data: bytes.Repeat([]byte("a"), 64)},
+		"other.yaml":             {Data: []byte("other")},
+	}
+
+	type Config struct {
+		App    string `file:"app.yaml"`
+		DB     string `file:"nested/db.yaml"`
+		Key    string `file:"secret.key"`
+		Head   string `file:".git/HEAD"`
+		Other  string `file:"other.yaml"`
+		Parent string `file:"../other.yaml"`
+	}
+
+	d, err := scanner.NewDirectory(fsys, scanner.WithRoot("config"), scanner.WithInclude("*.yaml"), scanner.WithExclude(".git", "big.yaml"))
+	assert.NoError(err)
+	c := Config{}
+	assert.NoError(d.Scan(&c))
+	assert.Equal(Config{App: "name: app", DB: "host: db"}, c)
+
+	// missing and filtered files leave fields of any type alone
+	type Typed struct {
+		Port    int  `file:"port"`
+		Debug   bool `file:"secret.key"`
+		Verbose bool `file:".git/HEAD"`
+	}
+	typed := Typed{Port: 8080}
+	assert.NoError(d.Scan(&typed))
+	assert.Equal(Typed{Port: 8080}, typed)
+
+	// files are opened on every scan
+	fsys["config/app.yaml"] = &fstest.MapFile{Data: []byte("name: renamed")}
+	c = Config{}
+	assert.NoError(d.Scan(&c))
+	assert.Equal("name: renamed", c.App)
+
+	type Big struct {
+		App string `file:"app.yaml"`
+		Big string `file:"nested/big.yaml"`
+	}
+	d, err = scanner.NewDirectory(fsys, scanner.WithRoot("config"), scanner.WithMaxFileSize(32))
+	assert.NoError(err)
+	assert.NoError(d.Scan(&Config{}))
+	var tooLarge *scanner.FileTooLargeError
+	assert.ErrorAs(d.Scan(&Big{}), &tooLarge)
+	assert.Equal("nested/big.yaml", tooLarge.Name)
+
+	d, err = scanner.NewDirectory(fsys, scanner.WithMaxFiles(1))
+	assert.NoError(err)
+	var tooMany *scanner.TooManyFilesError
+	assert.ErrorAs(d.Scan(&struct {
+		App string `file:"config/app.yaml"`
+		Key string `file:"config/secret.key"`
+	}{}), &tooMany)
+	assert.NoError(d.Scan(&struct {
+		App     string `file:"config/app.yaml"`
+		Missing string `file:"config/missing.yaml"`
+	}{}))
+
+	_, err = scanner.NewDirectory(fsys, scanner.WithInclude("[a-"))
+	assert.ErrorIs(err, path.ErrBadPattern)
+}
+
+func TestSecrets(t *testing.T) {
+	assert := assert.New(t)
+
+	fsys := fstest.MapFS{
+		"db_password":      {Data: []byte("hunter2\n")},
+		"signing_key":      {Data: []byte("c2lnbmluZw==\r\n")},
+		"port":             {Data: []byte("5432\n")},
+		"pool":             {Data: []byte("not a number\n")},
+		"..data/api_token": {Data: []byte("token")},
+	}
+
+	type Config struct {
+		Password string `secret:"db_password,required"`
+		Key      []byte `secret:"signing_key,base64"`
+		Port     int    `secret:"port"`
+		Region   string `secret:"region,default=eu-west-1"`
+		Token    string `secret:"..data/api_token"`
+		Optional string `secret:"optional"`
+	}
+
+	s, err := scanner.NewSecrets(fsys)
+	assert.NoError(err)
+	c := Config{}
+	assert.NoError(s.Scan(&c))
+	assert.Equal(Config{Password: "hunter2", Key: []byte("signing"), Port: 5432, Region: "eu-west-1"}, c)
+
+	var missing *scanner.MissingSecretError
+	assert.ErrorAs(s.Scan(&struct {
+		Missing string `secret:"missing,required"`
+	}{}), &missing)
+	assert.Equal("missing", missing.Name)
+
+	err = s.Scan(&struct {
+		Pool int `secret:"pool"`
+	}{})
+	assert.Error(err)
+	assert.NotContains(err.Error(), "not a number")
+
+	type ctxKey struct{}
+	source := scanner.SecretSourceFunc(func(ctx context.Context, path, key string) (string, error) {
+		if path == "kv/data/admin" {
+			t.Errorf("read the secret of an unexported field")
+		}
+		if ctx.Value(ctxKey{}) == nil {
+			return "", errors.New("scanner: no request context")
+		}
+		if path == "kv/data/db" && key == "password" {
+			return "hunter2", nil
+		}
+		return "", scanner.ErrSecretNotFound
+	})
+	type Manager struct {
+		Password string `secret:"kv/data/db#password,required"`
+		admin    string `secret:"kv/data/admin#password,required"`
+	}
+
+	m := Manager{}
+	manager := scanner.NewSecretManager(source)
+	assert.Error(manager.Scan(&m))
+	assert.NoError(manager.ScanContext(context.WithValue(context.Background(), ctxKey{}, true), &m))
+	assert.Equal(Manager{Password: "hunter2"}, m)
+}
+
+func TestPod(t *testing.T) {
+	assert := assert.New(t)
+	t.Setenv("SCANNER_POD_IP", "10.0.0.12")
+
+	fsys := fstest.MapFS{
+		"namespace":       {Data: []byte("payments")},
+		"labels":          {Data: []byte("app=\"api\"\ntier=\"backend\"\n")},
+		"replicas":        {Data: []byte("3\n")},
+		"..data/replicas": {Data: []byte("3\n")},
+	}
+
+	type Config struct {
+		Namespace string            `file:"namespace"`
+		Labels    scanner.PodLabels `file:"labels"`
+		Replicas  int               `file:"replicas"`
+		PodIP     string            `env:"SCANNER_POD_IP"`
+		Node      string            `env:"SCANNER_NODE_NAME"`
+	}
+
+	s, err := scanner.NewPod(fsys)
+	assert.NoError(err)
+	c := Config{}
+	assert.NoError(s.Scan(&c))
+	assert.Equal(Config{
+		Namespace: "payments",
+		Labels:    scanner.PodLabels{"app": "api", "tier": "backend"},
+		Replicas:  3,
+		PodIP:     "10.0.0.12",
+	}, c)
+
+	w, err := s.Watch(&c)
+	assert.NoError(err)
+	fsys["replicas"] = &fstest.MapFile{Data: []byte("5\n"), ModTime: time.Now().Add(time.Second)}
+	changed, err := w.Poll()
+	assert.NoError(err)
+	assert.Equal([]string{"Replicas"}, changed)
+	assert.Equal(5, c.Replicas)
+}
+
+func TestKVWatcher(t *testing.T) {
+	assert := assert.New(t)
+
+	values := map[string]string{"flags/beta": "true", "limits/rps": "100"}
+	source := scanner.KVSourceFunc(func(ctx context.Context, prefix string) (map[string]string, error) {
+		assert.Equal("app/", prefix)
+		return maps.Clone(values), nil
+	})
+
+	type Config struct {
+		Beta bool `kv:"flags/beta"`
+		RPS  int  `kv:"limits/rps"`
+	}
+
+	c := Config{}
+	w, err := scanner.NewKVWatcher(context.Background(), source, "app/", &c)
+	assert.NoError(err)
+	assert.Equal(Config{Beta: true, RPS: 100}, c)
+
+	notified := []string{}
+	w.Subscribe(func(changed []string) {
+		notified = append(notified, changed...)
+	})
+
+	changed, err := w.Poll(context.Background())
+	assert.NoError(err)
+	assert.Empty(changed)
+
+	values["limits/rps"] = "250"
+	delete(values, "flags/beta")
+	changed, err = w.Poll(context.Background())
+	assert.NoError(err)
+	assert.Equal([]string{"Beta", "RPS"}, changed)
+	assert.Equal(changed, notified)
+	assert.Equal(Config{RPS: 250}, c)
+}
+
+func TestStaticFlags(t *testing.T) {
+	assert := assert.New(t)
+
+	type Flags struct {
+		Beta     bool          `flag:"beta"`
+		Limit    int           `flag:"limit"`
+		Ratio    float32       `flag:"ratio"`
+		Timeout  time.Duration `flag:"timeout"`
+		Variants []string      `flag:"variants"`
+	}
+
+	source := scanner.StaticFlags{"beta": true, "limit": 10.0, "ratio": 0.5, "timeout": "150", "variants": []any{"a", "b"}}
+	s, err := scanner.NewFlags(context.Background(), source, scanner.FlagContext{Key: "user-1"})
+	assert.NoError(err)
+	f := Flags{}
+	assert.NoError(s.Scan(&f))
+	assert.Equal(Flags{Beta: true, Limit: 10, Ratio: 0.5, Timeout: 150, Variants: []string{"a", "b"}}, f)
+
+	s, err = scanner.NewFlags(context.Background(), scanner.StaticFlags{"limit": 1.5}, scanner.FlagContext{})
+	assert.NoError(err)
+	assert.Error(s.Scan(&Flags{}))
+
+	// false and zero override the defaults, missing flags keep them
+	s, err = scanner.NewFlags(context.Background(), scanner.StaticFlags{"beta": false, "limit": 0}, scanner.FlagContext{})
+	assert.NoError(err)
+	f = Flags{Beta: true, Limit: 10, Ratio: 0.5}
+	assert.NoError(s.Scan(&f))
+	assert.Equal(Flags{Ratio: 0.5}, f)
+}
+
+type observation struct {
+	Kind   string
+	Target string
+	Failed bool
+}
+
+type observer struct {
+	scans   []observation
+	casts   []observation
+	uploads map[string]int64
+}
+
+func (o *observer) ObserveScan(kind string, target reflect.Type, d time.Duration, err error) {
+	o.scans = append(o.scans, observation{kind, target.String(), err != nil})
+}
+
+func (o *observer) ObserveCast(kind string, to reflect.Type, d time.Duration, err error) {
+	o.casts = append(o.casts, observation{kind, to.String(), err != nil})
+}
+
+func (o *observer) ObserveUpload(kind string, field string, size int64) {
+	o.uploads[kind+"/"+field] = size
+}
+
+func TestObserver(t *testing.T) {
+	assert := assert.New(t)
+
+	o := &observer{uploads: map[string]int64{}}
+
+	type Params struct {
+		Page int `query:"page"`
+	}
+	q := scanner.NewQuery(url.Values{"page": {"2"}}, scanner.WithObserver(o))
+	assert.NoError(q.Scan(&Params{}))
+	q = scanner.NewQuery(url.Values{"page": {"two"}}, scanner.WithObserver(o))
+	assert.Error(q.Scan(&Params{}))
+
+	assert.Equal([]observation{
+		{"query", "scanner_test.Params", false},
+		{"query", "scanner_test.Params", true},
+	}, o.scans)
+	assert.Equal([]observation{
+		{"query", "int", false},
+		{"query", "int", true},
+	}, o.casts)
+
+	r := bytes.NewReader([]byte("text document"))
+	values := &scanner.MultipartValues{Files: map[string]multipart.File{"document": file{Reader: r, ReaderAt: r, Seeker: r}}}
+	type Upload struct {
+		Document multipart.File `multipart:"document"`
+	}
+	assert.NoError(scanner.NewMultipart(values, scanner.WithObserver(o)).Scan(&Upload{}))
+	assert.Equal(map[string]int64{"multipart/document": 13}, o.uploads)
+	// a scan is reported once, whatever the decodes it takes
+	assert.Len(o.scans, 3)
+	assert.Equal(observation{"multipart", "scanner_test.Upload", false}, o.scans[2])
+
+	type Body struct {
+		Name string `json:"name"`
+	}
+	assert.Error(scanner.NewJSON(strings.NewReader(`{"name":"ada"}`), scanner.WithMaxBytes(4), scanner.WithObserver(o)).Scan(&Body{}))
+	assert.Equal(observation{"json", "scanner_test.Body", true}, o.scans[3])
+}
+
+func TestDeterministicOrder(t *testing.T) {
+	assert := assert.New(t)
+
+	type Params struct {
+		Filter scanner.Filter `list:"filter"`
+	}
+	values, _ := url.ParseQuery("filter[owner]=me&filter[author]=me&filter[zone]=eu")
+	options := scanner.ListOptions{FilterFields: []string{"status"}}
+	for range 20 {
+		err := scanner.NewList(values, options).Scan(&Params{})
+		var listErr *scanner.ListError
+		assert.ErrorAs(err, &listErr)
+		assert.Equal("filter[author]", listErr.Value)
+	}
+
+	type Config struct {
+		Limits map[string]int `gql:"limits"`
+	}
+	req := &scanner.GraphQLRequest{Variables: map[string]any{"limits": map[string]any{"b": "two", "a": "one", "c": "three"}}}
+	first := scanner.NewGraphQL(req).Scan(&Config{})
+	assert.Error(first)
+	for range 20 {
+		assert.Equal(first.Error(), scanner.NewGraphQL(req).Scan(&Config{}).Error())
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	assert := assert.New(t)
+
+	type Params struct {
+		Name     string                  `query:"name,trim,maxlen=32"`
+		Page     int                     `query:"page,min=1"`
+		Tags     []string                `query:"tags"`
+		Since    time.Time               `query:"since,layout=2006-01-02"`
+		Picture  image.Image             `query:"picture"`
+		Agent    scanner.UserAgent       `query:"agent"`
+		Optional scanner.Optional[int]   `query:"optional"`
+		Counts   map[string]int          `query:"counts"`
+		Ignored  string                  `header:"ignored"`
+		private  string                  `query:"private"`
+		Shape    Shape                   `query:"shape"`
+		Nulls    sql.NullString          `query:"nulls"`
+		Headers  map[string]scanner.Page `json:"headers"`
+	}
+
+	specs, err := structd.Describe(reflect.TypeFor[*Params](), "query")
+	assert.NoError(err)
+
+	fields := []string{}
+	for _, spec := range specs {
+		fields = append(fields, spec.Field)
+	}
+	assert.Equal([]string{"Name", "Page", "Tags", "Since", "Picture", "Agent", "Optional", "Counts", "Shape", "Nulls"}, fields)
+
+	assert.Equal(structd.FieldSpec{
+		Field:  "Name",
+		Index:  0,
+		Type:   reflect.TypeFor[string](),
+		Target: reflect.TypeFor[string](),
+		Tag:    structd.ParseTag("name,trim,maxlen=32"),
+		Cast:   structd.CastAssign,
+	}, specs[0])
+	assert.Equal(structd.CastDefault, specs[1].Cast)
+	assert.Equal(structd.CastDefault, specs[2].Cast)
+	assert.Equal(structd.CastTime, specs[3].Cast)
+	assert.Equal(structd.CastGetter, specs[4].Cast)
+	assert.False(specs[4].Castable())
+	assert.Equal(structd.CastUnmarshaler, specs[5].Cast)
+	assert.Equal(reflect.TypeFor[int](), specs[6].Target)
+	assert.Equal(structd.CastDefault, specs[6].Cast)
+	assert.Equal(structd.CastGetter, specs[7].Cast)
+	assert.Equal(structd.CastSQL, specs[9].Cast)
+
+	_, err = structd.Describe(reflect.TypeFor[string](), "query")
+	assert.Error(err)
+}
+
+func TestRegisterOption(t *testing.T) {
+	assert := assert.New(t)
+
+	structd.RegisterOption("prefix", func(field reflect.StructField, option string, raw any) (any, error) {
+		s, ok := raw.(string)
+		if !ok {
+			return raw, nil
+		}
+		if !strings.HasPrefix(s, option) {
+			return nil, fmt.Errorf("%q is not prefixed with %q", s, option)
+		}
+		return strings.TrimPrefix(s, option), nil
+	})
+
+	type Params struct {
+		Tenant string `query:"tenant,trim,prefix=t-"`
+		ID     int    `query:"id,prefix=id_"`
+	}
+
+	p := Params{}
+	assert.NoError(scanner.NewQuery(url.Values{"tenant": {" t-acme "}, "id": {"id_42"}}).Scan(&p))
+	assert.Equal(Params{Tenant: "acme", ID: 42}, p)
+
+	var ferr *structd.FieldError
+	assert.ErrorAs(scanner.NewQuery(url.Values{"tenant": {"acme"}}).Scan(&Params{}), &ferr)
+	assert.Equal("Tenant", ferr.Field)
+
+	spec := structd.ParseTag("tenant, trim ,prefix=t-,trim")
+	assert.Equal("tenant", spec.Name)
+	assert.Equal([]string{"trim", "prefix"}, spec.Keys())
+	value, ok := spec.Lookup("prefix")
+	assert.True(ok)
+	assert.Equal("t-", value)
+	assert.False(spec.Has("lower"))
+}
+
+func TestDecoderSeparatorAndCaster(t *testing.T) {
+	assert := assert.New(t)
+
+	type Params struct {
+		Tags  []string `src:"tags"`
+		IDs   []int    `src:"ids"`
+		Level int      `src:"level"`
+	}
+
+	getter := mapGetter{"tags": "a;b", "ids": "1;2;3", "level": "high"}
+	levels := func(from any, to reflect.Type) (any, error) {
+		if from == "high" {
+			return 3, nil
+		}
+		return structd.DefaultCast(from, to)
+	}
+	d := structd.New(getter, "src", structd.WithSeparator(";"), structd.WithCaster(levels))
+
+	p := Params{}
+	assert.NoError(d.Decode(&p))
+	assert.Equal(Params{Tags: []string{"a", "b"}, IDs: []int{1, 2, 3}, Level: 3}, p)
+
+	// without a caster, a getter that cannot cast fails
+	assert.Error(structd.New(getter, "src", structd.WithSeparator(";")).Decode(&Params{}))
+}
+
+type Celsius struct {
+	Degrees float64
+}
+
+func TestCasterChain(t *testing.T) {
+	assert := assert.New(t)
+
+	structd.RegisterCaster(func(from any, to reflect.Type) (any, error) {
+		if to != reflect.TypeFor[Celsius]() {
+			return nil, errors.ErrUnsupported
+		}
+		var s string
+		switch from := from.(type) {
+		case string:
+			s = from
+		case []byte:
+			s = string(from)
+		default:
+			return nil, errors.ErrUnsupported
+		}
+		f, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "C"), 64)
+		return Celsius{f}, err
+	})
+
+	type Reading struct {
+		Indoor  Celsius `file:"indoor"`
+		Outdoor Celsius `header:"x-outdoor"`
+	}
+
+	fsys := FS{Files: map[string]*File{"indoor": NewFile("indoor", []byte("21.5C\n"))}}
+	d, err := scanner.NewDirectory(fsys)
+	assert.NoError(err)
+	header := http.Header{"X-Outdoor": {"-3C"}}
+
+	r := Reading{}
+	assert.NoError(scanner.NewPipe(d, scanner.NewHeader(&header)).Scan(&r))
+	assert.Equal(Reading{Indoor: Celsius{21.5}, Outdoor: Celsius{-3}}, r)
+
+	upper := func(from any, to reflect.Type) (any, error) {
+		s, ok := from.(string)
+		if !ok || to.Kind() != reflect.String {
+			return nil, errors.ErrUnsupported
+		}
+		return strings.ToUpper(s), nil
+	}
+	cast := structd.Chain(upper, structd.DefaultCast)
+	v, err := cast("abc", reflect.TypeFor[string]())
+	assert.NoError(err)
+	assert.Equal("ABC", v)
+	v, err = cast("12", reflect.TypeFor[int]())
+	assert.NoError(err)
+	assert.Equal(12, v)
+	_, err = structd.Chain(upper)("12", reflect.TypeFor[int]())
+	assert.ErrorIs(err, errors.ErrUnsupported)
+}
+
+func TestDefaultCastFallback(t *testing.T) {
+	assert := assert.New(t)
+
+	type Config struct {
+		Port    int           `file:"port"`
+		Debug   bool          `file:"debug"`
+		Retries uint8         `header:"x-retries"`
+		Timeout time.Duration `header:"x-timeout"`
+	}
+
+	fsys := FS{Files: map[string]*File{
+		"port":  NewFile("port", []byte("8080\n")),
+		"debug": NewFile("debug", []byte("true")),
+	}}
+	d, err := scanner.NewDirectory(fsys)
+	assert.NoError(err)
+	header := http.Header{"X-Retries": {"3"}, "X-Timeout": {"1500"}}
+
+	c := Config{}
+	assert.NoError(scanner.NewPipe(d, scanner.NewHeader(&header)).Scan(&c))
+	assert.Equal(Config{Port: 8080, Debug: true, Retries: 3, Timeout: 1500}, c)
+
+	header = http.Header{"X-Retries": {"many"}}
+	var cerr *structd.CastError
+	assert.ErrorAs(scanner.NewHeader(&header).Scan(&Config{}), &cerr)
+}
+
+func TestHeaderCast(t *testing.T) {
+	assert := assert.New(t)
+
+	type Headers struct {
+		Length       int64         `header:"content-length"`
+		Mobile       bool          `header:"sec-ch-ua-mobile"`
+		DNT          bool          `header:"dnt"`
+		MaxForwards  uint8         `header:"max-forwards"`
+		LastModified time.Time     `header:"last-modified"`
+		Since        time.Time     `header:"if-modified-since"`
+		Retry        time.Duration `header:"x-retry"`
+		Versions     []int         `header:"x-versions"`
+	}
+
+	header := http.Header{
+		"Content-Length":    {" 348 "},
+		"Sec-Ch-Ua-Mobile":  {"?1"},
+		"Dnt":               {"1"},
+		"Max-Forwards":      {"10"},
+		"Last-Modified":     {"Wed, 21 Oct 2015 07:28:00 GMT"},
+		"If-Modified-Since": {"2015-10-21T07:28:00Z"},
+		"X-Retry":           {"30"},
+		"X-Versions":        {"1, 2", "3"},
+	}
+
+	h := Headers{}
+	assert.NoError(scanner.NewHeader(&header).Scan(&h))
+	modified := time.Date(2015, 10, 21, 7, 28, 0, 0, time.UTC)
+	assert.Equal(int64(348), h.Length)
+	assert.True(h.Mobile)
+	assert.True(h.DNT)
+	assert.Equal(uint8(10), h.MaxForwards)
+	assert.True(modified.Equal(h.LastModified))
+	assert.True(modified.Equal(h.Since))
+	assert.Equal(time.Duration(30), h.Retry)
+	assert.Equal([]int{1, 2, 3}, h.Versions)
+
+	header = http.Header{"Content-Length": {"large"}}
+	assert.Error(scanner.NewHeader(&header).Scan(&Headers{}))
+}
+
+func TestCookieCast(t *testing.T) {
+	assert := assert.New(t)
+
+	type Session struct {
+		Visits  int          `cookie:"visits"`
+		Dark    bool         `cookie:"dark"`
+		Session *http.Cookie `cookie:"session"`
+		Theme   http.Cookie  `cookie:"theme"`
+		Missing *http.Cookie `cookie:"missing"`
+	}
+
+	expires := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	res := &http.Response{Header: http.Header{"Set-Cookie": {
+		"visits=12",
+		"dark=true",
+		"session=abc; Path=/; Expires=" + expires.Format(http.TimeFormat) + "; HttpOnly; Secure",
+		"theme=solarized; Max-Age=60",
+	}}}
+
+	s := Session{}
+	assert.NoError(scanner.NewCookie(res.Cookies()).Scan(&s))
+	assert.Equal(12, s.Visits)
+	assert.True(s.Dark)
+	assert.Equal("abc", s.Session.Value)
+	assert.True(s.Session.HttpOnly)
+	assert.True(s.Session.Secure)
+	assert.True(expires.Equal(s.Session.Expires))
+	assert.Equal("solarized", s.Theme.Value)
+	assert.Equal(60, s.Theme.MaxAge)
+	assert.Nil(s.Missing)
+
+	codec, err := scanner.NewSignedCookies(bytes.Repeat([]byte("k"), 32))
+	assert.NoError(err)
+	signed, err := codec.Encode("session", "abc")
+	assert.NoError(err)
+	s = Session{}
+	assert.NoError(scanner.NewCookie([]*http.Cookie{{Name: "session", Value: signed, Path: "/"}}, scanner.WithCookieCodec(codec)).Scan(&s))
+	assert.Equal("abc", s.Session.Value)
+	assert.Equal("/", s.Session.Path)
+
+	assert.Error(scanner.NewCookie([]*http.Cookie{{Name: "visits", Value: "many"}}).Scan(&Session{}))
+}
+
+func TestMultipartBytes(t *testing.T) {
+	assert := assert.New(t)
+
+	type Upload struct {
+		CSV      []byte          `multipart:"csv"`
+		Manifest string          `multipart:"manifest"`
+		Raw      json.RawMessage `multipart:"manifest"`
+		File     multipart.File  `multipart:"csv"`
+	}
+
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	part, _ := w.CreateFormFile("csv", "data.csv")
+	part.Write([]byte("a,b\n1,2\n"))
+	part, _ = w.CreateFormFile("manifest", "manifest.json")
+	part.Write([]byte(`{"version":1}`))
+	w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/", body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	values, err := scanner.MultipartValuesFromParser(req, 1<<20, "csv", "manifest")
+	assert.NoError(err)
+	defer values.Close()
+
+	u := Upload{}
+	assert.NoError(scanner.NewMultipart(values).Scan(&u))
+	assert.Equal("a,b\n1,2\n", string(u.CSV))
+	assert.Equal(`{"version":1}`, u.Manifest)
+	assert.JSONEq(`{"version":1}`, string(u.Raw))
+	// the file is rewound after its content is read
+	b, _ := io.ReadAll(u.File)
+	assert.Equal("a,b\n1,2\n", string(b))
+
+	u.File.Seek(0, io.SeekStart)
+	var tooLarge *scanner.BodyTooLargeError
+	assert.ErrorAs(scanner.NewMultipart(values, scanner.WithMaxBytes(4)).Scan(&Upload{}), &tooLarge)
+}
+
+func TestDuplicateTags(t *testing.T) {
+	assert := assert.New(t)
+
+	type Params struct {
+		ID     int    `query:"id"`
+		Name   string `query:"name"`
+		RawID  string `query:"id"`
+		Label  string `query:"name,upper"`
+		Header string `header:"id"`
+	}
+
+	err := structd.Validate(reflect.TypeFor[*Params](), "query")
+	var derr *structd.DuplicateTagError
+	assert.ErrorAs(err, &derr)
+	assert.Equal(&structd.DuplicateTagError{Struct: "Params", Key: "query", Name: "id", Fields: []string{"ID", "RawID"}}, derr)
+	assert.ErrorContains(err, `fields Name, Label of Params share the query tag "name"`)
+	assert.NoError(structd.Validate(reflect.TypeFor[Params](), "header"))
+
+	// fields that share a name each receive the value
+	values := url.Values{"id": {"7"}, "name": {"ada"}}
+	p := Params{}
+	assert.NoError(scanner.NewQuery(values).Scan(&p))
+	assert.Equal(Params{ID: 7, RawID: "7", Name: "ada", Label: "ADA"}, p)
+
+	assert.ErrorAs(scanner.NewQuery(values, scanner.WithDecoderOptions(structd.WithUniqueNames())).Scan(&Params{}), &derr)
+}
+
+func TestJSONFlatten(t *testing.T) {
+	assert := assert.New(t)
+
+	type Event struct {
+		ID     string         `json:"id"`
+		Kind   string         `json:"kind"`
+		User   string         `json:"user"`
+		Amount int            `json:"amount"`
+		Data   struct{}       `json:"payload,flatten"`
+		Extra  map[string]any `json:",remain"`
+	}
+
+	body := `{"id":"evt_1","kind":"charge","payload":{"user":"ada","amount":30,"kind":"ignored","currency":"usd"},"livemode":false}`
+	e := Event{}
+	assert.NoError(scanner.NewJSON(strings.NewReader(body), scanner.WithStrict()).Scan(&e))
+	assert.Equal(Event{ID: "evt_1", Kind: "charge", User: "ada", Amount: 30, Extra: map[string]any{"currency": "usd", "livemode": false}}, e)
+
+	type Strict struct {
+		ID      string          `json:"id"`
+		Payload json.RawMessage `json:"payload,flatten"`
+	}
+	err := scanner.NewJSON(strings.NewReader(body), scanner.WithStrict()).Scan(&Strict{})
+	assert.ErrorContains(err, "unknown field")
+
+	// unexported fields only lift their keys, the struct is built at runtime since vet rejects their json tags
+	lifted := reflect.New(reflect.StructOf([]reflect.StructField{
+		{Name: "User", Type: reflect.TypeFor[string](), Tag: `json:"user"`},
+		{Name: "payload", PkgPath: "scanner_test", Type: reflect.TypeFor[struct{}](), Tag: `json:"payload,flatten"`},
+	}))
+	assert.NoError(scanner.NewJSON(strings.NewReader(body)).Scan(lifted.Interface()))
+	assert.Equal("ada", lifted.Elem().Field(0).String())
+
+	type Embedded struct {
+		Listing
+		Rest map[string]json.RawMessage `json:",remain"`
+	}
+	em := Embedded{}
+	assert.NoError(scanner.NewJSON(strings.NewReader(`{"Page":2,"sort":"name","q":"go"}`)).Scan(&em))
+	assert.Equal(2, em.Page)
+	assert.Equal(map[string]json.RawMessage{"q": json.RawMessage(`"go"`)}, em.Rest)
+}
+
+func TestRemain(t *testing.T) {
+	assert := assert.New(t)
+
+	type Params struct {
+		Page  int               `query:"page"`
+		Extra map[string]string `query:",remain"`
+	}
+
+	p := Params{}
+	values := url.Values{"page": {"2"}, "utm_source": {"mail"}, "debug": {""}}
+	assert.NoError(scanner.NewQuery(values).Scan(&p))
+	assert.Equal(Params{Page: 2, Extra: map[string]string{"utm_source": "mail", "debug": ""}}, p)
+
+	p = Params{}
+	assert.NoError(scanner.NewQuery(url.Values{"page": {"1"}}).Scan(&p))
+	assert.Nil(p.Extra)
+
+	type Headers struct {
+		Type  string              `header:"content-type"`
+		Extra map[string][]string `header:",remain"`
+	}
+
+	header := &http.Header{}
+	header.Set("Content-Type", "text/plain")
+	header.Set("Accept", "text/html, application/json")
+	h := Headers{}
+	assert.NoError(scanner.NewHeader(header).Scan(&h))
+	assert.Equal(Headers{Type: "text/plain", Extra: map[string][]string{"Accept": {"text/html", "application/json"}}}, h)
+
+	type Counts struct {
+		Total int            `query:"total"`
+		Rest  map[string]int `query:",remain"`
+	}
+
+	c := Counts{}
+	assert.NoError(scanner.NewQuery(url.Values{"total": {"3"}, "a": {"1"}, "b": {"2"}}).Scan(&c))
+	assert.Equal(Counts{Total: 3, Rest: map[string]int{"a": 1, "b": 2}}, c)
+	assert.Error(scanner.NewQuery(url.Values{"a": {"one"}}).Scan(&Counts{}))
+
+	type Session struct {
+		ID    string            `cookie:"id"`
+		Other map[string]string `cookie:",remain"`
+	}
+
+	s := Session{}
+	assert.NoError(scanner.NewCookie([]*http.Cookie{{Name: "id", Value: "s1"}, {Name: "theme", Value: "dark"}}).Scan(&s))
+	assert.Equal(Session{ID: "s1", Other: map[string]string{"theme": "dark"}}, s)
+
+	// getters that cannot list their keys leave the field untouched
+	type Route struct {
+		ID    string            `path:"id"`
+		Extra map[string]string `path:",remain"`
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.SetPathValue("id", "7")
+	r := Route{}
+	assert.NoError(scanner.NewPath(req).Scan(&r))
+	assert.Equal(Route{ID: "7"}, r)
+
+	type Invalid struct {
+		Extra []string `query:",remain"`
+	}
+	assert.Error(scanner.NewQuery(values).Scan(&Invalid{}))
+
+	specs, err := structd.Describe(reflect.TypeFor[Params](), "query")
+	assert.NoError(err)
+	assert.Equal(structd.CastRemain, specs[1].Cast)
+}
+
+func TestLimits(t *testing.T) {
+	assert := assert.New(t)
+
+	type Params struct {
+		Query string            `query:"q"`
+		IDs   []int             `query:"ids"`
+		Page  int               `query:"page"`
+		Extra map[string]string `query:",remain"`
+	}
+
+	limits := scanner.WithLimits(scanner.Limits{MaxKeys: 3, MaxValueLength: 16, MaxElements: 4})
+	p := Params{}
+	assert.NoError(scanner.NewQuery(url.Values{"q": {"go"}, "ids": {"1,2,3,4"}, "page": {"2"}}, limits).Scan(&p))
+	assert.Equal(Params{Query: "go", IDs: []int{1, 2, 3, 4}, Page: 2}, p)
+
+	var lerr *structd.LimitError
+	err := scanner.NewQuery(url.Values{"ids": {"1,2,3,4,5"}}, limits).Scan(&Params{})
+	assert.ErrorAs(err, &lerr)
+	assert.Equal(&structd.LimitError{Limit: structd.LimitElements, Max: 4, Key: "ids"}, lerr)
+
+	err = scanner.NewQuery(url.Values{"q": {strings.Repeat("a", 17)}}, limits).Scan(&Params{})
+	assert.ErrorAs(err, &lerr)
+	assert.Equal(structd.LimitValueLength, lerr.Limit)
+	assert.EqualError(err, `structd: value of "q" exceeds the limit of 16 bytes`)
+
+	// keys gathered by a remain field count against the limit
+	err = scanner.NewQuery(url.Values{"q": {"go"}, "page": {"1"}, "a": {"1"}, "b": {"2"}}, limits).Scan(&Params{})
+	assert.ErrorAs(err, &lerr)
+	assert.Equal(structd.LimitKeys, lerr.Limit)
+
+	// every scan has a budget of its own
+	s := scanner.NewQuery(url.Values{"q": {"go"}, "page": {"1"}}, limits)
+	for range 3 {
+		assert.NoError(s.Scan(&Params{}))
+	}
+
+	type Headers struct {
+		Accept []string `header:"accept"`
+	}
+	header := &http.Header{}
+	header.Set("Accept", "a, b, c, d, e")
+	err = scanner.NewHeader(header, limits).Scan(&Headers{})
+	assert.ErrorAs(err, &lerr)
+	assert.Equal(structd.LimitElements, lerr.Limit)
+
+	// decodes run on pooled states of their own, so a decoder can be shared between goroutines
+	d := structd.New(scanner.NewQuery(url.Values{"ids": {"1,2"}, "page": {"3"}}), "query", structd.WithLimits(structd.Limits{MaxKeys: 4}))
+	wg := sync.WaitGroup{}
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p := Params{}
+			assert.NoError(d.Decode(&p))
+			assert.Equal(Params{IDs: []int{1, 2}, Page: 3}, p)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestQueryEscapes(t *testing.T) {
+	assert := assert.New(t)
+
+	type Params struct {
+		Query string `query:"q"`
+		Sign  string `query:"sign"`
+	}
+
+	s, err := scanner.NewQueryString("q=a+b&sign=%2B1")
+	assert.NoError(err)
+	p := Params{}
+	assert.NoError(s.Scan(&p))
+	assert.Equal(Params{Query: "a b", Sign: "+1"}, p)
+
+	s, err = scanner.NewQueryString("q=a+b&sign=%2B1", scanner.WithLiteralPlus())
+	assert.NoError(err)
+	p = Params{}
+	assert.NoError(s.Scan(&p))
+	assert.Equal(Params{Query: "a+b", Sign: "+1"}, p)
+
+	_, err = scanner.NewQueryString("q=100%&sign=%zz")
+	assert.Error(err)
+	_, err = scanner.NewQueryString("q=100%&sign=%zz", scanner.WithLiteralPlus())
+	assert.Error(err)
+
+	s, err = scanner.NewQueryString("q=100%+off&sign=%zz%41", scanner.WithLenientEscapes())
+	assert.NoError(err)
+	p = Params{}
+	assert.NoError(s.Scan(&p))
+	assert.Equal(Params{Query: "100% off", Sign: "%zzA"}, p)
+
+	type Login struct {
+		User string `form:"user"`
+	}
+	f, err := scanner.NewFormString("user=ada+lovelace%21", scanner.WithLiteralPlus())
+	assert.NoError(err)
+	l := Login{}
+	assert.NoError(f.Scan(&l))
+	assert.Equal(Login{User: "ada+lovelace!"}, l)
+
+	_, err = scanner.NewFormString("user=ada;admin=1", scanner.WithLenientEscapes())
+	assert.Error(err)
+}
+
+func TestNull(t *testing.T) {
+	assert := assert.New(t)
+
+	type Update struct {
+		Name  scanner.Null[string] `json:"name" query:"name"`
+		Email scanner.Null[string] `json:"email" query:"email"`
+		Age   scanner.Null[int]    `json:"age" query:"age"`
+	}
+
+	u := Update{}
+	assert.NoError(scanner.NewJSON(strings.NewReader(`{"name":"ada","email":null}`)).Scan(&u))
+	assert.Equal(Update{Name: scanner.Valid("ada"), Email: scanner.Nulled[string]()}, u)
+	assert.True(u.Email.IsNull())
+	assert.False(u.Age.IsNull())
+	assert.False(u.Age.Present)
+	assert.Equal("unknown", u.Email.Or("unknown"))
+
+	b, err := json.Marshal(u)
+	assert.NoError(err)
+	assert.JSONEq(`{"name":"ada","email":null,"age":null}`, string(b))
+
+	u = Update{}
+	assert.NoError(scanner.NewQuery(url.Values{"age": {"36"}}).Scan(&u))
+	value, ok := u.Age.Get()
+	assert.True(ok)
+	assert.Equal(36, value)
+	assert.False(u.Name.Present)
+
+	// a merge patch keeps the null it decoded
+	u = Update{Name: scanner.Valid("ada"), Email: scanner.Valid("ada@example.com")}
+	p := scanner.NewPatch(strings.NewReader(`{"email":null}`))
+	assert.NoError(p.Scan(&u))
+	assert.Equal(Update{Name: scanner.Valid("ada"), Email: scanner.Nulled[string]()}, u)
+	assert.True(p.Changed("email"))
+}
+
+func TestContentEncoding(t *testing.T) {
+	assert := assert.New(t)
+
+	type Payload struct {
+		Name string `json:"name" form:"name"`
+	}
+
+	compress := func(encoding string, b []byte) []byte {
+		buf := &bytes.Buffer{}
+		var w io.WriteCloser
+		switch encoding {
+		case "gzip":
+			w = gzip.NewWriter(buf)
+		case "zlib":
+			w = zlib.NewWriter(buf)
+		case "flate":
+			w, _ = flate.NewWriter(buf, flate.DefaultCompression)
+		}
+		w.Write(b)
+		w.Close()
+		return buf.Bytes()
+	}
+
+	body := []byte(`{"name":"ada"}`)
+	for header, b := range map[string][]byte{
+		"gzip":          compress("gzip", body),
+		"deflate":       compress("zlib", body),
+		"identity":      body,
+		"deflate, gzip": compress("gzip", compress("flate", body)),
+	} {
+		p := Payload{}
+		assert.NoError(scanner.NewJSONBytes(b, scanner.WithContentEncoding(header)).Scan(&p), header)
+		assert.Equal(Payload{Name: "ada"}, p, header)
+	}
+
+	var uerr *scanner.UnsupportedEncodingError
+	assert.ErrorAs(scanner.NewJSONBytes(body, scanner.WithContentEncoding("compress")).Scan(&Payload{}), &uerr)
+	assert.Equal("compress", uerr.Encoding)
+
+	// decompression bombs stop at the limit
+	bomb := compress("gzip", []byte(`{"name":"`+strings.Repeat("a", 1<<16)+`"}`))
+	var terr *scanner.BodyTooLargeError
+	err := scanner.NewJSONBytes(bomb, scanner.WithContentEncoding("gzip"), scanner.WithMaxDecompressedBytes(1<<10)).Scan(&Payload{})
+	assert.ErrorAs(err, &terr)
+	assert.Equal(int64(1<<10), terr.Limit)
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(compress("gzip", body)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	p := Payload{}
+	assert.NoError(scanner.NewRequest(req).Scan(&p))
+	assert.Equal(Payload{Name: "ada"}, p)
+
+	req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(compress("gzip", []byte("name=grace"))))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Content-Encoding", "gzip")
+	p = Payload{}
+	assert.NoError(scanner.NewRequest(req).Scan(&p))
+	assert.Equal(Payload{Name: "grace"}, p)
+}
+
+func TestCharset(t *testing.T) {
+	assert := assert.New(t)
+
+	type Payload struct {
+		Name string `json:"name" form:"name" query:"name"`
+	}
+
+	// "José" in windows-1252 and "日本" in shift_jis
+	latin := []byte("{\"name\":\"Jos\xe9\"}")
+	p := Payload{}
+	assert.NoError(scanner.NewJSONBytes(latin, scanner.WithCharset("ISO-8859-1")).Scan(&p))
+	assert.Equal(Payload{Name: "José"}, p)
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte("{\"name\":\"\x93\xfa\x96\x7b\"}")))
+	req.Header.Set("Content-Type", "application/json; charset=Shift_JIS")
+	p = Payload{}
+	assert.NoError(scanner.NewRequest(req).Scan(&p))
+	assert.Equal(Payload{Name: "日本"}, p)
+
+	// form values are transcoded once they are unescaped
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("name=Jos%E9"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=windows-1252")
+	p = Payload{}
+	assert.NoError(scanner.NewRequest(req).Scan(&p))
+	assert.Equal(Payload{Name: "José"}, p)
+
+	s, err := scanner.NewQueryString("name=%93%fa%96%7b", scanner.WithCharset("shift_jis"))
+	assert.NoError(err)
+	p = Payload{}
+	assert.NoError(s.Scan(&p))
+	assert.Equal(Payload{Name: "日本"}, p)
+
+	p = Payload{}
+	assert.NoError(scanner.NewJSONBytes([]byte(`{"name":"José"}`), scanner.WithCharset("utf-8")).Scan(&p))
+	assert.Equal(Payload{Name: "José"}, p)
+
+	var cerr *scanner.UnsupportedCharsetError
+	assert.ErrorAs(scanner.NewJSONBytes(latin, scanner.WithCharset("klingon")).Scan(&Payload{}), &cerr)
+	assert.Equal("klingon", cerr.Charset)
+	_, err = scanner.NewFormString("name=a", scanner.WithCharset("klingon"))
+	assert.ErrorAs(err, &cerr)
+}
+
+func TestFormIndexes(t *testing.T) {
+	assert := assert.New(t)
+
+	type Tag struct {
+		Label string `form:"label"`
+	}
+	type Item struct {
+		Name     string `form:"name"`
+		Quantity int    `form:"quantity"`
+		Tags     []Tag  `form:"tags"`
+	}
+	type Order struct {
+		ID    string            `form:"id"`
+		Items []Item            `form:"items"`
+		Refs  []*Item           `form:"refs"`
+		Extra map[string]string `form:",remain"`
+	}
+
+	values := url.Values{
+		"id":                       {"ord_1"},
+		"items[0].name":            {"a"},
+		"items[0].quantity":        {"2"},
+		"items[0].tags[0].label":   {"new"},
+		"items[1][name]":           {"b"},
+		"items[1][tags][0][label]": {"sale"},
+		"items[1][tags][1][label]": {"gift"},
+		"refs[7].name":             {"c"},
+		"note":                     {"leave at door"},
+	}
+
+	o := Order{}
+	assert.NoError(scanner.NewForm(values).Scan(&o))
+	assert.Equal(Order{
+		ID: "ord_1",
+		Items: []Item{
+			{Name: "a", Quantity: 2, Tags: []Tag{{Label: "new"}}},
+			{Name: "b", Tags: []Tag{{Label: "sale"}, {Label: "gift"}}},
+		},
+		// gaps between indexes are dropped
+		Refs:  []*Item{{Name: "c"}},
+		Extra: map[string]string{"note": "leave at door"},
+	}, o)
+
+	err := scanner.NewForm(url.Values{"items[0].quantity": {"many"}}).Scan(&Order{})
+	assert.Error(err)
+
+	// keys that are not indexes are left alone
+	o = Order{}
+	assert.NoError(scanner.NewForm(url.Values{"items[x].name": {"a"}, "items[0]": {"b"}}).Scan(&o))
+	assert.Nil(o.Items)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("items%5B0%5D%5Bname%5D=a&items%5B1%5D%5Bname%5D=b"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	o = Order{}
+	assert.NoError(scanner.NewRequest(req).Scan(&o))
+	assert.Equal([]Item{{Name: "a"}, {Name: "b"}}, o.Items)
+
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	w.WriteField("items[0].name", "a")
+	w.WriteField("items[1][name]", "b")
+	w.WriteField("items[1][quantity]", "3")
+	w.Close()
+	parts, err := scanner.MultipartValuesFromReader(multipart.NewReader(body, w.Boundary()), 1<<20)
+	assert.NoError(err)
+	o = Order{}
+	assert.NoError(scanner.NewMultipart(parts).Scan(&o))
+	assert.Equal([]Item{{Name: "a"}, {Name: "b", Quantity: 3}}, o.Items)
+
+	// structs without slices of structs keep their generated decoders
+	c := Checkout{}
+	assert.NoError(scanner.NewForm(url.Values{"plan": {"pro"}}).Scan(&c))
+	assert.Equal(Checkout{Plan: "pro", generated: true}, c)
+}
+
+type Checkout struct {
+	Plan      string `form:"plan"`
+	generated bool
+}
+
+func (v *Checkout) DecodeGenerated(d *structd.Decoder) (bool, error) {
+	if d.Key() != "form" {
+		return false, nil
+	}
+	v.generated = true
+	return true, d.DecodeField(v, "Plan")
+}
@@ -0,0 +1,127 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/canpacis/scanner/structd"
+)
+
+// A SchemaValidator validates a json document before the json scanner binds it, like a `*jsonschema.Schema`.
+// The document holds the types encoding/json produces: strings, float64s, bools, nil, []any and map[string]any.
+type SchemaValidator interface {
+	Validate(doc any) []SchemaViolation
+}
+
+// A SchemaViolation is a value of a json document that violates a schema
+type SchemaViolation struct {
+	// Pointer is the json pointer of the value, like `/items/0/price`
+	Pointer string
+	// Keyword is the schema keyword the value violates, like `minimum`
+	Keyword string
+	// Message describes the violation in the terms of the schema
+	Message string
+}
+
+func (v *SchemaViolation) Error() string {
+	pointer := v.Pointer
+	if pointer == "" {
+		pointer = "/"
+	}
+	return "schema: " + pointer + " " + v.Message
+}
+
+// WithSchema makes the json scanner validate bodies against schema before it binds them. Violations fail
+// the scan as `*structd.FieldError`s of the fields they point to, wrapping a `*scanner.SchemaViolation`.
+func WithSchema(schema SchemaValidator) Option {
+	return func(c *config) {
+		c.schema = schema
+	}
+}
+
+// validate validates the body b against the schema of the scanner, returning the violations as field errors of v
+func (s *JSON) validate(b []byte, v any) error {
+	var doc any
+	d := json.NewDecoder(bytes.NewReader(b))
+	if err := d.Decode(&doc); err != nil {
+		return err
+	}
+
+	violations := s.config.schema.Validate(doc)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	rt := reflect.TypeOf(v)
+	for rt != nil && rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+
+	errs := []error{}
+	for _, violation := range violations {
+		errs = append(errs, &structd.FieldError{
+			Struct: typeName(rt),
+			Field:  schemaField(rt, violation.Pointer),
+			Err:    &violation,
+		})
+	}
+	return errors.Join(errs...)
+}
+
+// typeName returns the name of t, empty for nil
+func typeName(t reflect.Type) string {
+	if t == nil {
+		return ""
+	}
+	return t.Name()
+}
+
+// schemaField returns the path of the go field the json pointer points to in t, like `Items[0].Price`.
+// Segments past the fields it can follow are kept as they are.
+func schemaField(t reflect.Type, pointer string) string {
+	if pointer == "" {
+		return ""
+	}
+
+	path := []string{}
+	for _, segment := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		segment = strings.NewReplacer("~1", "/", "~0", "~").Replace(segment)
+		for t != nil && t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+
+		kind := reflect.Invalid
+		if t != nil {
+			kind = t.Kind()
+		}
+		switch kind {
+		case reflect.Struct:
+			if i, ok := jsonFieldIndex(t, segment); ok {
+				path = append(path, t.Field(i).Name)
+				t = t.Field(i).Type
+				continue
+			}
+		case reflect.Slice, reflect.Array:
+			if _, err := strconv.Atoi(segment); err == nil && len(path) > 0 {
+				path[len(path)-1] += "[" + segment + "]"
+				t = t.Elem()
+				continue
+			}
+		case reflect.Map:
+			if len(path) > 0 {
+				path[len(path)-1] += "[" + strconv.Quote(segment) + "]"
+				t = t.Elem()
+				continue
+			}
+		}
+
+		path = append(path, segment)
+		t = nil
+	}
+
+	return strings.Join(path, ".")
+}
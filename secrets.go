@@ -0,0 +1,200 @@
+package scanner
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io/fs"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/canpacis/scanner/structd"
+)
+
+// SecretsDir is the directory Docker mounts secrets in
+const SecretsDir = "/run/secrets"
+
+// ErrSecretNotFound is returned by secret sources for secrets that do not exist
+var ErrSecretNotFound = errors.New("scanner: secret not found")
+
+// A SecretSource reads the secrets of a secret manager, like HashiCorp Vault or AWS Secrets Manager.
+// Key names a value of a secret with many values and is empty otherwise, missing secrets and keys
+// are reported with `scanner.ErrSecretNotFound`.
+type SecretSource interface {
+	GetSecret(ctx context.Context, path, key string) (string, error)
+}
+
+// SecretSourceFunc is a function that implements SecretSource
+type SecretSourceFunc func(ctx context.Context, path, key string) (string, error)
+
+func (f SecretSourceFunc) GetSecret(ctx context.Context, path, key string) (string, error) {
+	return f(ctx, path, key)
+}
+
+// A MissingSecretError describes a `required` secret that does not exist
+type MissingSecretError struct {
+	Name string
+}
+
+func (e *MissingSecretError) Error() string {
+	return "scanner: required secret " + e.Name + " is missing"
+}
+
+// A scanner to scan secrets to a struct, from mounted secret files like Docker secrets in `/run/secrets` and
+// the volumes of Kubernetes secrets, or from a secret manager. Fields are bound with the `secret` tag and its options:
+//
+//	type Secrets struct {
+//		Password string `secret:"db_password,required"`
+//		Region   string `secret:"region,default=eu-west-1"`
+//		Key      []byte `secret:"signing_key,base64"`
+//	}
+//
+// Trailing newlines are trimmed from files, a missing `required` secret fails with a `*scanner.MissingSecretError`
+// and values are redacted from errors.
+type Secrets struct {
+	// read returns the value of a secret and whether it exists
+	read   func(ctx context.Context, name string) (string, bool, error)
+	values map[string]string
+	config *config
+}
+
+func (s *Secrets) Get(key string) any {
+	v, ok := s.values[key]
+	if !ok {
+		return nil
+	}
+	return v
+}
+
+func (s *Secrets) Cast(from any, to reflect.Type) (any, error) {
+	if str, ok := from.(string); ok && to.Kind() == reflect.Slice && to.Elem().Kind() == reflect.Uint8 {
+		return []byte(str), nil
+	}
+	return structd.DefaultCast(from, to)
+}
+
+// load reads the secrets the tags of v name
+func (s *Secrets) load(ctx context.Context, v any, key string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	rt := rv.Elem().Type()
+
+	for i := range rt.NumField() {
+		if !rt.Field(i).IsExported() {
+			continue
+		}
+		tag, ok := rt.Field(i).Tag.Lookup(key)
+		if !ok {
+			continue
+		}
+		spec := structd.ParseTag(tag)
+		name := spec.Name
+
+		value, found, err := s.read(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		if fallback, ok := spec.Lookup("default"); ok && !found {
+			value, found = fallback, true
+		}
+		encoded, required := spec.Has("base64"), spec.Has("required")
+
+		if !found {
+			if required {
+				return &MissingSecretError{Name: name}
+			}
+			continue
+		}
+		if encoded {
+			b, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				b, err = base64.RawStdEncoding.DecodeString(value)
+			}
+			if err != nil {
+				return &structd.FieldError{Struct: rt.Name(), Field: rt.Field(i).Name, Err: errors.New("scanner: secret " + name + " is not valid base64")}
+			}
+			value = string(b)
+		}
+		s.values[name] = value
+	}
+
+	return nil
+}
+
+// Scans the secrets the tags of v name
+func (s *Secrets) Scan(v any) error {
+	return s.ScanContext(context.Background(), v)
+}
+
+// ScanContext scans the secrets the tags of v name, reading the secrets of a secret manager with ctx
+func (s *Secrets) ScanContext(ctx context.Context, v any) error {
+	key := "secret"
+	if s.config.tag != "" {
+		key = s.config.tag
+	}
+
+	clear(s.values)
+	if err := s.load(ctx, v, key); err != nil {
+		return err
+	}
+	if err := s.config.decoder(s, key).Decode(v); err != nil {
+		secrets := []string{}
+		for _, name := range slices.Sorted(maps.Keys(s.values)) {
+			secrets = append(secrets, s.values[name])
+		}
+		return structd.Redact(err, secrets...)
+	}
+	return nil
+}
+
+// NewSecrets creates a secrets scanner of the files in fsys, like `os.DirFS(scanner.SecretsDir)` or the mount
+// path of a Kubernetes secret volume. The hidden `..data` entries of Kubernetes volumes are never bound,
+// the options of `scanner.NewDirectory` restrict the files it reads.
+func NewSecrets(fsys fs.FS, opts ...Option) (*Secrets, error) {
+	dir, err := NewDirectory(fsys, append(opts[:len(opts):len(opts)], WithExclude("..*"))...)
+	if err != nil {
+		return nil, err
+	}
+
+	read := func(ctx context.Context, name string) (string, bool, error) {
+		if !fs.ValidPath(name) || !dir.config.directory.allowed(name) {
+			return "", false, nil
+		}
+		b, err := dir.readFile(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", false, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+		return strings.TrimRight(string(b), "\r\n"), true, nil
+	}
+	return &Secrets{read: read, values: map[string]string{}, config: dir.config}, nil
+}
+
+// NewSecretManager creates a secrets scanner of the secrets of source, tags name a secret by its path and
+// optionally a key after a `#`, like `secret:"kv/data/db#password,required"`. Secrets are read on every scan,
+// with the context of `Secrets.ScanContext` or a background context for `Secrets.Scan`.
+func NewSecretManager(source SecretSource, opts ...Option) *Secrets {
+	read := func(ctx context.Context, name string) (string, bool, error) {
+		path, key := name, ""
+		if i := strings.LastIndexByte(name, '#'); i >= 0 {
+			path, key = name[:i], name[i+1:]
+		}
+
+		value, err := source.GetSecret(ctx, path, key)
+		if errors.Is(err, ErrSecretNotFound) {
+			return "", false, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+		return value, true, nil
+	}
+	return &Secrets{read: read, values: map[string]string{}, config: newConfig(opts)}
+}
@@ -0,0 +1,199 @@
+// Package awssecrets reads secrets from AWS Secrets Manager for `scanner.NewSecretManager` over the http api
+// of the service, signed with signature version 4, without the AWS SDK.
+//
+//	source, err := awssecrets.FromEnv()
+//	s := scanner.NewSecretManager(source)
+//
+//	type Config struct {
+//		Password string `secret:"prod/db#password,required"`
+//	}
+package awssecrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/canpacis/scanner"
+)
+
+// A Source reads the secrets of AWS Secrets Manager
+type Source struct {
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is the token of temporary credentials, if any
+	SessionToken string
+	// Endpoint overrides the endpoint of the region, like `http://localhost:4566` for localstack
+	Endpoint string
+	// Client sends the requests, `http.DefaultClient` is used when it is nil
+	Client *http.Client
+}
+
+// FromEnv creates a source from the `AWS_REGION`, `AWS_ACCESS_KEY_ID`, `AWS_SECRET_ACCESS_KEY` and
+// `AWS_SESSION_TOKEN` environment variables
+func FromEnv() (*Source, error) {
+	s := &Source{
+		Region:          os.Getenv("AWS_REGION"),
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if s.Region == "" {
+		s.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if s.Region == "" || s.AccessKeyID == "" || s.SecretAccessKey == "" {
+		return nil, errors.New("awssecrets: AWS_REGION, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+	}
+	return s, nil
+}
+
+// GetSecret reads the current version of the secret with the name or arn path. With a key the secret
+// must be a json object and the value of key is returned.
+func (s *Source) GetSecret(ctx context.Context, path, key string) (string, error) {
+	endpoint := s.Endpoint
+	if endpoint == "" {
+		endpoint = "https://secretsmanager." + s.Region + ".amazonaws.com"
+	}
+	payload, err := json.Marshal(map[string]string{"SecretId": path})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	s.sign(req, payload, time.Now(), "secretsmanager")
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode != http.StatusOK {
+		failure := struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}{}
+		json.Unmarshal(body, &failure)
+		if strings.HasSuffix(failure.Type, "ResourceNotFoundException") {
+			return "", scanner.ErrSecretNotFound
+		}
+		return "", fmt.Errorf("awssecrets: reading %s failed with status %s: %s %s", path, res.Status, failure.Type, failure.Message)
+	}
+
+	secret := struct {
+		SecretString *string
+		SecretBinary []byte
+	}{}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", err
+	}
+	value := string(secret.SecretBinary)
+	if secret.SecretString != nil {
+		value = *secret.SecretString
+	}
+	if key == "" {
+		return value, nil
+	}
+
+	values := map[string]json.RawMessage{}
+	if err := json.Unmarshal([]byte(value), &values); err != nil {
+		return "", fmt.Errorf("awssecrets: secret %s is not a json object: %w", path, err)
+	}
+	raw, ok := values[key]
+	if !ok {
+		return "", scanner.ErrSecretNotFound
+	}
+	var str string
+	if err := json.Unmarshal(raw, &str); err != nil {
+		return string(raw), nil
+	}
+	return str, nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hashHex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// sign sets the signature version 4 headers of req for service
+func (s *Source) sign(req *http.Request, payload []byte, t time.Time, service string) {
+	t = t.UTC()
+	stamp := t.Format("20060102T150405Z")
+	date := stamp[:8]
+
+	req.Header.Set("X-Amz-Date", stamp)
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	canonicalHeaders := &strings.Builder{}
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonical := strings.Join([]string{
+		req.Method,
+		path,
+		strings.ReplaceAll(req.URL.Query().Encode(), "+", "%20"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hashHex(payload),
+	}, "\n")
+
+	scope := date + "/" + s.Region + "/" + service + "/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + stamp + "\n" + scope + "\n" + hashHex([]byte(canonical))
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.AccessKeyID+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
@@ -0,0 +1,64 @@
+package awssecrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/canpacis/scanner"
+	"github.com/stretchr/testify/assert"
+)
+
+// the example request of the signature version 4 documentation
+func TestSign(t *testing.T) {
+	s := &Source{Region: "us-east-1", AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	req, _ := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	s.sign(req, nil, time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC), "iam")
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7", req.Header.Get("Authorization"))
+}
+
+func TestGetSecret(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.Contains(r.Header.Get("Authorization"), "/eu-west-1/secretsmanager/aws4_request")
+
+		body := map[string]string{}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body["SecretId"] {
+		case "prod/db":
+			fmt.Fprint(w, `{"Name": "prod/db", "SecretString": "{\"password\": \"hunter2\", \"port\": 5432}"}`)
+		case "prod/token":
+			fmt.Fprint(w, `{"Name": "prod/token", "SecretString": "token"}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"__type": "ResourceNotFoundException", "message": "not found"}`)
+		}
+	}))
+	defer server.Close()
+
+	s := &Source{Region: "eu-west-1", AccessKeyID: "id", SecretAccessKey: "secret", Endpoint: server.URL}
+	ctx := context.Background()
+
+	value, err := s.GetSecret(ctx, "prod/db", "password")
+	assert.NoError(err)
+	assert.Equal("hunter2", value)
+	value, err = s.GetSecret(ctx, "prod/db", "port")
+	assert.NoError(err)
+	assert.Equal("5432", value)
+	value, err = s.GetSecret(ctx, "prod/token", "")
+	assert.NoError(err)
+	assert.Equal("token", value)
+
+	_, err = s.GetSecret(ctx, "prod/db", "user")
+	assert.ErrorIs(err, scanner.ErrSecretNotFound)
+	_, err = s.GetSecret(ctx, "prod/missing", "")
+	assert.ErrorIs(err, scanner.ErrSecretNotFound)
+}
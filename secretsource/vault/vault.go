@@ -0,0 +1,114 @@
+// Package vault reads secrets from HashiCorp Vault for `scanner.NewSecretManager` over the http api of Vault,
+// without its client library.
+//
+//	s := scanner.NewSecretManager(vault.FromEnv())
+//
+//	type Config struct {
+//		Password string `secret:"kv/data/db#password,required"`
+//	}
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/canpacis/scanner"
+)
+
+// A Source reads the secrets of a Vault server
+type Source struct {
+	// Addr is the address of the server, like `https://vault.example.com:8200`
+	Addr string
+	// Token authenticates the requests
+	Token string
+	// Namespace is the enterprise namespace of the secrets, if any
+	Namespace string
+	// Client sends the requests, `http.DefaultClient` is used when it is nil
+	Client *http.Client
+}
+
+// New creates a source of the server at addr that authenticates with token
+func New(addr, token string) *Source {
+	return &Source{Addr: addr, Token: token}
+}
+
+// FromEnv creates a source from the `VAULT_ADDR`, `VAULT_TOKEN` and `VAULT_NAMESPACE` environment variables
+func FromEnv() *Source {
+	return &Source{
+		Addr:      os.Getenv("VAULT_ADDR"),
+		Token:     os.Getenv("VAULT_TOKEN"),
+		Namespace: os.Getenv("VAULT_NAMESPACE"),
+	}
+}
+
+// GetSecret reads the secret at path, like `kv/data/db` for version 2 of the kv engine or `secret/db`
+// for version 1, and returns the value of key. Without a key the secret must hold a single value.
+// Values that are not strings are returned as json.
+func (s *Source) GetSecret(ctx context.Context, path, key string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(s.Addr, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", s.Token)
+	if s.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", s.Namespace)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return "", scanner.ErrSecretNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault: reading %s failed with status %s", path, res.Status)
+	}
+
+	body := struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return "", err
+	}
+
+	data := body.Data
+	// the kv engine version 2 nests the values of a secret next to its metadata
+	if inner, ok := data["data"]; ok {
+		if _, ok := data["metadata"]; ok {
+			data = map[string]json.RawMessage{}
+			if err := json.Unmarshal(inner, &data); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if key == "" {
+		if len(data) != 1 {
+			return "", fmt.Errorf("vault: secret %s has %d values, name one with a key", path, len(data))
+		}
+		for k := range data {
+			key = k
+		}
+	}
+	raw, ok := data[key]
+	if !ok {
+		return "", scanner.ErrSecretNotFound
+	}
+
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return string(raw), nil
+	}
+	return value, nil
+}
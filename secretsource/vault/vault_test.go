@@ -0,0 +1,56 @@
+package vault_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/canpacis/scanner"
+	"github.com/canpacis/scanner/secretsource/vault"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretManager(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/kv/data/db":
+			fmt.Fprint(w, `{"data": {"data": {"password": "hunter2", "port": 5432}, "metadata": {"version": 3}}}`)
+		case "/v1/secret/api":
+			fmt.Fprint(w, `{"data": {"token": "abc"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errors": []}`)
+		}
+	}))
+	defer server.Close()
+
+	type Config struct {
+		Password string `secret:"kv/data/db#password,required"`
+		Port     int    `secret:"kv/data/db#port"`
+		Token    string `secret:"secret/api"`
+		User     string `secret:"kv/data/db#user,default=postgres"`
+		Missing  string `secret:"kv/data/missing#value"`
+	}
+
+	s := scanner.NewSecretManager(vault.New(server.URL, "root"))
+	c := Config{}
+	assert.NoError(s.ScanContext(context.Background(), &c))
+	assert.Equal(Config{Password: "hunter2", Port: 5432, Token: "abc", User: "postgres"}, c)
+
+	var missing *scanner.MissingSecretError
+	assert.ErrorAs(s.Scan(&struct {
+		Missing string `secret:"kv/data/missing#value,required"`
+	}{}), &missing)
+
+	forbidden := scanner.NewSecretManager(vault.New(server.URL, "guest"))
+	assert.Error(forbidden.Scan(&Config{}))
+}
@@ -0,0 +1,199 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/canpacis/scanner/structd"
+)
+
+// Event is a single server-sent event
+type Event struct {
+	ID    string
+	Event string
+	Data  []byte
+	Retry time.Duration
+
+	opts []Option
+}
+
+func (e *Event) Get(key string) any {
+	switch key {
+	case "id":
+		return e.ID
+	case "event":
+		return e.Event
+	case "data":
+		return string(e.Data)
+	case "retry":
+		if e.Retry == 0 {
+			return nil
+		}
+		return e.Retry
+	default:
+		return nil
+	}
+}
+
+func (e *Event) Cast(from any, to reflect.Type) (any, error) {
+	if to.Kind() == reflect.Slice && to.Elem().Kind() == reflect.Uint8 {
+		if s, ok := from.(string); ok {
+			return []byte(s), nil
+		}
+	}
+	return structd.DefaultCast(from, to)
+}
+
+// Scans the event onto v, the `sse` tag binds the `id`, `event`, `retry` and raw `data` fields
+// and json data is decoded onto the `json` tags
+func (e *Event) Scan(v any) error {
+	c := newConfig(e.opts)
+	if err := c.decoder(e, "sse").Decode(v); err != nil {
+		return err
+	}
+
+	data := bytes.TrimSpace(e.Data)
+	if len(data) == 0 || data[0] != '{' {
+		return nil
+	}
+	return NewJSONBytes(data, e.opts...).Scan(v)
+}
+
+// maxEventLine is the longest line an event stream accepts
+const maxEventLine = 1 << 20
+
+// An EventStream reads server-sent events from a `text/event-stream` body, like a `bufio.Scanner`:
+//
+//	for s.Next() {
+//		e := &Update{}
+//		if err := s.Scan(e); err != nil { ... }
+//	}
+//
+// Cancelling the context stops the stream, the reader is closed when it is an `io.Closer`.
+type EventStream struct {
+	ctx         context.Context
+	s           *bufio.Scanner
+	event       *Event
+	lastEventID string
+	retry       time.Duration
+	err         error
+	stop        func() bool
+	opts        []Option
+}
+
+// Next reads the next event, it returns false at the end of the stream, on an error or when the context is done
+func (s *EventStream) Next() bool {
+	s.event = nil
+	if s.err != nil {
+		return false
+	}
+
+	data := &bytes.Buffer{}
+	event := &Event{opts: s.opts}
+	for {
+		if err := s.ctx.Err(); err != nil {
+			s.err = err
+			return false
+		}
+		if !s.s.Scan() {
+			s.err = s.s.Err()
+			if ctxErr := s.ctx.Err(); ctxErr != nil {
+				s.err = ctxErr
+			}
+			return false
+		}
+
+		line := s.s.Text()
+		if line == "" {
+			if data.Len() == 0 {
+				event = &Event{opts: s.opts}
+				continue
+			}
+
+			event.ID = s.lastEventID
+			event.Retry = s.retry
+			event.Data = bytes.TrimSuffix(data.Bytes(), []byte("\n"))
+			if event.Event == "" {
+				event.Event = "message"
+			}
+			s.event = event
+			return true
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				s.lastEventID = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				s.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
+
+// Event returns the current event
+func (s *EventStream) Event() *Event {
+	return s.event
+}
+
+// Scans the current event onto v
+func (s *EventStream) Scan(v any) error {
+	if s.event == nil {
+		return io.EOF
+	}
+	return s.event.Scan(v)
+}
+
+// LastEventID returns the last event id the server sent, to be used in the `Last-Event-ID` header when reconnecting
+func (s *EventStream) LastEventID() string {
+	return s.lastEventID
+}
+
+// Err returns the error that stopped the stream, reaching the end of the stream is not an error
+func (s *EventStream) Err() error {
+	return s.err
+}
+
+// Close releases the context watcher of the stream
+func (s *EventStream) Close() error {
+	if s.stop != nil {
+		s.stop()
+	}
+	return nil
+}
+
+func NewEventStream(ctx context.Context, r io.Reader, opts ...Option) *EventStream {
+	c := newConfig(opts)
+
+	s := &EventStream{
+		ctx:  ctx,
+		s:    bufio.NewScanner(c.reader(r)),
+		opts: opts,
+	}
+	s.s.Buffer(nil, maxEventLine)
+	if closer, ok := r.(io.Closer); ok {
+		s.stop = context.AfterFunc(ctx, func() {
+			closer.Close()
+		})
+	}
+
+	return s
+}
@@ -0,0 +1,70 @@
+package structd
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// A Caster converts a raw value to type to, returning `errors.ErrUnsupported` passes the value to the next
+// caster of a chain
+type Caster func(from any, to reflect.Type) (any, error)
+
+var (
+	castersMu sync.RWMutex
+	casters   []Caster
+)
+
+// RegisterCaster registers c to be tried by every decoder after the casters of the decoder and its getter,
+// like a caster of the types of an application
+func RegisterCaster(c Caster) {
+	castersMu.Lock()
+	defer castersMu.Unlock()
+
+	casters = append(casters, c)
+}
+
+// Chain returns a caster that tries casters in order until one of them does not return `errors.ErrUnsupported`
+func Chain(casters ...Caster) Caster {
+	return func(from any, to reflect.Type) (any, error) {
+		return castWith(casters, from, to)
+	}
+}
+
+// castWith tries casters in order like the caster of Chain
+func castWith(casters []Caster, from any, to reflect.Type) (any, error) {
+	for _, cast := range casters {
+		v, err := cast(from, to)
+		if !errors.Is(err, errors.ErrUnsupported) {
+			return v, err
+		}
+	}
+	return nil, errors.ErrUnsupported
+}
+
+// chain returns the casters of d in the order they are tried: its own, the cast of its getter, the
+// registered ones and DefaultCast when every other caster declines. The chain of a Decode is built once.
+func (d *Decoder) chain() []Caster {
+	if !d.pooled {
+		return d.buildChain(nil)
+	}
+	if !d.built {
+		d.chained = d.buildChain(d.chained)
+		d.built = true
+	}
+	return d.chained
+}
+
+// buildChain appends the cast chain of d to chain
+func (d *Decoder) buildChain(chain []Caster) []Caster {
+	chain = append(chain, d.casters...)
+	if c, ok := d.getter.(caster); ok {
+		chain = append(chain, c.Cast)
+	}
+
+	castersMu.RLock()
+	defer castersMu.RUnlock()
+
+	chain = append(chain, casters...)
+	return append(chain, DefaultCast)
+}
@@ -0,0 +1,80 @@
+package structd
+
+import (
+	"cmp"
+	"reflect"
+	"strconv"
+	"unicode/utf8"
+)
+
+// checkConstraints enforces the `min`, `max`, `minlen` and `maxlen` tag options on a decoded value
+func checkConstraints(t TagSpec, v reflect.Value) error {
+	for _, option := range []string{"min", "max"} {
+		limit, ok := t.Lookup(option)
+		if !ok {
+			continue
+		}
+
+		c, err := compare(v, option, limit)
+		if err != nil {
+			return err
+		}
+		if (option == "min" && c < 0) || (option == "max" && c > 0) {
+			return &ConstraintError{Constraint: option, Limit: limit}
+		}
+	}
+
+	for _, option := range []string{"minlen", "maxlen"} {
+		limit, ok := t.Lookup(option)
+		if !ok {
+			continue
+		}
+
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return &TagError{Option: option, Value: limit, Err: err}
+		}
+
+		var length int
+		switch v.Kind() {
+		case reflect.String:
+			length = utf8.RuneCountInString(v.String())
+		case reflect.Slice, reflect.Array, reflect.Map:
+			length = v.Len()
+		default:
+			return &TagError{Option: option, Value: limit, Err: errUnsupportedKind(v.Kind())}
+		}
+
+		if (option == "minlen" && length < n) || (option == "maxlen" && length > n) {
+			return &ConstraintError{Constraint: option, Limit: limit}
+		}
+	}
+
+	return nil
+}
+
+// compare returns -1, 0 or 1 depending on whether numeric value v is less than, equal to or greater than limit
+func compare(v reflect.Value, option, limit string) (int, error) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		l, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil {
+			return 0, &TagError{Option: option, Value: limit, Err: err}
+		}
+		return cmp.Compare(v.Int(), l), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		l, err := strconv.ParseUint(limit, 10, 64)
+		if err != nil {
+			return 0, &TagError{Option: option, Value: limit, Err: err}
+		}
+		return cmp.Compare(v.Uint(), l), nil
+	case reflect.Float32, reflect.Float64:
+		l, err := strconv.ParseFloat(limit, 64)
+		if err != nil {
+			return 0, &TagError{Option: option, Value: limit, Err: err}
+		}
+		return cmp.Compare(v.Float(), l), nil
+	default:
+		return 0, &TagError{Option: option, Value: limit, Err: errUnsupportedKind(v.Kind())}
+	}
+}
@@ -1,17 +1,28 @@
 package structd
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"reflect"
+	"runtime/debug"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/text/language"
 )
 
 type Getter interface {
 	Get(string) any
 }
 
+// A MultiGetter is a Getter that can return every value of a key, it is used to bind slice fields
+type MultiGetter interface {
+	GetAll(string) []string
+}
+
 type caster interface {
 	Cast(any, reflect.Type) (any, error)
 }
@@ -20,12 +31,110 @@ type Unmarshaler interface {
 	UnmarshalString(v string) error
 }
 
+// A ParamUnmarshaler is an Unmarshaler that also receives the tag name of the value and its source,
+// which is the tag key of the decoder like `header` or `query`. It is preferred over Unmarshaler.
+type ParamUnmarshaler interface {
+	UnmarshalParam(key, value, source string) error
+}
+
+// A Wrapper is implemented by types that hold a single value of another type along with some state,
+// like whether the value was present. The decoder casts raw values to the wrapped type and passes them to Wrap.
+type Wrapper interface {
+	WrappedType() reflect.Type
+	Wrap(v any)
+}
+
 type Decoder struct {
-	getter Getter
-	key    string
+	getter      Getter
+	key         string
+	beforeField []func(reflect.StructField, any) (any, error)
+	afterDecode []func(any) error
+	locale      language.Tag
+	location    *time.Location
+	timeLayouts []string
+	reflective  bool
+	separator   string
+	unique      bool
+	casters     []Caster
+	limits      Limits
+	// keys counts the keys a Decode with limits consumed
+	keys int
+	// pooled decoders hold the state of a single Decode, their cast chain is built on its first cast
+	pooled  bool
+	built   bool
+	chained []Caster
+}
+
+// states pools the decoders Decode works on, which hold the state of a single decode like its cast chain
+// and the keys it consumed. Their buffers are reused across decodes.
+var states = sync.Pool{
+	New: func() any {
+		return &Decoder{}
+	},
+}
+
+// state returns a pooled copy of d for a single Decode, release returns it to the pool
+func (d *Decoder) state() *Decoder {
+	s := states.Get().(*Decoder)
+	chained := s.chained[:0]
+	*s = *d
+	s.keys = 0
+	s.pooled = true
+	s.built = false
+	s.chained = chained
+	return s
+}
+
+func (d *Decoder) release() {
+	chained := d.chained
+	clear(chained)
+	*d = Decoder{chained: chained[:0]}
+	states.Put(d)
+}
+
+// An Option configures a Decoder
+type Option func(*Decoder)
+
+// WithBeforeField registers a hook that receives every raw value before it is cast and set on its field.
+// The value the hook returns is used in place of the raw value, returning nil leaves the field untouched.
+func WithBeforeField(fn func(field reflect.StructField, raw any) (any, error)) Option {
+	return func(d *Decoder) {
+		d.beforeField = append(d.beforeField, fn)
+	}
+}
+
+// WithSeparator splits string values of slice fields at sep, instead of DefaultSeperator
+func WithSeparator(sep string) Option {
+	return func(d *Decoder) {
+		d.separator = sep
+	}
+}
+
+// WithCaster adds casters the decoder tries before the cast of its getter, see Chain
+func WithCaster(casters ...Caster) Option {
+	return func(d *Decoder) {
+		d.casters = append(d.casters, casters...)
+	}
+}
+
+// WithUniqueNames makes the decoder fail with a *DuplicateTagError on structs with fields that share a tag name
+func WithUniqueNames() Option {
+	return func(d *Decoder) {
+		d.unique = true
+	}
+}
+
+// WithAfterDecode registers a hook that runs on the decoded value once all fields are set
+func WithAfterDecode(fn func(v any) error) Option {
+	return func(d *Decoder) {
+		d.afterDecode = append(d.afterDecode, fn)
+	}
 }
 
-func (d *Decoder) Decode(v any) error {
+// Decode decodes the values of the getter onto v, which must be a pointer to a struct. Fields that share a
+// tag name each receive its value on their own, so the result does not depend on their order. Panics raised
+// while decoding are recovered and returned as a *DecodePanicError.
+func (d *Decoder) Decode(v any) (err error) {
 	rv := reflect.ValueOf(v)
 	rt := reflect.TypeOf(v)
 	if rv.Kind() != reflect.Pointer || rv.IsNil() {
@@ -37,55 +146,241 @@ func (d *Decoder) Decode(v any) error {
 		return &InvalidUnmarshalError{rt}
 	}
 
-	for i := range rv.NumField() {
-		field := rt.Field(i)
-		value := rv.Field(i)
+	current := ""
+	defer func() {
+		if r := recover(); r != nil {
+			err = &DecodePanicError{
+				Struct: rt.Name(),
+				Field:  current,
+				Value:  r,
+				Stack:  debug.Stack(),
+			}
+		}
+	}()
 
-		if !field.IsExported() {
-			continue
+	// every Decode works on a state of its own, which gives it a budget of its own against the limits
+	d = d.state()
+	defer d.release()
+
+	if d.unique {
+		if err := Validate(rt, d.key); err != nil {
+			return err
 		}
+	}
 
-		tag, ok := field.Tag.Lookup(d.key)
-		if !ok {
-			continue
+	handled, err := d.generated(v)
+	if err != nil {
+		return err
+	}
+	if handled {
+		return d.after(v)
+	}
+
+	for _, f := range plan(rt, d.key) {
+		current = f.field.Name
+		if err := d.decodeField(rt, f.field, rv.Field(f.index), f.tag); err != nil {
+			return err
 		}
+	}
+	current = ""
 
-		target := d.getter.Get(tag)
-		if target == nil {
-			continue
+	return d.after(v)
+}
+
+// after runs the after decode hooks on v
+func (d *Decoder) after(v any) error {
+	for _, hook := range d.afterDecode {
+		if err := hook(v); err != nil {
+			return err
 		}
+	}
 
-		tv := reflect.ValueOf(target)
-		tt := reflect.TypeOf(target)
-		if tv.IsZero() {
-			continue
+	return nil
+}
+
+// decodeField decodes the value the tag points to onto a single struct field
+func (d *Decoder) decodeField(rt reflect.Type, field reflect.StructField, value reflect.Value, tag TagSpec) (err error) {
+	if tag.Has("remain") {
+		return d.decodeRemain(rt, field, value)
+	}
+	if field.Type.Kind() == reflect.Interface && hasImplementations(field.Type) {
+		impl, err := d.decodeImplementation(tag, field.Type)
+		if err != nil {
+			return err
+		}
+		if impl.IsValid() {
+			value.Set(impl)
 		}
+		return nil
+	}
 
-		if !tt.AssignableTo(field.Type) {
-			c, ok := d.getter.(caster)
-			if ok {
-				casted, err := c.Cast(target, field.Type)
-				if err != nil {
-					return wrapCastErr(err)
-				}
-				value.Set(reflect.ValueOf(casted))
-				continue
-			}
+	to := field.Type
+	var wrapper Wrapper
+	wrapped := reflect.PointerTo(field.Type).Implements(wrapperType)
+	if wrapped {
+		wrapper = reflect.New(field.Type).Interface().(Wrapper)
+		to = wrapper.WrappedType()
+	}
 
-			return &UnmarshalTypeError{
-				Value:  tt.Name(),
-				Type:   field.Type,
+	target := d.get(tag.Name, to)
+	if target == nil {
+		return nil
+	}
+	if err := d.consume(tag.Name, target, to); err != nil {
+		return err
+	}
+	target = sanitize(tag, target)
+	if tag.Has("secret") {
+		defer func(raw any) {
+			err = redact(err, raw)
+		}(target)
+	}
+
+	target, err = handleOptions(tag, field, target)
+	if err != nil {
+		return &FieldError{
+			Struct: rt.Name(),
+			Field:  field.Name,
+			Err:    err,
+		}
+	}
+	if target == nil {
+		return nil
+	}
+
+	for _, hook := range d.beforeField {
+		target, err = hook(field, target)
+		if err != nil {
+			return &FieldError{
 				Struct: rt.Name(),
 				Field:  field.Name,
+				Err:    err,
 			}
-		} else {
-			value.Set(tv)
+		}
+	}
+	if target == nil {
+		return nil
+	}
+
+	tv := reflect.ValueOf(target)
+	if tv.IsZero() {
+		return nil
+	}
+
+	localized, err := d.localize(tag, target, to)
+	if err != nil {
+		return wrapCastErr(err)
+	}
+	tv = reflect.ValueOf(localized)
+
+	tv, err = d.cast(tv, to, tag.Name)
+	if err != nil {
+		var terr *UnmarshalTypeError
+		if errors.As(err, &terr) {
+			terr.Struct = rt.Name()
+			terr.Field = field.Name
+		}
+		return err
+	}
+
+	if err := checkConstraints(tag, tv); err != nil {
+		return &FieldError{
+			Struct: rt.Name(),
+			Field:  field.Name,
+			Err:    err,
 		}
 	}
 
+	if wrapped {
+		wrapper.Wrap(tv.Interface())
+		tv = reflect.ValueOf(wrapper).Elem()
+	}
+	value.Set(tv)
 	return nil
 }
 
+// get reads the raw value of key, slice fields receive every value when the getter is a MultiGetter
+func (d *Decoder) get(key string, to reflect.Type) any {
+	m, ok := d.getter.(MultiGetter)
+	if ok && to.Kind() == reflect.Slice && to.Elem().Kind() != reflect.Uint8 && !isUnmarshaler(to) {
+		values := m.GetAll(key)
+		if len(values) == 0 {
+			return nil
+		}
+		return values
+	}
+
+	return d.getter.Get(key)
+}
+
+// cast converts v to type to, using the getter's caster when v is not assignable. key is the tag name
+// of the value that is passed to ParamUnmarshalers.
+func (d *Decoder) cast(v reflect.Value, to reflect.Type, key string) (reflect.Value, error) {
+	if v.Type().AssignableTo(to) {
+		return v, nil
+	}
+
+	if s, ok := v.Interface().(string); ok && reflect.PointerTo(to).Implements(paramUnmarshalerType) {
+		u, err := unmarshalParam(key, s, d.key, to)
+		if err != nil {
+			return v, wrapCastErr(err)
+		}
+		return reflect.ValueOf(u), nil
+	}
+
+	if s, ok := v.Interface().(string); ok && reflect.PointerTo(to).Implements(unmarshalerType) {
+		u, err := unmarshal(s, to)
+		if err != nil {
+			return v, wrapCastErr(err)
+		}
+		return reflect.ValueOf(u), nil
+	}
+
+	if s, ok := v.Interface().(string); ok && d.separator != "" && to.Kind() == reflect.Slice && to.Elem().Kind() != reflect.Uint8 && !isUnmarshaler(to) {
+		return d.cast(reflect.ValueOf(strings.Split(s, d.separator)), to, key)
+	}
+
+	if values, ok := v.Interface().([]string); ok && to.Kind() == reflect.Slice {
+		result := reflect.MakeSlice(to, 0, len(values))
+		for _, entry := range values {
+			casted, err := d.cast(reflect.ValueOf(entry), to.Elem(), key)
+			if err != nil {
+				return v, err
+			}
+			result = reflect.Append(result, casted)
+		}
+		return result, nil
+	}
+
+	chain := d.chain()
+	if len(chain) == 0 {
+		return v, &UnmarshalTypeError{
+			Value: v.Type().Name(),
+			Type:  to,
+		}
+	}
+
+	casted, err := castWith(chain, v.Interface(), to)
+	if err != nil {
+		return v, wrapCastErr(err)
+	}
+
+	cv := reflect.ValueOf(casted)
+	switch {
+	case !cv.IsValid():
+		return reflect.Zero(to), nil
+	case cv.Type().AssignableTo(to):
+		return cv, nil
+	case cv.Type().ConvertibleTo(to) && cv.Kind() == to.Kind():
+		return cv.Convert(to), nil
+	default:
+		return v, &UnmarshalTypeError{
+			Value: cv.Type().String(),
+			Type:  to,
+		}
+	}
+}
+
 type numbers interface {
 	int | int8 | int16 | int32 | int64 | uint | uint8 | uint16 | uint32 | uint64 | float32 | float64
 }
@@ -120,9 +415,88 @@ func parse[T numbers](s string) (T, error) {
 
 const DefaultSeperator = ","
 
+var (
+	sqlScannerType       = reflect.TypeFor[sql.Scanner]()
+	unmarshalerType      = reflect.TypeFor[Unmarshaler]()
+	paramUnmarshalerType = reflect.TypeFor[ParamUnmarshaler]()
+)
+
+// isUnmarshaler reports whether the pointer of t implements Unmarshaler or ParamUnmarshaler
+func isUnmarshaler(t reflect.Type) bool {
+	pt := reflect.PointerTo(t)
+	return pt.Implements(unmarshalerType) || pt.Implements(paramUnmarshalerType)
+}
+
+// castSQL casts from to a type that implements `sql.Scanner`, like `sql.NullString` or `sql.NullInt64`
+func castSQL(from any, to reflect.Type) (any, error) {
+	toPtr := reflect.New(to)
+	if err := toPtr.Interface().(sql.Scanner).Scan(from); err != nil {
+		return nil, err
+	}
+
+	return toPtr.Elem().Interface(), nil
+}
+
+// unmarshalParam creates a value of type to, which must implement ParamUnmarshaler through its pointer, from s
+func unmarshalParam(key, s, source string, to reflect.Type) (any, error) {
+	toPtr := reflect.New(to)
+	if err := toPtr.Interface().(ParamUnmarshaler).UnmarshalParam(key, s, source); err != nil {
+		return nil, &UnmarshalerError{
+			Err:         err,
+			Value:       s,
+			Unmarshaler: to,
+		}
+	}
+
+	return toPtr.Elem().Interface(), nil
+}
+
+// unmarshal creates a value of type to, which must implement Unmarshaler through its pointer, from s
+func unmarshal(s string, to reflect.Type) (any, error) {
+	toPtr := reflect.New(to)
+	if err := toPtr.Interface().(Unmarshaler).UnmarshalString(s); err != nil {
+		return nil, &UnmarshalerError{
+			Err:         err,
+			Value:       s,
+			Unmarshaler: to,
+		}
+	}
+
+	return toPtr.Elem().Interface(), nil
+}
+
+// DefaultCast casts strings, byte slices, numbers and bools to the basic types, slices of them, types that
+// implement Unmarshaler and `sql.Scanner` structs. Named types receive values of their own type. A trailing
+// newline of a byte slice is ignored.
 func DefaultCast(from any, to reflect.Type) (any, error) {
+	v, err := defaultCast(from, to)
+	if err != nil || v == nil {
+		return v, err
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Type() != to && rv.Type().ConvertibleTo(to) {
+		return rv.Convert(to).Interface(), nil
+	}
+	return v, nil
+}
+
+func defaultCast(from any, to reflect.Type) (any, error) {
+	if to.Kind() == reflect.Struct && reflect.PointerTo(to).Implements(sqlScannerType) && !isUnmarshaler(to) {
+		return castSQL(from, to)
+	}
+
+	if b, ok := from.([]byte); ok {
+		// the contents of files and bodies, like a number followed by a newline
+		from = strings.TrimRight(string(b), "\r\n")
+	}
+
 	switch from := from.(type) {
 	case string:
+		if reflect.PointerTo(to).Implements(unmarshalerType) {
+			return unmarshal(from, to)
+		}
+
 		switch to.Kind() {
 		case reflect.Uint8:
 			return parse[uint8](from)
@@ -158,7 +532,7 @@ func DefaultCast(from any, to reflect.Type) (any, error) {
 			case reflect.String:
 				return split, nil
 			default:
-				result := reflect.New(to).Elem()
+				result := reflect.MakeSlice(to, 0, len(split))
 
 				for _, entry := range split {
 					value, err := DefaultCast(entry, to.Elem())
@@ -171,26 +545,12 @@ func DefaultCast(from any, to reflect.Type) (any, error) {
 				return result.Interface(), nil
 			}
 		default:
-			toPtr := reflect.New(to)
-			u, ok := toPtr.Interface().(Unmarshaler)
-			if !ok {
-				return nil, errors.ErrUnsupported
-			}
-
-			if err := u.UnmarshalString(from); err != nil {
-				return nil, &UnmarshalerError{
-					Err:         err,
-					Value:       from,
-					Unmarshaler: to,
-				}
-			}
-
-			return toPtr.Elem().Interface(), nil
+			return nil, errors.ErrUnsupported
 		}
 	case uint, int, uint8, uint16, uint32, uint64, int8, int16, int32, int64, float32, float64:
 		switch to.Kind() {
 		case reflect.String:
-			return fmt.Sprintf("%d", from), nil
+			return fmt.Sprint(from), nil
 		case reflect.Bool:
 			return from != 0, nil
 		default:
@@ -240,9 +600,14 @@ func DefaultCast(from any, to reflect.Type) (any, error) {
 	}
 }
 
-func New(getter Getter, key string) *Decoder {
-	return &Decoder{
+func New(getter Getter, key string, opts ...Option) *Decoder {
+	d := &Decoder{
 		getter: getter,
 		key:    key,
 	}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
 }
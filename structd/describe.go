@@ -0,0 +1,115 @@
+package structd
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// A CastPath is the way raw string values reach a field
+type CastPath string
+
+const (
+	// CastAssign fields receive strings as they are
+	CastAssign CastPath = "assign"
+	// CastUnmarshaler fields implement Unmarshaler or ParamUnmarshaler through their pointer
+	CastUnmarshaler CastPath = "unmarshaler"
+	// CastTime fields are `time.Time` values parsed with the `layout=`, `locale=` and `tz=` options
+	CastTime CastPath = "time"
+	// CastSQL fields are `sql.Scanner` structs
+	CastSQL CastPath = "sql"
+	// CastDefault fields are basic types and slices of them that DefaultCast parses
+	CastDefault CastPath = "default"
+	// CastImplementation fields are interfaces decoded into a registered implementation
+	CastImplementation CastPath = "implementation"
+	// CastRemain fields are maps that receive the keys no other field binds
+	CastRemain CastPath = "remain"
+	// CastGetter fields are only set when the caster of the getter produces their type
+	CastGetter CastPath = "getter"
+)
+
+// A FieldSpec describes how a Decoder binds a tagged field of a struct
+type FieldSpec struct {
+	// Field is the name of the struct field
+	Field string
+	// Index is the index of the field in its struct
+	Index int
+	// Type is the type of the field
+	Type reflect.Type
+	// Target is the type raw values are cast to, the wrapped type of Wrapper fields and Type otherwise
+	Target reflect.Type
+	// Tag is the parsed tag, its name is the key the value is read with
+	Tag TagSpec
+	// Cast is how raw strings are cast to Target without a caster of the getter
+	Cast CastPath
+}
+
+// Castable reports whether structd casts raw strings to the field on its own
+func (s FieldSpec) Castable() bool {
+	return s.Cast != CastGetter
+}
+
+// Describe returns the fields of t, a struct or a pointer to one, a Decoder of the key tag binds, in field order
+func Describe(t reflect.Type, key string) ([]FieldSpec, error) {
+	if t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("structd: cannot describe non-struct type %v", t)
+	}
+
+	specs := []FieldSpec{}
+	for _, f := range plan(t, key) {
+		target := f.field.Type
+		if reflect.PointerTo(target).Implements(wrapperType) {
+			target = reflect.New(target).Interface().(Wrapper).WrappedType()
+		}
+
+		cast := castPath(target)
+		if f.field.Type.Kind() == reflect.Interface && hasImplementations(f.field.Type) {
+			cast = CastImplementation
+		}
+		if f.tag.Has("remain") {
+			cast = CastRemain
+		}
+
+		specs = append(specs, FieldSpec{
+			Field:  f.field.Name,
+			Index:  f.index,
+			Type:   f.field.Type,
+			Target: target,
+			Tag:    f.tag.clone(),
+			Cast:   cast,
+		})
+	}
+
+	return specs, nil
+}
+
+// castPath returns how strings are cast to t
+func castPath(t reflect.Type) CastPath {
+	switch {
+	case t == reflect.TypeFor[string]():
+		return CastAssign
+	case isUnmarshaler(t):
+		return CastUnmarshaler
+	case t == timeType:
+		return CastTime
+	case t.Kind() == reflect.Struct && reflect.PointerTo(t).Implements(sqlScannerType):
+		return CastSQL
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return CastDefault
+	case reflect.Slice:
+		switch castPath(t.Elem()) {
+		case CastAssign, CastUnmarshaler, CastDefault:
+			return CastDefault
+		}
+	}
+
+	return CastGetter
+}
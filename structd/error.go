@@ -1,7 +1,11 @@
 package structd
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 )
 
 type CastError struct {
@@ -85,3 +89,106 @@ func (e *UnmarshalTypeError) Error() string {
 	}
 	return "structd: cannot unmarshal " + e.Value + " into Go value of type " + e.Type.String()
 }
+
+func errUnsupportedKind(kind reflect.Kind) error {
+	return fmt.Errorf("%w: %s", errors.ErrUnsupported, kind)
+}
+
+// A TagError describes a malformed tag option.
+type TagError struct {
+	Option string
+	Value  string
+	Err    error
+}
+
+func (e *TagError) Error() string {
+	return "structd: invalid tag option " + e.Option + "=" + e.Value + ": " + e.Err.Error()
+}
+
+func (e *TagError) Unwrap() error {
+	return e.Err
+}
+
+// A ConstraintError describes a decoded value that violates a constraint given in the field tag.
+type ConstraintError struct {
+	Constraint string
+	Limit      string
+}
+
+func (e *ConstraintError) Error() string {
+	return "value violates " + e.Constraint + "=" + e.Limit
+}
+
+// A FieldError describes a decoded value that was rejected for a specific struct field.
+type FieldError struct {
+	Struct string
+	Field  string
+	Err    error
+}
+
+func (e *FieldError) Error() string {
+	return "structd: invalid value for Go struct field " + e.Struct + "." + e.Field + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// A DuplicateTagError describes fields of a struct that bind the same name of a tag key
+type DuplicateTagError struct {
+	Struct string
+	Key    string
+	Name   string
+	Fields []string
+}
+
+func (e *DuplicateTagError) Error() string {
+	return "structd: fields " + strings.Join(e.Fields, ", ") + " of " + e.Struct + " share the " + e.Key + " tag " + strconv.Quote(e.Name)
+}
+
+// The limits a LimitError reports
+const (
+	LimitKeys        = "keys"
+	LimitValueLength = "value length"
+	LimitElements    = "elements"
+)
+
+// A LimitError describes input that exceeded a limit of WithLimits while reading the value of Key
+type LimitError struct {
+	Limit string
+	Max   int
+	Key   string
+}
+
+func (e *LimitError) Error() string {
+	switch e.Limit {
+	case LimitKeys:
+		return "structd: reading " + strconv.Quote(e.Key) + " exceeds the limit of " + strconv.Itoa(e.Max) + " keys"
+	case LimitValueLength:
+		return "structd: value of " + strconv.Quote(e.Key) + " exceeds the limit of " + strconv.Itoa(e.Max) + " bytes"
+	default:
+		return "structd: value of " + strconv.Quote(e.Key) + " exceeds the limit of " + strconv.Itoa(e.Max) + " " + e.Limit
+	}
+}
+
+// A DecodePanicError describes a panic recovered while decoding a struct, Field is empty when the panic
+// happened outside of a field, like in an after decode hook.
+type DecodePanicError struct {
+	Struct string
+	Field  string
+	Value  any
+	Stack  []byte
+}
+
+func (e *DecodePanicError) Error() string {
+	where := e.Struct
+	if e.Field != "" {
+		where += "." + e.Field
+	}
+	return fmt.Sprintf("structd: panic while decoding %s: %v", where, e.Value)
+}
+
+func (e *DecodePanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
@@ -0,0 +1,70 @@
+package structd
+
+import (
+	"reflect"
+
+	"golang.org/x/text/language"
+)
+
+// A GeneratedDecoder is implemented by structs with a decoder generated by scannergen. It decodes the fields
+// of the tag key of d without reflection and reports whether it handled the key, the reflective decoder is
+// used when it did not.
+type GeneratedDecoder interface {
+	DecodeGenerated(d *Decoder) (bool, error)
+}
+
+// WithoutGenerated makes the decoder ignore generated decoders, it is used when the decoder is configured
+// in ways generated code does not follow, like custom casters
+func WithoutGenerated() Option {
+	return func(d *Decoder) {
+		d.reflective = true
+	}
+}
+
+// Key returns the tag key the decoder binds
+func (d *Decoder) Key() string {
+	return d.key
+}
+
+// Get returns the raw value of key from the getter of the decoder
+func (d *Decoder) Get(key string) any {
+	return d.getter.Get(key)
+}
+
+// DecodeField decodes a single field of v, which must be a pointer to a struct, by its name with reflection.
+// Generated decoders fall back to it for the fields they cannot decode.
+func (d *Decoder) DecodeField(v any, name string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return &InvalidUnmarshalError{reflect.TypeOf(v)}
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	field, ok := rt.FieldByName(name)
+	if !ok || !field.IsExported() {
+		return nil
+	}
+	raw, ok := field.Tag.Lookup(d.key)
+	if !ok {
+		return nil
+	}
+
+	return d.decodeField(rt, field, rv.FieldByIndex(field.Index), ParseTag(raw))
+}
+
+// generated decodes v with its generated decoder when it has one and the decoder has no options
+// that generated code ignores, like hooks, limits or registered tag options
+func (d *Decoder) generated(v any) (bool, error) {
+	g, ok := v.(GeneratedDecoder)
+	if !ok || d.reflective || len(d.casters) > 0 || len(d.beforeField) > 0 || d.limited() || d.locale != language.Und {
+		return false, nil
+	}
+	for _, f := range plan(reflect.TypeOf(v).Elem(), d.key) {
+		if len(handlers(f.tag)) > 0 {
+			return false, nil
+		}
+	}
+
+	return g.DecodeGenerated(d)
+}
@@ -0,0 +1,74 @@
+package structd
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Limits bound the input a single Decode consumes, zero fields are unlimited
+type Limits struct {
+	// MaxKeys is the number of keys with a value the decoder reads from its getter
+	MaxKeys int
+	// MaxValueLength is the length in bytes of a single raw value
+	MaxValueLength int
+	// MaxElements is the number of elements a slice value has, after splitting strings at the separator
+	MaxElements int
+}
+
+// WithLimits makes the decoder fail with a *LimitError on input that exceeds l. Values are checked before
+// they are cast, so a long comma separated list is rejected without splitting it.
+func WithLimits(l Limits) Option {
+	return func(d *Decoder) {
+		d.limits = l
+	}
+}
+
+// limited reports whether d has any limit
+func (d *Decoder) limited() bool {
+	return d.limits != Limits{}
+}
+
+// consume counts the raw value of key against the limits of d, to is the type the value is cast to
+func (d *Decoder) consume(key string, raw any, to reflect.Type) error {
+	if !d.limited() {
+		return nil
+	}
+
+	d.keys++
+	if d.limits.MaxKeys > 0 && d.keys > d.limits.MaxKeys {
+		return &LimitError{Limit: LimitKeys, Max: d.limits.MaxKeys, Key: key}
+	}
+
+	values := []string{}
+	switch raw := raw.(type) {
+	case string:
+		values = append(values, raw)
+	case []byte:
+		values = append(values, string(raw))
+	case []string:
+		values = raw
+	}
+
+	for _, value := range values {
+		if d.limits.MaxValueLength > 0 && len(value) > d.limits.MaxValueLength {
+			return &LimitError{Limit: LimitValueLength, Max: d.limits.MaxValueLength, Key: key}
+		}
+	}
+
+	if d.limits.MaxElements <= 0 || to.Kind() != reflect.Slice || to.Elem().Kind() == reflect.Uint8 || isUnmarshaler(to) {
+		return nil
+	}
+	elements := len(values)
+	if s, ok := raw.(string); ok {
+		sep := d.separator
+		if sep == "" {
+			sep = DefaultSeperator
+		}
+		elements = strings.Count(s, sep) + 1
+	}
+	if elements > d.limits.MaxElements {
+		return &LimitError{Limit: LimitElements, Max: d.limits.MaxElements, Key: key}
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package structd
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// WithLocale makes the decoder parse numbers and dates of every field in the conventions of the given language,
+// fields can override it with the `locale=` tag option.
+func WithLocale(locale language.Tag) Option {
+	return func(d *Decoder) {
+		d.locale = locale
+	}
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// dateLayouts are the numeric date layouts used by the most common locales
+var dateLayouts = map[string]string{
+	"en":    "01/02/2006",
+	"en-GB": "02/01/2006",
+	"de":    "02.01.2006",
+	"tr":    "02.01.2006",
+	"ru":    "02.01.2006",
+	"pl":    "02.01.2006",
+	"fr":    "02/01/2006",
+	"es":    "02/01/2006",
+	"it":    "02/01/2006",
+	"pt":    "02/01/2006",
+	"nl":    "02-01-2006",
+	"ja":    "2006/01/02",
+	"zh":    "2006/01/02",
+	"ko":    "2006. 01. 02.",
+}
+
+func dateLayout(locale language.Tag) string {
+	for t := locale; t != language.Und; t = t.Parent() {
+		if layout, ok := dateLayouts[t.String()]; ok {
+			return layout
+		}
+	}
+
+	return time.DateOnly
+}
+
+type separators struct {
+	group   rune
+	decimal rune
+}
+
+var separatorCache sync.Map
+
+// numberSeparators derives the digit grouping and decimal separators of a locale by formatting a known number
+func numberSeparators(locale language.Tag) separators {
+	if s, ok := separatorCache.Load(locale); ok {
+		return s.(separators)
+	}
+
+	s := separators{group: ',', decimal: '.'}
+	formatted := []rune(message.NewPrinter(locale).Sprintf("%.1f", 1234.5))
+	for i, r := range formatted {
+		if unicode.IsDigit(r) {
+			continue
+		}
+		if i == len(formatted)-2 {
+			s.decimal = r
+		} else {
+			s.group = r
+		}
+	}
+
+	separatorCache.Store(locale, s)
+	return s
+}
+
+// WithLocation sets the location naive date and time strings are parsed in, fields can override it
+// with the `tz=` tag option. Strings that carry their own offset are not affected.
+func WithLocation(loc *time.Location) Option {
+	return func(d *Decoder) {
+		d.location = loc
+	}
+}
+
+// WithTimeLayouts adds layouts that are tried before the default ones for time fields without a layout or
+// locale in effect, like `http.TimeFormat` for the dates of headers
+func WithTimeLayouts(layouts ...string) Option {
+	return func(d *Decoder) {
+		d.timeLayouts = append(d.timeLayouts, layouts...)
+	}
+}
+
+// timeLayouts are tried in order when a time field has no layout or locale in effect
+var timeLayouts = []string{time.RFC3339Nano, time.DateTime, "2006-01-02T15:04:05", "2006-01-02T15:04", time.DateOnly}
+
+// localize converts a localized number or date string to a value the casters understand,
+// it returns v untouched when there is no locale or layout in effect
+func (d *Decoder) localize(t TagSpec, v any, to reflect.Type) (any, error) {
+	s, ok := v.(string)
+	if !ok {
+		return v, nil
+	}
+
+	locale := d.locale
+	if option, ok := t.Lookup("locale"); ok {
+		parsed, err := language.Parse(option)
+		if err != nil {
+			return nil, &TagError{Option: "locale", Value: option, Err: err}
+		}
+		locale = parsed
+	}
+
+	if to == timeType {
+		return d.parseTime(t, locale, strings.TrimSpace(s))
+	}
+
+	switch to.Kind() {
+	case reflect.Float32, reflect.Float64, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if locale == language.Und {
+			return v, nil
+		}
+
+		sep := numberSeparators(locale)
+		s = strings.Map(func(r rune) rune {
+			switch {
+			case r == sep.decimal:
+				return '.'
+			case r == sep.group || unicode.IsSpace(r):
+				return -1
+			default:
+				return r
+			}
+		}, strings.TrimSpace(s))
+		return s, nil
+	}
+
+	return v, nil
+}
+
+// parseTime parses s with the layout, locale and location in effect for a field
+func (d *Decoder) parseTime(t TagSpec, locale language.Tag, s string) (time.Time, error) {
+	loc := time.UTC
+	if d.location != nil {
+		loc = d.location
+	}
+	if option, ok := t.Lookup("tz"); ok {
+		l, err := time.LoadLocation(option)
+		if err != nil {
+			return time.Time{}, &TagError{Option: "tz", Value: option, Err: err}
+		}
+		loc = l
+	}
+
+	layouts := append(d.timeLayouts[:len(d.timeLayouts):len(d.timeLayouts)], timeLayouts...)
+	if layout, ok := t.Lookup("layout"); ok {
+		layouts = []string{layout}
+	} else if locale != language.Und {
+		layouts = []string{dateLayout(locale)}
+	}
+
+	var err error
+	for _, layout := range layouts {
+		var parsed time.Time
+		parsed, err = time.ParseInLocation(layout, s, loc)
+		if err == nil {
+			return parsed, nil
+		}
+	}
+
+	return time.Time{}, err
+}
@@ -0,0 +1,99 @@
+package structd
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// plannedField is a tagged field of a struct with its parsed tag
+type plannedField struct {
+	index int
+	field reflect.StructField
+	tag   TagSpec
+}
+
+type planKey struct {
+	t   reflect.Type
+	key string
+}
+
+// plans caches the tagged fields of struct types by tag key, they are immutable once built
+var plans sync.Map
+
+var wrapperType = reflect.TypeFor[Wrapper]()
+
+// plan returns the exported fields of t that have the key tag, in field order
+func plan(t reflect.Type, key string) []plannedField {
+	k := planKey{t: t, key: key}
+	if p, ok := plans.Load(k); ok {
+		return p.([]plannedField)
+	}
+
+	fields := []plannedField{}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		raw, ok := field.Tag.Lookup(key)
+		if !ok {
+			continue
+		}
+
+		fields = append(fields, plannedField{
+			index: i,
+			field: field,
+			tag:   ParseTag(raw),
+		})
+	}
+
+	p, _ := plans.LoadOrStore(k, fields)
+	return p.([]plannedField)
+}
+
+// duplicates caches the results of Validate by struct type and tag key
+var duplicates sync.Map
+
+// Validate reports the fields of t that share a name of the key tag, as *DuplicateTagErrors in the order of
+// their first field
+func Validate(t reflect.Type, key string) error {
+	if t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return fmt.Errorf("structd: cannot validate non-struct type %v", t)
+	}
+
+	k := planKey{t: t, key: key}
+	if err, ok := duplicates.Load(k); ok {
+		return err.(validation).err
+	}
+
+	names := []string{}
+	fields := map[string][]string{}
+	for _, f := range plan(t, key) {
+		if _, ok := fields[f.tag.Name]; !ok {
+			names = append(names, f.tag.Name)
+		}
+		fields[f.tag.Name] = append(fields[f.tag.Name], f.field.Name)
+	}
+
+	errs := []error{}
+	for _, name := range names {
+		if len(fields[name]) > 1 {
+			errs = append(errs, &DuplicateTagError{Struct: t.Name(), Key: key, Name: name, Fields: fields[name]})
+		}
+	}
+
+	err := errors.Join(errs...)
+	duplicates.Store(k, validation{err})
+	return err
+}
+
+// validation is a cached result of Validate, which may be nil
+type validation struct {
+	err error
+}
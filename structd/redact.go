@@ -0,0 +1,77 @@
+package structd
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Redacted replaces the values of fields tagged with the `secret` option in errors
+const Redacted = "[REDACTED]"
+
+// redactedError hides every occurrence of a secret value in the message of the error it wraps
+type redactedError struct {
+	err     error
+	secrets []string
+}
+
+func (e *redactedError) Error() string {
+	msg := e.err.Error()
+	for _, secret := range e.secrets {
+		msg = strings.ReplaceAll(msg, secret, Redacted)
+	}
+
+	return msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redact scrubs the raw value of a secret field from err and the errors it wraps
+func redact(err error, raw any) error {
+	if err == nil {
+		return nil
+	}
+
+	var uerr *UnmarshalerError
+	if errors.As(err, &uerr) {
+		uerr.Value = Redacted
+	}
+	var nerr *strconv.NumError
+	if errors.As(err, &nerr) {
+		nerr.Num = Redacted
+	}
+
+	switch raw := raw.(type) {
+	case string:
+		return Redact(err, raw)
+	case []string:
+		return Redact(err, raw...)
+	}
+
+	return err
+}
+
+// Redact hides every occurrence of the secrets in the message of err, it still unwraps to err
+func Redact(err error, secrets ...string) error {
+	secrets = nonEmpty(slices.Clone(secrets))
+	if err == nil || len(secrets) == 0 {
+		return err
+	}
+
+	return &redactedError{err: err, secrets: secrets}
+}
+
+// nonEmpty drops the empty strings from s, which would otherwise match everywhere
+func nonEmpty(s []string) []string {
+	result := s[:0]
+	for _, entry := range s {
+		if entry != "" {
+			result = append(result, entry)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,97 @@
+package structd
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	implementationsMu sync.RWMutex
+	implementations   = map[reflect.Type]map[string]reflect.Type{}
+)
+
+// RegisterImplementation registers the type of impl as the concrete type to decode into fields of
+// interface type I when their discriminator reads name. Registering a pointer makes the field hold a pointer.
+//
+//	structd.RegisterImplementation[Shape]("circle", Circle{})
+func RegisterImplementation[I any](name string, impl I) {
+	it := reflect.TypeFor[I]()
+	if it.Kind() != reflect.Interface {
+		panic("structd: RegisterImplementation of non-interface type " + it.String())
+	}
+
+	ct := reflect.TypeOf(impl)
+	if ct == nil {
+		panic("structd: RegisterImplementation of nil implementation for " + it.String())
+	}
+	if ct.Kind() != reflect.Struct && (ct.Kind() != reflect.Pointer || ct.Elem().Kind() != reflect.Struct) {
+		panic("structd: RegisterImplementation of non-struct implementation " + ct.String())
+	}
+
+	implementationsMu.Lock()
+	defer implementationsMu.Unlock()
+
+	if implementations[it] == nil {
+		implementations[it] = map[string]reflect.Type{}
+	}
+	implementations[it][name] = ct
+}
+
+func hasImplementations(iface reflect.Type) bool {
+	implementationsMu.RLock()
+	defer implementationsMu.RUnlock()
+
+	return len(implementations[iface]) > 0
+}
+
+func lookupImplementation(iface reflect.Type, name string) (reflect.Type, bool) {
+	implementationsMu.RLock()
+	defer implementationsMu.RUnlock()
+
+	ct, ok := implementations[iface][name]
+	return ct, ok
+}
+
+// decodeImplementation reads the discriminator of an interface field, then decodes the getter
+// into the registered implementation and returns it
+func (d *Decoder) decodeImplementation(t TagSpec, iface reflect.Type) (reflect.Value, error) {
+	key := t.Name
+	if k, ok := t.Lookup("discriminator"); ok {
+		key = k
+	}
+
+	name, ok := d.getter.Get(key).(string)
+	if !ok || name == "" {
+		return reflect.Value{}, nil
+	}
+
+	ct, ok := lookupImplementation(iface, name)
+	if !ok {
+		return reflect.Value{}, &ImplementationError{Interface: iface, Name: name}
+	}
+
+	ptr := reflect.New(ct)
+	if ct.Kind() == reflect.Pointer {
+		ptr.Elem().Set(reflect.New(ct.Elem()))
+		ptr = ptr.Elem()
+	}
+	if err := d.Decode(ptr.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+
+	if ct.Kind() == reflect.Pointer {
+		return ptr, nil
+	}
+	return ptr.Elem(), nil
+}
+
+// An ImplementationError describes a discriminator that has no implementation registered for an interface.
+type ImplementationError struct {
+	Interface reflect.Type
+	Name      string
+}
+
+func (e *ImplementationError) Error() string {
+	return fmt.Sprintf("structd: no implementation of %s registered as %q", e.Interface, e.Name)
+}
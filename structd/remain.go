@@ -0,0 +1,81 @@
+package structd
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+)
+
+// A KeyGetter is a Getter that can list its keys. A field with the `remain` option, like
+// `Extra map[string]string query:",remain"`, receives the keys of a KeyGetter that no other field binds.
+type KeyGetter interface {
+	Keys() []string
+}
+
+// A KeyMatcher is a KeyGetter that matches tag names to its keys in its own way, like case-insensitively
+type KeyMatcher interface {
+	MatchKey(name, key string) bool
+}
+
+// binds reports whether a field of fields other than the remain ones binds key
+func (d *Decoder) binds(fields []plannedField, key string) bool {
+	m, ok := d.getter.(KeyMatcher)
+	return slices.ContainsFunc(fields, func(f plannedField) bool {
+		if f.tag.Has("remain") {
+			return false
+		}
+		if ok {
+			return m.MatchKey(f.tag.Name, key)
+		}
+		return f.tag.Name == key
+	})
+}
+
+// decodeRemain sets the map field value of rt to the values of the keys of the getter that no other field
+// binds, casting them to the element type of the map. It leaves the field untouched when the getter cannot
+// list its keys or every key is bound.
+func (d *Decoder) decodeRemain(rt reflect.Type, field reflect.StructField, value reflect.Value) error {
+	if field.Type.Kind() != reflect.Map || field.Type.Key().Kind() != reflect.String {
+		return &UnmarshalTypeError{Value: "remain keys", Type: field.Type, Struct: rt.Name(), Field: field.Name}
+	}
+	g, ok := d.getter.(KeyGetter)
+	if !ok {
+		return nil
+	}
+
+	fields := plan(rt, d.key)
+	to := field.Type.Elem()
+	result := reflect.MakeMap(field.Type)
+	for _, key := range g.Keys() {
+		if d.binds(fields, key) {
+			continue
+		}
+
+		raw := d.get(key, to)
+		if raw == nil {
+			continue
+		}
+		rv := reflect.ValueOf(raw)
+		if rv.IsZero() && !rv.Type().AssignableTo(to) {
+			continue
+		}
+		if err := d.consume(key, raw, to); err != nil {
+			return err
+		}
+		tv, err := d.cast(rv, to, key)
+		if err != nil {
+			var terr *UnmarshalTypeError
+			if errors.As(err, &terr) {
+				terr.Struct = rt.Name()
+				terr.Field = field.Name
+			}
+			return err
+		}
+		result.SetMapIndex(reflect.ValueOf(key).Convert(field.Type.Key()), tv)
+	}
+
+	if result.Len() > 0 {
+		value.Set(result)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package structd
+
+import (
+	"strings"
+	"sync"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// A Sanitizer transforms a raw string value before it is cast
+type Sanitizer func(string) string
+
+var (
+	sanitizersMu sync.RWMutex
+	sanitizers   = map[string]Sanitizer{
+		"trim":  strings.TrimSpace,
+		"lower": strings.ToLower,
+		"upper": strings.ToUpper,
+		"nfkc":  norm.NFKC.String,
+	}
+)
+
+// RegisterSanitizer registers s under the tag option name, it is applied to the
+// string values of every field tagged with that option.
+func RegisterSanitizer(name string, s Sanitizer) {
+	sanitizersMu.Lock()
+	defer sanitizersMu.Unlock()
+
+	sanitizers[name] = s
+}
+
+// sanitize applies the sanitizers named in the tag options to v, in the order they are given
+func sanitize(t TagSpec, v any) any {
+	sanitizersMu.RLock()
+	defer sanitizersMu.RUnlock()
+
+	for _, key := range t.keys {
+		s, ok := sanitizers[key]
+		if !ok {
+			continue
+		}
+
+		switch value := v.(type) {
+		case string:
+			v = s(value)
+		case []string:
+			result := make([]string, len(value))
+			for i, entry := range value {
+				result[i] = s(entry)
+			}
+			v = result
+		}
+	}
+
+	return v
+}
@@ -0,0 +1,123 @@
+package structd
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// A TagSpec is a parsed field tag in the form of `name,option,option=value`
+type TagSpec struct {
+	// Name is the name of the value the field binds
+	Name string
+	// Options are the values of the options by name, options without a value map to an empty string
+	Options map[string]string
+	keys    []string
+}
+
+// Lookup returns the value of option and whether the tag has it
+func (t TagSpec) Lookup(option string) (string, bool) {
+	v, ok := t.Options[option]
+	return v, ok
+}
+
+// Has reports whether the tag has option
+func (t TagSpec) Has(option string) bool {
+	_, ok := t.Options[option]
+	return ok
+}
+
+// Keys returns the names of the options in the order they are given
+func (t TagSpec) Keys() []string {
+	return slices.Clone(t.keys)
+}
+
+// clone returns a copy of t that does not share its options
+func (t TagSpec) clone() TagSpec {
+	return TagSpec{Name: t.Name, Options: maps.Clone(t.Options), keys: slices.Clone(t.keys)}
+}
+
+// ParseTag parses a tag value like `name,trim,maxlen=32`. Options are trimmed and the last value of a
+// repeated option wins.
+func ParseTag(s string) TagSpec {
+	parts := strings.Split(s, ",")
+	t := TagSpec{
+		Name:    parts[0],
+		Options: map[string]string{},
+	}
+
+	for _, part := range parts[1:] {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		key, value, _ := strings.Cut(part, "=")
+		if _, ok := t.Options[key]; !ok {
+			t.keys = append(t.keys, key)
+		}
+		t.Options[key] = value
+	}
+
+	return t
+}
+
+// An OptionHandler handles a custom tag option. It receives the field, the value of the option and the
+// raw value before it is cast, and returns the value to use in its place. Returning nil leaves the field untouched.
+type OptionHandler func(field reflect.StructField, option string, raw any) (any, error)
+
+var (
+	optionsMu sync.RWMutex
+	options   = map[string]OptionHandler{}
+)
+
+// RegisterOption registers h as the handler of the tag option name. It runs for every field tagged with
+// that option after the sanitizers, in the order the options are given.
+//
+//	structd.RegisterOption("encrypted", func(field reflect.StructField, option string, raw any) (any, error) {
+//		return decrypt(raw.(string))
+//	})
+func RegisterOption(name string, h OptionHandler) {
+	optionsMu.Lock()
+	defer optionsMu.Unlock()
+
+	options[name] = h
+}
+
+// handlers returns the registered handlers of the options of t, in the order they are given
+func handlers(t TagSpec) []namedHandler {
+	optionsMu.RLock()
+	defer optionsMu.RUnlock()
+
+	result := []namedHandler{}
+	for _, key := range t.keys {
+		if h, ok := options[key]; ok {
+			result = append(result, namedHandler{name: key, handler: h})
+		}
+	}
+	return result
+}
+
+type namedHandler struct {
+	name    string
+	handler OptionHandler
+}
+
+// handleOptions passes raw through the handlers of the options of t
+func handleOptions(t TagSpec, field reflect.StructField, raw any) (any, error) {
+	for _, h := range handlers(t) {
+		if raw == nil {
+			return nil, nil
+		}
+
+		var err error
+		raw, err = h.handler(field, t.Options[h.name], raw)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return raw, nil
+}
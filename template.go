@@ -0,0 +1,49 @@
+package scanner
+
+import (
+	"reflect"
+
+	"github.com/canpacis/scanner/structd"
+	"github.com/canpacis/scanner/uritemplate"
+)
+
+type templateValues map[string]string
+
+func (v templateValues) Get(key string) any {
+	return v[key]
+}
+
+func (v templateValues) Cast(from any, to reflect.Type) (any, error) {
+	return structd.DefaultCast(from, to)
+}
+
+// A scanner to scan path parameters onto a struct by matching a path against an RFC 6570 uri template
+// like `/users/{id}/posts/{slug}`, without the need of a router. It uses the `path` tag.
+type PathTemplate struct {
+	template *uritemplate.Template
+	path     string
+	config   *config
+}
+
+// Scans the path parameters onto v, it fails with `uritemplate.ErrMismatch` when the path does not match the template
+func (s *PathTemplate) Scan(v any) error {
+	values, err := s.template.Match(s.path)
+	if err != nil {
+		return err
+	}
+
+	return s.config.decoder(templateValues(values), "path").Decode(v)
+}
+
+func NewPathTemplate(template, path string, opts ...Option) (*PathTemplate, error) {
+	t, err := uritemplate.Parse(template)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PathTemplate{
+		template: t,
+		path:     path,
+		config:   newConfig(opts),
+	}, nil
+}
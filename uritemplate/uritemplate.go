@@ -0,0 +1,310 @@
+// Package uritemplate implements RFC 6570 uri templates, expanding them with values and
+// matching uris against them to extract the values back.
+package uritemplate
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrMismatch is returned when a uri does not match a template
+var ErrMismatch = errors.New("uritemplate: uri does not match the template")
+
+// A Variable is a single variable of a template expression
+type Variable struct {
+	Name     string
+	Operator byte // 0 for simple string expansion, or one of + # . / ; ? &
+	Prefix   int  // the `:n` prefix modifier, 0 when absent
+	Explode  bool // the `*` explode modifier
+}
+
+type operator struct {
+	first    string
+	sep      string
+	named    bool
+	ifEmpty  string
+	reserved bool
+}
+
+var operators = map[byte]operator{
+	0:   {first: "", sep: ","},
+	'+': {first: "", sep: ",", reserved: true},
+	'#': {first: "#", sep: ",", reserved: true},
+	'.': {first: ".", sep: "."},
+	'/': {first: "/", sep: "/"},
+	';': {first: ";", sep: ";", named: true},
+	'?': {first: "?", sep: "&", named: true, ifEmpty: "="},
+	'&': {first: "&", sep: "&", named: true, ifEmpty: "="},
+}
+
+type part struct {
+	literal   string
+	operator  byte
+	variables []Variable
+}
+
+// A Template is a parsed uri template
+type Template struct {
+	raw     string
+	parts   []part
+	pattern *regexp.Regexp
+	names   []string
+}
+
+// String returns the template as it was parsed
+func (t *Template) String() string {
+	return t.raw
+}
+
+// Variables returns the variables of the template in the order they appear
+func (t *Template) Variables() []Variable {
+	variables := []Variable{}
+	for _, p := range t.parts {
+		variables = append(variables, p.variables...)
+	}
+
+	return variables
+}
+
+// Expand expands the template with values, a variable with more than one value is expanded as a list.
+// Variables without values are left out as the rfc describes.
+func (t *Template) Expand(values map[string][]string) string {
+	var b strings.Builder
+
+	for _, p := range t.parts {
+		if p.variables == nil {
+			b.WriteString(p.literal)
+			continue
+		}
+
+		op := operators[p.operator]
+		first := true
+		for _, v := range p.variables {
+			list, ok := values[v.Name]
+			if !ok || len(list) == 0 {
+				continue
+			}
+
+			if first {
+				b.WriteString(op.first)
+				first = false
+			} else {
+				b.WriteString(op.sep)
+			}
+
+			if len(list) == 1 && !v.Explode {
+				value := list[0]
+				if v.Prefix > 0 && len([]rune(value)) > v.Prefix {
+					value = string([]rune(value)[:v.Prefix])
+				}
+				writeNamed(&b, op, v.Name, escape(value, op.reserved))
+				continue
+			}
+
+			if v.Explode {
+				for i, value := range list {
+					if i > 0 {
+						b.WriteString(op.sep)
+					}
+					writeNamed(&b, op, v.Name, escape(value, op.reserved))
+				}
+				continue
+			}
+
+			escaped := make([]string, len(list))
+			for i, value := range list {
+				escaped[i] = escape(value, op.reserved)
+			}
+			writeNamed(&b, op, v.Name, strings.Join(escaped, ","))
+		}
+	}
+
+	return b.String()
+}
+
+func writeNamed(b *strings.Builder, op operator, name, value string) {
+	if !op.named {
+		b.WriteString(value)
+		return
+	}
+
+	b.WriteString(name)
+	if value == "" {
+		b.WriteString(op.ifEmpty)
+		return
+	}
+	b.WriteString("=")
+	b.WriteString(value)
+}
+
+const unreserved = "-._~"
+const reservedChars = ":/?#[]@!$&'()*+,;="
+
+// escape percent-encodes value, reserved characters are kept for the `+` and `#` operators
+func escape(value string, reserved bool) string {
+	var b strings.Builder
+
+	for _, c := range []byte(value) {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', strings.IndexByte(unreserved, c) >= 0:
+			b.WriteByte(c)
+		case reserved && (strings.IndexByte(reservedChars, c) >= 0 || c == '%'):
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+// Match matches a uri path against the template and returns the values of its variables.
+// Query expressions (`?` and `&`) are ignored, only the path is matched.
+func (t *Template) Match(path string) (map[string]string, error) {
+	matches := t.pattern.FindStringSubmatch(path)
+	if matches == nil {
+		return nil, ErrMismatch
+	}
+
+	values := map[string]string{}
+	for i, name := range t.names {
+		value, err := url.PathUnescape(matches[i+1])
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := values[name]; !ok || value != "" {
+			values[name] = value
+		}
+	}
+
+	return values, nil
+}
+
+// compile builds the regular expression used to match uris against the template
+func (t *Template) compile() error {
+	var b strings.Builder
+	b.WriteString("^")
+
+	for _, p := range t.parts {
+		if p.variables == nil {
+			b.WriteString(regexp.QuoteMeta(p.literal))
+			continue
+		}
+
+		for i, v := range p.variables {
+			var prefix, value string
+			switch p.operator {
+			case 0:
+				prefix, value = ",", `[^/?#,]*?`
+			case '+':
+				prefix, value = ",", `[^?#,]*?`
+			case '#':
+				prefix, value = ",", `[^,]*?`
+			case '.':
+				prefix, value = `\.`, `[^/?#.]*?`
+			case '/':
+				prefix, value = "/", `[^/?#]*?`
+			case ';':
+				prefix, value = ";"+regexp.QuoteMeta(v.Name)+"=?", `[^/?#;]*?`
+			case '?', '&':
+				continue
+			}
+
+			if v.Explode && (p.operator == '/' || p.operator == '.') {
+				value = "(?:" + value + prefix + ")*" + value
+			}
+
+			if i == 0 {
+				switch p.operator {
+				case 0, '+':
+					prefix = ""
+				case '#':
+					prefix = "#"
+				}
+			}
+
+			b.WriteString("(?:" + prefix + "(" + value + "))?")
+			t.names = append(t.names, v.Name)
+		}
+	}
+	b.WriteString(`(?:\?.*)?$`)
+
+	pattern, err := regexp.Compile(b.String())
+	if err != nil {
+		return err
+	}
+	t.pattern = pattern
+	return nil
+}
+
+// Parse parses a uri template
+func Parse(raw string) (*Template, error) {
+	t := &Template{raw: raw}
+	s := raw
+
+	for s != "" {
+		start := strings.IndexByte(s, '{')
+		if start < 0 {
+			t.parts = append(t.parts, part{literal: s})
+			break
+		}
+		if start > 0 {
+			t.parts = append(t.parts, part{literal: s[:start]})
+		}
+
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			return nil, errors.New("uritemplate: unterminated expression in " + raw)
+		}
+		expression := s[start+1 : start+end]
+		s = s[start+end+1:]
+
+		p := part{variables: []Variable{}}
+		if expression != "" && strings.IndexByte("+#./;?&", expression[0]) >= 0 {
+			p.operator = expression[0]
+			expression = expression[1:]
+		}
+
+		for _, spec := range strings.Split(expression, ",") {
+			v := Variable{Operator: p.operator}
+			if name, ok := strings.CutSuffix(spec, "*"); ok {
+				v.Explode = true
+				spec = name
+			}
+			if name, prefix, ok := strings.Cut(spec, ":"); ok {
+				n, err := strconv.Atoi(prefix)
+				if err != nil || n <= 0 || n >= 10000 {
+					return nil, errors.New("uritemplate: invalid prefix modifier in " + raw)
+				}
+				v.Prefix = n
+				spec = name
+			}
+			if spec == "" {
+				return nil, errors.New("uritemplate: empty variable name in " + raw)
+			}
+
+			v.Name = spec
+			p.variables = append(p.variables, v)
+		}
+		t.parts = append(t.parts, p)
+	}
+
+	if err := t.compile(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// MustParse is like Parse but panics when the template cannot be parsed
+func MustParse(raw string) *Template {
+	t, err := Parse(raw)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
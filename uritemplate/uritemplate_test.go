@@ -0,0 +1,63 @@
+package uritemplate_test
+
+import (
+	"testing"
+
+	"github.com/canpacis/scanner/uritemplate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpand(t *testing.T) {
+	values := map[string][]string{
+		"var":   {"value"},
+		"hello": {"Hello World!"},
+		"path":  {"/foo/bar"},
+		"list":  {"red", "green", "blue"},
+		"x":     {"1024"},
+		"y":     {"768"},
+		"empty": {""},
+	}
+
+	cases := map[string]string{
+		"{var}":             "value",
+		"{hello}":           "Hello%20World%21",
+		"{+path}/here":      "/foo/bar/here",
+		"{#path}":           "#/foo/bar",
+		"X{.var}":           "X.value",
+		"{/var,x}/here":     "/value/1024/here",
+		"{;x,y,empty}":      ";x=1024;y=768;empty",
+		"{?x,y,undef}":      "?x=1024&y=768",
+		"?fixed=yes{&x}":    "?fixed=yes&x=1024",
+		"{var:3}":           "val",
+		"{list}":            "red,green,blue",
+		"{/list*}":          "/red/green/blue",
+		"{?list*}":          "?list=red&list=green&list=blue",
+		"/users/{var}/tail": "/users/value/tail",
+	}
+
+	for template, expected := range cases {
+		assert.Equal(t, expected, uritemplate.MustParse(template).Expand(values), template)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	assert := assert.New(t)
+
+	values, err := uritemplate.MustParse("/users/{id}/posts/{slug}{?page}").Match("/users/42/posts/hello%20world?page=2")
+	assert.NoError(err)
+	assert.Equal(map[string]string{"id": "42", "slug": "hello world"}, values)
+
+	values, err = uritemplate.MustParse("/files{/dir}{.ext}").Match("/files/docs.pdf")
+	assert.NoError(err)
+	assert.Equal(map[string]string{"dir": "docs", "ext": "pdf"}, values)
+
+	values, err = uritemplate.MustParse("/static/{+path}").Match("/static/css/site.css")
+	assert.NoError(err)
+	assert.Equal(map[string]string{"path": "css/site.css"}, values)
+
+	_, err = uritemplate.MustParse("/users/{id}").Match("/posts/42")
+	assert.ErrorIs(err, uritemplate.ErrMismatch)
+
+	_, err = uritemplate.Parse("/users/{id")
+	assert.Error(err)
+}
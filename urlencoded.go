@@ -0,0 +1,93 @@
+package scanner
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// WithLiteralPlus makes the query and form scanners of raw strings keep `+` as a plus sign instead of
+// decoding it as a space, like the path segments of RFC 3986
+func WithLiteralPlus() Option {
+	return func(c *config) {
+		c.literalPlus = true
+	}
+}
+
+// WithLenientEscapes makes the query and form scanners of raw strings keep malformed percent escapes like
+// `%zz` as they are instead of failing, like php does
+func WithLenientEscapes() Option {
+	return func(c *config) {
+		c.lenientEscapes = true
+	}
+}
+
+// parseQuery parses a raw `application/x-www-form-urlencoded` string like `url.ParseQuery`, following the
+// plus sign, escape and charset options. It fails on the first malformed pair.
+func (c *config) parseQuery(raw string) (url.Values, error) {
+	if !c.literalPlus && !c.lenientEscapes {
+		values, err := url.ParseQuery(raw)
+		if err != nil {
+			return nil, err
+		}
+		return c.transcodeValues(values)
+	}
+
+	values := url.Values{}
+	for _, pair := range strings.Split(raw, "&") {
+		if pair == "" {
+			continue
+		}
+		if strings.Contains(pair, ";") {
+			return nil, errors.New("invalid semicolon separator in query")
+		}
+
+		key, value, _ := strings.Cut(pair, "=")
+		key, err := c.unescape(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err = c.unescape(value)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = append(values[key], value)
+	}
+
+	return c.transcodeValues(values)
+}
+
+// unescape decodes the percent escapes of s, and its plus signs as spaces unless they are literal
+func (c *config) unescape(s string) (string, error) {
+	if !c.literalPlus {
+		s = strings.ReplaceAll(s, "+", " ")
+	}
+	if !c.lenientEscapes {
+		return url.PathUnescape(s)
+	}
+
+	b := strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			if c, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String(), nil
+}
+
+// NewFormString creates a form scanner of a raw `application/x-www-form-urlencoded` body like `a=1&b=2`,
+// `scanner.WithLiteralPlus` and `scanner.WithLenientEscapes` change how it is decoded
+func NewFormString(form string, opts ...Option) (*Form, error) {
+	values, err := newConfig(opts).parseQuery(form)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewForm(values, opts...), nil
+}
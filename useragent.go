@@ -0,0 +1,172 @@
+package scanner
+
+import "strings"
+
+// DeviceClass is the kind of device a user agent runs on
+type DeviceClass string
+
+const (
+	DeviceUnknown DeviceClass = ""
+	DeviceDesktop DeviceClass = "desktop"
+	DeviceMobile  DeviceClass = "mobile"
+	DeviceTablet  DeviceClass = "tablet"
+	DeviceBot     DeviceClass = "bot"
+)
+
+// UserAgent is a parsed User-Agent header, it can be the destination of a `header:"user-agent"` field.
+// Parsing is heuristic and covers the common browsers, operating systems, crawlers and http clients.
+type UserAgent struct {
+	Raw       string
+	Browser   string
+	Version   string
+	OS        string
+	OSVersion string
+	Device    DeviceClass
+	Bot       bool
+}
+
+func (u *UserAgent) UnmarshalString(s string) error {
+	*u = ParseUserAgent(s)
+	return nil
+}
+
+// IsMobile reports whether the user agent runs on a phone or a tablet
+func (u UserAgent) IsMobile() bool {
+	return u.Device == DeviceMobile || u.Device == DeviceTablet
+}
+
+// uaProduct is a product token that identifies a browser or a bot, the first match wins
+type uaProduct struct {
+	token string
+	name  string
+}
+
+var (
+	uaBots = []uaProduct{
+		{"Googlebot/", "Googlebot"},
+		{"bingbot/", "Bingbot"},
+		{"DuckDuckBot/", "DuckDuckBot"},
+		{"YandexBot/", "YandexBot"},
+		{"Baiduspider/", "Baiduspider"},
+		{"facebookexternalhit/", "Facebook"},
+		{"Twitterbot/", "Twitterbot"},
+		{"Slackbot", "Slackbot"},
+		{"curl/", "curl"},
+		{"Wget/", "Wget"},
+		{"python-requests/", "python-requests"},
+		{"Go-http-client/", "Go-http-client"},
+		{"PostmanRuntime/", "Postman"},
+	}
+	uaBrowsers = []uaProduct{
+		{"Edg/", "Edge"},
+		{"EdgA/", "Edge"},
+		{"EdgiOS/", "Edge"},
+		{"OPR/", "Opera"},
+		{"SamsungBrowser/", "Samsung Internet"},
+		{"Firefox/", "Firefox"},
+		{"FxiOS/", "Firefox"},
+		{"CriOS/", "Chrome"},
+		{"Chrome/", "Chrome"},
+		{"Version/", "Safari"},
+		{"MSIE ", "Internet Explorer"},
+		{"Trident/", "Internet Explorer"},
+	}
+	uaWindows = map[string]string{
+		"10.0": "10",
+		"6.3":  "8.1",
+		"6.2":  "8",
+		"6.1":  "7",
+		"6.0":  "Vista",
+	}
+)
+
+// ParseUserAgent parses a User-Agent header value
+func ParseUserAgent(s string) UserAgent {
+	u := UserAgent{Raw: s}
+	if strings.TrimSpace(s) == "" {
+		return u
+	}
+
+	for _, bot := range uaBots {
+		if version, ok := uaVersion(s, bot.token); ok {
+			u.Browser, u.Version, u.Bot = bot.name, version, true
+			break
+		}
+	}
+	if !u.Bot {
+		lower := strings.ToLower(s)
+		for _, word := range []string{"bot", "crawler", "spider", "slurp"} {
+			if strings.Contains(lower, word) {
+				u.Bot = true
+				break
+			}
+		}
+	}
+
+	if u.Browser == "" {
+		for _, browser := range uaBrowsers {
+			version, ok := uaVersion(s, browser.token)
+			if !ok {
+				continue
+			}
+			if browser.token == "Version/" && !strings.Contains(s, "Safari/") {
+				continue
+			}
+			if browser.token == "Trident/" {
+				version, _ = uaVersion(s, "rv:")
+			}
+			u.Browser, u.Version = browser.name, version
+			break
+		}
+	}
+
+	switch {
+	case strings.Contains(s, "Windows NT "):
+		version, _ := uaVersion(s, "Windows NT ")
+		u.OS, u.OSVersion = "Windows", uaWindows[version]
+	case strings.Contains(s, "iPhone OS "), strings.Contains(s, "CPU OS "):
+		version, ok := uaVersion(s, "iPhone OS ")
+		if !ok {
+			version, _ = uaVersion(s, "CPU OS ")
+		}
+		u.OS, u.OSVersion = "iOS", strings.ReplaceAll(version, "_", ".")
+	case strings.Contains(s, "Mac OS X"):
+		version, _ := uaVersion(s, "Mac OS X ")
+		u.OS, u.OSVersion = "macOS", strings.ReplaceAll(version, "_", ".")
+	case strings.Contains(s, "Android"):
+		version, _ := uaVersion(s, "Android ")
+		u.OS, u.OSVersion = "Android", version
+	case strings.Contains(s, "CrOS"):
+		u.OS = "ChromeOS"
+	case strings.Contains(s, "Linux"):
+		u.OS = "Linux"
+	}
+
+	switch {
+	case u.Bot:
+		u.Device = DeviceBot
+	case strings.Contains(s, "iPad"), strings.Contains(s, "Tablet"), u.OS == "Android" && !strings.Contains(s, "Mobile"):
+		u.Device = DeviceTablet
+	case strings.Contains(s, "Mobi"), strings.Contains(s, "iPhone"):
+		u.Device = DeviceMobile
+	case u.OS != "":
+		u.Device = DeviceDesktop
+	}
+
+	return u
+}
+
+// uaVersion returns the version that follows token in s
+func uaVersion(s, token string) (string, bool) {
+	i := strings.Index(s, token)
+	if i < 0 {
+		return "", false
+	}
+
+	rest := s[i+len(token):]
+	end := strings.IndexAny(rest, " ;)(")
+	if end >= 0 {
+		rest = rest[:end]
+	}
+	return rest, true
+}
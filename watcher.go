@@ -0,0 +1,220 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"io/fs"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+	sum     [sha256.Size]byte
+}
+
+// A Watcher keeps a struct in sync with the files of an `fs.FS` using the `file` tag, or the tag of `scanner.WithTag`.
+// On every poll, only the fields whose underlying files changed are re-read and re-bound.
+//
+// Polls bind a copy of the struct and swap it in once it is bound, then copy it onto the watched struct. Code
+// that reads the struct while `Watch` polls in another goroutine reads it through Load instead, which is safe
+// for concurrent use.
+type Watcher struct {
+	mu          sync.Mutex
+	fsys        fs.FS
+	v           any
+	current     atomic.Value
+	state       map[string]fileState
+	subscribers []func(changed []string)
+	config      *config
+}
+
+// Subscribe registers fn to be called with the changed field paths after every poll that changed v
+func (w *Watcher) Subscribe(fn func(changed []string)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Poll checks the watched files for changes using their modification times and content hashes,
+// re-binds the affected fields and returns their paths.
+func (w *Watcher) Poll() ([]string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	key := "file"
+	if w.config.tag != "" {
+		key = w.config.tag
+	}
+	rv := reflect.ValueOf(w.v).Elem()
+	fields := tagFields(rv.Type(), key)
+	changed := map[string]io.Reader{}
+	states := map[string]fileState{}
+	removed := []string{}
+
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		info, err := fs.Stat(w.fsys, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			if _, ok := w.state[name]; ok {
+				removed = append(removed, name)
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		prev, ok := w.state[name]
+		if ok && prev.modTime.Equal(info.ModTime()) && prev.size == info.Size() {
+			continue
+		}
+
+		b, err := fs.ReadFile(w.fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		state := fileState{modTime: info.ModTime(), size: info.Size(), sum: sha256.Sum256(b)}
+		if ok && prev.sum == state.sum {
+			w.state[name] = state
+			continue
+		}
+		changed[name] = bytes.NewReader(b)
+		states[name] = state
+	}
+
+	if len(changed) == 0 && len(removed) == 0 {
+		return []string{}, nil
+	}
+
+	next := reflect.New(rv.Type())
+	next.Elem().Set(reflect.ValueOf(w.current.Load()).Elem())
+	if len(changed) > 0 {
+		d := &Directory{files: changed, config: w.config}
+		if err := w.config.decoder(changedFiles{d}, "file").Decode(next.Interface()); err != nil {
+			return nil, err
+		}
+	}
+
+	paths := []string{}
+	for name := range changed {
+		paths = append(paths, fields[name]...)
+	}
+	for _, name := range removed {
+		for _, path := range fields[name] {
+			field := next.Elem().FieldByName(path)
+			field.Set(reflect.Zero(field.Type()))
+		}
+		paths = append(paths, fields[name]...)
+		delete(w.state, name)
+	}
+	slices.Sort(paths)
+
+	// states are only kept once their files are bound, so files that fail to bind are read again on the next poll
+	maps.Copy(w.state, states)
+	w.current.Store(next.Interface())
+	rv.Set(next.Elem())
+
+	if len(paths) > 0 {
+		for _, fn := range w.subscribers {
+			fn(paths)
+		}
+	}
+
+	return paths, nil
+}
+
+// changedFiles gets the files that changed since the last poll and nil for the rest, so the fields of
+// unchanged files keep their values
+type changedFiles struct {
+	*Directory
+}
+
+func (f changedFiles) Get(key string) any {
+	if _, ok := f.files[key]; !ok {
+		return nil
+	}
+	return f.Directory.Get(key)
+}
+
+// Load returns a copy of the latest bound struct, a pointer of the type the watcher was created with
+//
+//	config := w.Load().(*Config)
+func (w *Watcher) Load() any {
+	v := reflect.New(reflect.TypeOf(w.v).Elem())
+	v.Elem().Set(reflect.ValueOf(w.current.Load()).Elem())
+	return v.Interface()
+}
+
+// Watch polls the watched files every interval until ctx is done or a poll fails
+func (w *Watcher) Watch(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if _, err := w.Poll(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// tagFields maps the names referenced by key tags on t to the names of the fields that bind them
+func tagFields(t reflect.Type, key string) map[string][]string {
+	fields := map[string][]string{}
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag, ok := field.Tag.Lookup(key)
+		if !ok {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		fields[name] = append(fields[name], field.Name)
+	}
+
+	return fields
+}
+
+// NewWatcher binds the files of fsys onto v, which must be a pointer to a struct, and returns a watcher
+// that keeps it up to date.
+func NewWatcher(fsys fs.FS, v any, opts ...Option) (*Watcher, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("scanner: watcher target must be a non-nil pointer to a struct")
+	}
+
+	w := &Watcher{
+		fsys:   fsys,
+		v:      v,
+		state:  map[string]fileState{},
+		config: newConfig(opts),
+	}
+	initial := reflect.New(rv.Elem().Type())
+	initial.Elem().Set(rv.Elem())
+	w.current.Store(initial.Interface())
+	if _, err := w.Poll(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
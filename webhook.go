@@ -0,0 +1,197 @@
+package scanner
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/canpacis/scanner/structd"
+)
+
+var (
+	// ErrInvalidSignature is reported for webhooks without a signature that matches their body
+	ErrInvalidSignature = errors.New("scanner: invalid webhook signature")
+	// ErrExpiredSignature is reported for webhooks whose signed timestamp is outside the tolerance
+	ErrExpiredSignature = errors.New("scanner: webhook signature timestamp is outside the tolerance")
+	// ErrUnverifiedBody is returned when the body of a webhook is read before it is verified
+	ErrUnverifiedBody = errors.New("scanner: webhook body is read before it is verified")
+)
+
+// A WebhookError describes a webhook that failed verification, it usually maps to an http 401 status
+type WebhookError struct {
+	Err error
+}
+
+func (e *WebhookError) Error() string {
+	return "scanner: webhook verification failed: " + e.Err.Error()
+}
+
+func (e *WebhookError) Unwrap() error {
+	return e.Err
+}
+
+// A WebhookVerifier verifies the signature of a webhook body with its headers
+type WebhookVerifier func(h http.Header, body []byte) error
+
+// sign returns the hex HMAC-SHA256 of the parts with the secret
+func sign(secret []byte, parts ...string) []byte {
+	h := hmac.New(sha256.New, secret)
+	for _, part := range parts {
+		h.Write([]byte(part))
+	}
+	return []byte(hex.EncodeToString(h.Sum(nil)))
+}
+
+// checkTimestamp checks that a unix timestamp is within tolerance of now
+func checkTimestamp(raw string, tolerance time.Duration) error {
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if tolerance > 0 && time.Since(time.Unix(n, 0)).Abs() > tolerance {
+		return ErrExpiredSignature
+	}
+	return nil
+}
+
+// GitHubSignature verifies the `X-Hub-Signature-256` header of GitHub webhooks
+func GitHubSignature(secret []byte) WebhookVerifier {
+	return func(h http.Header, body []byte) error {
+		signature, ok := strings.CutPrefix(h.Get("X-Hub-Signature-256"), "sha256=")
+		if !ok || !hmac.Equal([]byte(signature), sign(secret, string(body))) {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+}
+
+// StripeSignature verifies the `Stripe-Signature` header of Stripe webhooks, signatures older than the
+// tolerance are rejected to prevent replays
+func StripeSignature(secret []byte, tolerance time.Duration) WebhookVerifier {
+	return func(h http.Header, body []byte) error {
+		timestamp := ""
+		signatures := [][]byte{}
+		for _, pair := range strings.Split(h.Get("Stripe-Signature"), ",") {
+			key, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+			switch key {
+			case "t":
+				timestamp = value
+			case "v1":
+				signatures = append(signatures, []byte(value))
+			}
+		}
+
+		if err := checkTimestamp(timestamp, tolerance); err != nil {
+			return err
+		}
+		expected := sign(secret, timestamp, ".", string(body))
+		for _, signature := range signatures {
+			if hmac.Equal(signature, expected) {
+				return nil
+			}
+		}
+		return ErrInvalidSignature
+	}
+}
+
+// SlackSignature verifies the `X-Slack-Signature` and `X-Slack-Request-Timestamp` headers of Slack requests,
+// signatures older than the tolerance are rejected to prevent replays
+func SlackSignature(secret []byte, tolerance time.Duration) WebhookVerifier {
+	return func(h http.Header, body []byte) error {
+		timestamp := h.Get("X-Slack-Request-Timestamp")
+		if err := checkTimestamp(timestamp, tolerance); err != nil {
+			return err
+		}
+
+		signature, ok := strings.CutPrefix(h.Get("X-Slack-Signature"), "v0=")
+		if !ok || !hmac.Equal([]byte(signature), sign(secret, "v0:", timestamp, ":", string(body))) {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+}
+
+// A scanner to verify the signature of a webhook request. It buffers the body to verify it and exposes the
+// verified bytes with Body, so it can precede a body scanner in a `scanner.Pipe`. The raw body is bound
+// to `webhook:"body"` fields.
+type Webhook struct {
+	req      *http.Request
+	verify   WebhookVerifier
+	body     []byte
+	verified bool
+	config   *config
+}
+
+func (s *Webhook) Get(key string) any {
+	if key != "body" {
+		return nil
+	}
+	return s.body
+}
+
+func (s *Webhook) Cast(from any, to reflect.Type) (any, error) {
+	if b, ok := from.([]byte); ok && to.Kind() == reflect.String {
+		return string(b), nil
+	}
+	return structd.DefaultCast(from, to)
+}
+
+// Verifies the webhook and scans its raw body onto v, failures are reported as a `*scanner.WebhookError`
+func (s *Webhook) Scan(v any) error {
+	if !s.verified {
+		body := []byte{}
+		if s.req.Body != nil {
+			b, err := io.ReadAll(s.config.limit(s.req.Body))
+			if err != nil {
+				return err
+			}
+			body = b
+		}
+
+		if err := s.verify(s.req.Header, body); err != nil {
+			return &WebhookError{Err: err}
+		}
+		s.body = body
+		s.verified = true
+		s.req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	return s.config.decoder(s, "webhook").Decode(v)
+}
+
+// Body returns a reader of the verified body, reading it before the webhook is scanned
+// fails with `scanner.ErrUnverifiedBody`
+func (s *Webhook) Body() io.Reader {
+	return &webhookBody{webhook: s}
+}
+
+type webhookBody struct {
+	webhook *Webhook
+	r       *bytes.Reader
+}
+
+func (b *webhookBody) Read(p []byte) (int, error) {
+	if !b.webhook.verified {
+		return 0, ErrUnverifiedBody
+	}
+	if b.r == nil {
+		b.r = bytes.NewReader(b.webhook.body)
+	}
+	return b.r.Read(p)
+}
+
+func NewWebhook(req *http.Request, verify WebhookVerifier, opts ...Option) *Webhook {
+	return &Webhook{
+		req:    req,
+		verify: verify,
+		config: newConfig(opts),
+	}
+}
@@ -0,0 +1,192 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Message types of websocket frames, they match the values gorilla/websocket and nhooyr.io/websocket use
+const (
+	TextFrame   = 1
+	BinaryFrame = 2
+)
+
+// A FrameReader reads whole websocket messages, gorilla's `*websocket.Conn` satisfies it
+type FrameReader interface {
+	ReadMessage() (messageType int, p []byte, err error)
+}
+
+// FrameReaderFunc adapts a function to a `scanner.FrameReader`, e.g. for nhooyr.io/websocket:
+//
+//	scanner.FrameReaderFunc(func() (int, []byte, error) {
+//		typ, p, err := conn.Read(ctx)
+//		return int(typ), p, err
+//	})
+type FrameReaderFunc func() (int, []byte, error)
+
+func (f FrameReaderFunc) ReadMessage() (int, []byte, error) {
+	return f()
+}
+
+// Frame is a single websocket message, text frames hold json and binary frames msgpack
+type Frame struct {
+	Type int
+	Data []byte
+
+	opts []Option
+}
+
+// Scans the frame onto v, json frames are decoded onto the `json` tags and msgpack frames onto the `msgpack` tags
+func (f *Frame) Scan(v any) error {
+	if f.Type != BinaryFrame {
+		return NewJSONBytes(f.Data, f.opts...).Scan(v)
+	}
+
+	decoded, err := decodeMsgpack(f.Data)
+	if err != nil {
+		return err
+	}
+	values, ok := decoded.(map[string]any)
+	if !ok {
+		return fmt.Errorf("scanner: msgpack frame is not a map")
+	}
+
+	c := newConfig(f.opts)
+	return c.decoder(&document{values: values, key: "msgpack", config: c}, "msgpack").Decode(v)
+}
+
+// Kind returns the string value of the discriminator field key of the frame, like the `type` of `{"type": "join"}`
+func (f *Frame) Kind(key string) string {
+	var values map[string]any
+	if f.Type == BinaryFrame {
+		decoded, _ := decodeMsgpack(f.Data)
+		values, _ = decoded.(map[string]any)
+	} else {
+		json.Unmarshal(f.Data, &values)
+	}
+
+	kind, _ := values[key].(string)
+	return kind
+}
+
+// A FrameHandler handles a websocket frame of a single kind
+type FrameHandler func(f *Frame) error
+
+// HandleFrame creates a frame handler that scans frames onto a new T before calling fn
+func HandleFrame[T any](fn func(v *T) error) FrameHandler {
+	return func(f *Frame) error {
+		v := new(T)
+		if err := f.Scan(v); err != nil {
+			return err
+		}
+		return fn(v)
+	}
+}
+
+// UnknownFrameError is returned by `WebSocket.Dispatch` when there is no handler for the kind of a frame
+type UnknownFrameError struct {
+	Kind string
+}
+
+func (e *UnknownFrameError) Error() string {
+	return fmt.Sprintf("scanner: no handler for websocket frame kind %q", e.Kind)
+}
+
+// A WebSocket reads frames from a websocket connection and binds them like a `bufio.Scanner`:
+//
+//	for ws.Next() {
+//		m := &Chat{}
+//		if err := ws.Scan(m); err != nil { ... }
+//	}
+//
+// or dispatches them to handlers by a discriminator field with `WebSocket.Dispatch`.
+type WebSocket struct {
+	r     FrameReader
+	frame *Frame
+	err   error
+	opts  []Option
+}
+
+// Next reads the next frame, it returns false when the connection is closed or fails
+func (w *WebSocket) Next() bool {
+	w.frame = nil
+	if w.err != nil {
+		return false
+	}
+
+	for {
+		typ, p, err := w.r.ReadMessage()
+		if err != nil {
+			w.err = err
+			return false
+		}
+		if typ != TextFrame && typ != BinaryFrame {
+			continue
+		}
+
+		w.frame = &Frame{Type: typ, Data: p, opts: w.opts}
+		return true
+	}
+}
+
+// Frame returns the current frame
+func (w *WebSocket) Frame() *Frame {
+	return w.frame
+}
+
+// Scans the current frame onto v
+func (w *WebSocket) Scan(v any) error {
+	if w.frame == nil {
+		return io.EOF
+	}
+	return w.frame.Scan(v)
+}
+
+// Err returns the error that stopped reading frames, `io.EOF` is not reported
+func (w *WebSocket) Err() error {
+	if w.err == io.EOF {
+		return nil
+	}
+	return w.err
+}
+
+// Dispatch reads frames until the connection ends or ctx is done and passes each one to the handler of
+// its kind, read from the key field of the frame. Frames of unknown kinds go to the handler of the empty
+// kind when there is one and fail with a `*scanner.UnknownFrameError` otherwise. The connection is closed
+// when ctx is done if it is an `io.Closer`.
+func (w *WebSocket) Dispatch(ctx context.Context, key string, handlers map[string]FrameHandler) error {
+	if closer, ok := w.r.(io.Closer); ok {
+		stop := context.AfterFunc(ctx, func() {
+			closer.Close()
+		})
+		defer stop()
+	}
+
+	for w.Next() {
+		kind := w.frame.Kind(key)
+		handler, ok := handlers[kind]
+		if !ok {
+			handler, ok = handlers[""]
+		}
+		if !ok {
+			return &UnknownFrameError{Kind: kind}
+		}
+		if err := handler(w.frame); err != nil {
+			return err
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return w.Err()
+}
+
+func NewWebSocket(r FrameReader, opts ...Option) *WebSocket {
+	return &WebSocket{
+		r:    r,
+		opts: opts,
+	}
+}